@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/dataset"
+	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/service"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// wjdr-cli 运维命令行工具，用法：go run ./cmd/wjdr-cli <命令> [参数]
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+
+	cfgZap := zap.NewProductionConfig()
+	cfgZap.DisableStacktrace = true
+	cfgZap.EncoderConfig.StacktraceKey = ""
+	logger, err := cfgZap.Build()
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	defer logger.Sync()
+
+	switch os.Args[1] {
+	case "bootstrap-owner":
+		bootstrapOwner(cfg, logger, os.Args[2:])
+	case "export-captcha":
+		exportCaptcha(cfg, os.Args[2:])
+	case "reencrypt-ocr-keys":
+		reencryptOCRKeys(cfg, logger, os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `用法: wjdr-cli <命令> [参数]
+
+命令:
+  bootstrap-owner -username <用户名> -password <密码>
+      创建第一个 owner 管理员（已存在启用的 owner 时拒绝执行）
+  export-captcha -out <目录> [-dir 采集目录] [-preprocessed] [-val-ratio 0.1] [-since 2006-01-02]
+      将采集的验证码样本导出为 PaddleOCR 识别训练集（images/ + rec_gt_train.txt / rec_gt_val.txt）
+  reencrypt-ocr-keys [-dry-run]
+      用当前主密钥（CREDENTIAL_KEY_ID）重新加密全部 OCR Key 凭据：加密旧版明文，或在轮换后迁移旧主密钥加密的值
+      （轮换期间 CREDENTIAL_KEYS 需同时包含新旧主密钥，完成后重启服务或热更新 OCR Keys）`)
+}
+
+// bootstrapOwner 初始化第一个 owner 管理员
+func bootstrapOwner(cfg *config.Config, logger *zap.Logger, args []string) {
+	fs := flag.NewFlagSet("bootstrap-owner", flag.ExitOnError)
+	username := fs.String("username", "", "owner 用户名")
+	password := fs.String("password", os.Getenv("ADMIN_BOOTSTRAP_PASSWORD"), "owner 密码（也可通过 ADMIN_BOOTSTRAP_PASSWORD 提供）")
+	_ = fs.Parse(args)
+
+	if *username == "" || *password == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, err := repository.NewDatabase(&cfg.Database, logger)
+	if err != nil {
+		logger.Fatal("数据库初始化失败", zap.Error(err))
+	}
+	defer db.Close()
+
+	adminService := service.NewAdminService(repository.NewAdminRepository(db.GetDB(), logger), nil, nil, service.LegacyLoginOptions{}, nil, logger)
+	result, err := adminService.BootstrapOwner(*username, *password)
+	if err != nil {
+		logger.Fatal("创建owner失败", zap.Error(err))
+	}
+	if !result.Success {
+		fmt.Fprintln(os.Stderr, result.Error)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ 已创建 owner 管理员: %s\n", *username)
+}
+
+// exportCaptcha 导出验证码样本为 PaddleOCR rec_gt 标签文件
+func exportCaptcha(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("export-captcha", flag.ExitOnError)
+	dir := fs.String("dir", cfg.OCR.CaptureDir, "采集目录（默认 CAPTCHA_CAPTURE_DIR）")
+	out := fs.String("out", "", "导出目录")
+	preprocessed := fs.Bool("preprocessed", false, "导出预处理后的图片（默认原图）")
+	valRatio := fs.Float64("val-ratio", 0.1, "验证集比例")
+	since := fs.String("since", "", "仅导出该日期（YYYY-MM-DD）之后的样本")
+	_ = fs.Parse(args)
+
+	if *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	opts := dataset.ExportOptions{OutDir: *out, UsePreprocess: *preprocessed, ValRatio: *valRatio}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "since 格式错误: %v\n", err)
+			os.Exit(2)
+		}
+		opts.Since = t
+	}
+
+	res, err := dataset.Export(*dir, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已导出到 %s：训练集 %d，验证集 %d，识别错误待标注 %d（wrong.txt），跳过 %d\n",
+		*out, res.Train, res.Val, res.Wrong, res.Skipped)
+}
+
+// reencryptOCRKeys 用当前主密钥重新加密 OCR Key 凭据
+func reencryptOCRKeys(cfg *config.Config, logger *zap.Logger, args []string) {
+	fs := flag.NewFlagSet("reencrypt-ocr-keys", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "只统计需要重新加密的 Key，不写入数据库")
+	_ = fs.Parse(args)
+
+	cipher, err := utils.NewCredentialCipher(cfg.Security.CredentialKeyID, cfg.Security.CredentialKeys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "主密钥配置错误: %v\n", err)
+		os.Exit(2)
+	}
+	if !cipher.Enabled() {
+		fmt.Fprintln(os.Stderr, "未配置 CREDENTIAL_KEYS，无法加密")
+		os.Exit(2)
+	}
+
+	db, err := repository.NewDatabase(&cfg.Database, logger)
+	if err != nil {
+		logger.Fatal("数据库初始化失败", zap.Error(err))
+	}
+	defer db.Close()
+
+	svc := service.NewOCRKeyService(repository.NewOCRKeyRepository(db.GetDB(), logger), logger)
+	svc.SetCredentialCipher(cipher)
+	res, err := svc.ReencryptCredentials(*dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "重新加密失败（已处理 %d/%d）: %v\n", res.Updated, res.Total, err)
+		os.Exit(1)
+	}
+	verb := "已重新加密"
+	if *dryRun {
+		verb = "需要重新加密"
+	}
+	fmt.Printf("✅ 主密钥 %s：共 %d 个 Key，%s %d，无需处理 %d，无法解密 %d\n",
+		cipher.ActiveKeyID(), res.Total, verb, res.Updated, res.Unchanged, res.Failed)
+	if res.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,50 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// 构建时通过 -ldflags 注入，例如：
+//
+//	go build -ldflags "-X wjdr-backend-go/internal/buildinfo.Version=v1.2.0 -X wjdr-backend-go/internal/buildinfo.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// StartedAt 进程启动时间
+var StartedAt = time.Now()
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get 返回构建信息；未注入 Commit 时回退到 go 工具链记录的 vcs.revision
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" && len(s.Value) >= 7 {
+					info.Commit = s.Value[:7]
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
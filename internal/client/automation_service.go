@@ -1,14 +1,23 @@
 package client
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
 	"time"
 
+	"wjdr-backend-go/internal/dataset"
+	"wjdr-backend-go/internal/events"
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -16,6 +25,8 @@ import (
 type AutomationService struct {
 	gameClient *GameClient
 	ocr        OCRRecognizer
+	events     *events.Bus
+	captures   *dataset.Store
 	logger     *zap.Logger
 }
 
@@ -59,9 +70,81 @@ func NewAutomationService(gameClient *GameClient, ocr OCRRecognizer, logger *zap
 
 // 已移除验证码容错候选策略，严格按 OCR 返回提交
 
+// SetCaptchaStore 设置验证码样本采集（未设置时不采集）
+func (s *AutomationService) SetCaptchaStore(store *dataset.Store) {
+	s.captures = store
+}
+
+// SetEventBus 设置兑换进度事件总线（未设置时不发布事件）
+func (s *AutomationService) SetEventBus(bus *events.Bus) {
+	s.events = bus
+}
+
+// recognizeCaptcha 识别验证码；识别器支持上下文时透传，以便记录每个 Key 的子 span。
+// 返回全部候选（首个为最终采用的答案，含 provider/key 与置信度），投票时其余候选用于反馈准确率
+func (s *AutomationService) recognizeCaptcha(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
+	ctx, span := tracing.Start(ctx, "ocr.recognize")
+	cands, err := recognizeCandidates(ctx, s.ocr, base64Image)
+	tracing.End(span, err)
+	if err != nil || len(cands) == 0 {
+		return nil, err
+	}
+	return cands, nil
+}
+
+// captchaVerdict 根据兑换结果判定提交的验证码是否正确：40103 为识别错误；兑换成功或返回业务类错误
+// （已兑换、过期等）说明验证码已通过校验；技术类错误无法判断，返回空串
+func captchaVerdict(success bool, errCode int) string {
+	switch {
+	case errCode == 40103:
+		return dataset.VerdictWrong
+	case ClassifyRedeemResult(success, errCode) != model.ResultCategoryTechnical:
+		return dataset.VerdictCorrect
+	default:
+		return ""
+	}
+}
+
+// reportCaptcha 将游戏判定反馈给识别器（统计各 Key 的真实准确率）并记录验证码样本
+func (s *AutomationService) reportCaptcha(ctx context.Context, rawImg string, cands []CaptchaCandidate, answer string, success bool, errCode int) {
+	verdict := captchaVerdict(success, errCode)
+	if verdict == "" || len(cands) == 0 {
+		return
+	}
+	if fr, ok := s.ocr.(FeedbackRecognizer); ok {
+		fr.ReportCaptchaVerdict(ctx, cands, answer, verdict == dataset.VerdictCorrect)
+	}
+	s.captureCaptcha(ctx, rawImg, cands[0], answer, verdict, errCode)
+}
+
+// captureCaptcha 记录一条已判定的验证码样本（原图与实际发送给识别器的预处理图；未启用采集时跳过）
+func (s *AutomationService) captureCaptcha(ctx context.Context, rawImg string, cand CaptchaCandidate, answer, verdict string, errCode int) {
+	if s.captures == nil {
+		return
+	}
+	raw, err := decodeCaptchaBase64(rawImg)
+	if err != nil {
+		return
+	}
+	var pre []byte
+	if cand.Image != "" && cand.Image != rawImg {
+		pre, _ = decodeCaptchaBase64(cand.Image)
+	}
+	if err := s.captures.Capture(raw, pre, dataset.Sample{
+		Answer:     answer,
+		Provider:   cand.Provider,
+		KeyID:      cand.KeyID,
+		Confidence: cand.Confidence,
+		Verdict:    verdict,
+		ErrCode:    errCode,
+	}); err != nil {
+		logging.FromContext(ctx, s.logger).Warn("保存验证码样本失败", zap.Error(err))
+	}
+}
+
 // VerifyAccount 验证账号有效性
-func (s *AutomationService) VerifyAccount(fid string) (*RedeemResult, error) {
-	result, err := s.gameClient.VerifyAccount(fid)
+func (s *AutomationService) VerifyAccount(ctx context.Context, fid string) (*RedeemResult, error) {
+	result, err := s.gameClient.VerifyAccount(ctx, fid)
 	if err != nil {
 		return nil, err
 	}
@@ -75,20 +158,24 @@ func (s *AutomationService) VerifyAccount(fid string) (*RedeemResult, error) {
 }
 
 // RedeemSingle 完整的单账号兑换流程（与Node版本对齐）
-func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, error) {
+func (s *AutomationService) RedeemSingle(ctx context.Context, fid, giftCode string) (*RedeemResult, error) {
+	ctx, log := logging.With(ctx, s.logger, zap.String("fid", fid), zap.String("gift_code", giftCode))
 	// 降噪：流程级的开场使用调试级别
-	s.logger.Debug("🚀 开始兑换流程",
-		zap.String("fid", fid),
-		zap.String("gift_code", giftCode))
+	log.Debug("🚀 开始兑换流程")
 
 	// 全局闸门：确保系统内任意时刻仅一个账号在兑换，避免验证码/频率被风控
 	acquireAccountGate()
 	defer releaseAccountGate()
 
+	ctx, span := tracing.Start(ctx, "redeem.single",
+		attribute.String("fid", fid),
+		attribute.String("gift_code", giftCode))
+	defer span.End()
+
 	startTime := time.Now()
 
 	// 1. 登录
-	loginResult, err := s.gameClient.Login(fid)
+	loginResult, err := s.gameClient.Login(ctx, fid)
 	if err != nil {
 		// 不向上抛出裸错误，转换为标准结果
 		return &RedeemResult{
@@ -120,13 +207,13 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// 降噪：重试轮次改为调试级别
-		s.logger.Debug("📝 尝试验证码识别和兑换",
+		log.Debug("📝 尝试验证码识别和兑换",
 			zap.Int("attempt", attempt),
 			zap.Int("max_retries", maxRetries))
 
 		// 2.1 获取验证码（加小抖动以打散请求）
 		time.Sleep(time.Duration(200+rand.Intn(600)) * time.Millisecond)
-		captchaResult, err := s.gameClient.GetCaptcha()
+		captchaResult, err := s.gameClient.GetCaptcha(ctx)
 		if err != nil {
 			// 将异常视为服务器繁忙类问题，执行冷却+重登重试
 			lastError = fmt.Sprintf("获取验证码异常: %v", err)
@@ -142,19 +229,19 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				}, nil
 			}
 
-			s.logger.Warn("⏳ 获取验证码异常，可能服务器繁忙，冷却60秒后重试",
+			log.Warn("⏳ 获取验证码异常，可能服务器繁忙，冷却60秒后重试",
 				zap.Int("attempt", attempt),
 				zap.Int("max_retries", maxRetries),
 				zap.Error(err))
 			time.Sleep(60 * time.Second)
 
 			// 冷却后重新登录
-			reLoginResult, loginErr := s.gameClient.Login(fid)
+			reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 			if loginErr != nil {
 				return nil, loginErr
 			}
 			if !reLoginResult.Success {
-				s.logger.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
+				log.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
 				lastError = fmt.Sprintf("冷却后重新登录失败: %s", reLoginResult.Error)
 				if attempt == maxRetries {
 					return &RedeemResult{
@@ -168,10 +255,10 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 						Attempts:       attempt,
 					}, nil
 				}
-				s.logger.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
+				log.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
 				continue
 			}
-			s.logger.Debug("✅ 冷却后重新登录成功，继续尝试获取验证码...")
+			log.Debug("✅ 冷却后重新登录成功，继续尝试获取验证码...")
 			continue
 		}
 
@@ -180,10 +267,10 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 
 			// 检查是否为验证码获取过多错误（40100）
 			if captchaResult.ErrCode == 40100 {
-				s.logger.Info("🔄 验证码获取过多，尝试重新登录", zap.String("fid", fid))
+				log.Info("🔄 验证码获取过多，尝试重新登录")
 
 				// 重新登录
-				reLoginResult, err := s.gameClient.Login(fid)
+				reLoginResult, err := s.gameClient.Login(ctx, fid)
 				if err != nil {
 					return &RedeemResult{
 						Success:        false,
@@ -196,37 +283,37 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				}
 
 				if !reLoginResult.Success {
-					s.logger.Error("❌ 重新登录失败", zap.String("error", reLoginResult.Error))
+					log.Error("❌ 重新登录失败", zap.String("error", reLoginResult.Error))
 					lastError = fmt.Sprintf("重新登录失败: %s", reLoginResult.Error)
 					if attempt == maxRetries {
 						// 达到本轮上限：进入一次“冷却60s+重登”的兜底流程
-						s.logger.Warn("⏳ 重新登录仍失败，冷却60秒后再试一次...")
+						log.Warn("⏳ 重新登录仍失败，冷却60秒后再试一次...")
 						time.Sleep(60 * time.Second)
-						reLoginResult2, loginErr2 := s.gameClient.Login(fid)
+						reLoginResult2, loginErr2 := s.gameClient.Login(ctx, fid)
 						if loginErr2 != nil || !reLoginResult2.Success {
 							return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: "重新登录失败(兜底)", Stage: "relogin", ProcessingTime: int(time.Since(startTime).Milliseconds())}, nil
 						}
 						continue
 					}
-					s.logger.Debug("⚠️ 重新登录失败，继续重试...", zap.Int("attempt", attempt))
+					log.Debug("⚠️ 重新登录失败，继续重试...", zap.Int("attempt", attempt))
 					time.Sleep(3 * time.Second)
 					continue
 				}
 
-				s.logger.Debug("✅ 重新登录成功，继续尝试获取验证码...")
+				log.Debug("✅ 重新登录成功，继续尝试获取验证码...")
 				time.Sleep(3 * time.Second)
 				continue
 			}
 
 			// 服务器繁忙（40101）：对账号冷却60s，重新登录后重试
 			if captchaResult.ErrCode == 40101 {
-				s.logger.Warn("⏳ 服务器繁忙，冷却60秒后重试获取验证码",
+				log.Warn("⏳ 服务器繁忙，冷却60秒后重试获取验证码",
 					zap.Int("attempt", attempt),
 					zap.Int("max_retries", maxRetries))
 				time.Sleep(60 * time.Second)
 
 				// 冷却后重新登录
-				reLoginResult, err := s.gameClient.Login(fid)
+				reLoginResult, err := s.gameClient.Login(ctx, fid)
 				if err != nil {
 					return &RedeemResult{
 						Success:        false,
@@ -238,7 +325,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 					}, nil
 				}
 				if !reLoginResult.Success {
-					s.logger.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
+					log.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
 					lastError = fmt.Sprintf("冷却后重新登录失败: %s", reLoginResult.Error)
 					if attempt == maxRetries {
 						return &RedeemResult{
@@ -253,11 +340,11 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 						}, nil
 					}
 					// 进入下一轮尝试
-					s.logger.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
+					log.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
 					continue
 				}
 				// 重新登录成功，继续下一轮获取验证码
-				s.logger.Debug("✅ 冷却后重新登录成功，继续尝试获取验证码...")
+				log.Debug("✅ 冷却后重新登录成功，继续尝试获取验证码...")
 				continue
 			}
 
@@ -273,7 +360,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 					Attempts:       attempt,
 				}, nil
 			}
-			s.logger.Debug("⚠️ 获取验证码失败，继续重试...", zap.Int("attempt", attempt))
+			log.Debug("⚠️ 获取验证码失败，继续重试...", zap.Int("attempt", attempt))
 			time.Sleep(3 * time.Second)
 			continue
 		}
@@ -292,7 +379,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 			return base64.StdEncoding.DecodeString(s)
 		}(captchaImg); err == nil {
 			sum := md5.Sum(b64)
-			s.logger.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
+			log.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
 		}
 		// 记录验证码图片的短哈希，便于确认是否刷新（对 base64 字符串做标准化后直接哈希，避免解码失败不打日志）
 		{
@@ -304,23 +391,22 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 			normalized = strings.ReplaceAll(normalized, "\r", "")
 			normalized = strings.TrimSpace(normalized)
 			sum := md5.Sum([]byte(normalized))
-			s.logger.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
+			log.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
 		}
 
-		// 先对验证码进行轻量预处理（放大、灰度、二值化）后再识别
-		processedImg, perr := preprocessCaptchaBase64(captchaImg)
-		if perr != nil {
-			// 预处理失败则回退使用原图
-			processedImg = captchaImg
-		}
-		captchaValue, err := s.ocr.RecognizeCaptcha(processedImg)
-		if err != nil || captchaValue == "" {
+		// 预处理由识别器按 provider 的流水线完成（见 CaptchaPipeline）
+		captchaCands, err := s.recognizeCaptcha(ctx, captchaImg)
+		if err != nil || len(captchaCands) == 0 {
 			lastError = "验证码识别失败或长度异常"
+			if errors.Is(err, ErrNoConsensus) {
+				// 多个 Provider 结果不一致：不冒险提交，直接换一张验证码
+				lastError = "验证码识别结果不一致"
+			}
 			if attempt == maxRetries {
 				// 达到本轮最大重试，进入“冷却+重登+再试”的流程一次
-				s.logger.Warn("⏳ OCR 多次失败，冷却60秒并重新登录后再试一次...")
+				log.Warn("⏳ OCR 多次失败，冷却60秒并重新登录后再试一次...")
 				time.Sleep(60 * time.Second)
-				reLoginResult, loginErr := s.gameClient.Login(fid)
+				reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 				if loginErr != nil {
 					return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: fmt.Sprintf("冷却后重新登录请求异常: %v", loginErr), Stage: "relogin_exception", ProcessingTime: int(time.Since(startTime).Milliseconds())}, nil
 				}
@@ -331,11 +417,13 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				continue
 			}
 			// OCR 失败：冷却3秒后再获取新验证码，避免频率过快
-			s.logger.Warn("⏳ 验证码识别失败，3秒后重试获取验证码...", zap.Int("attempt", attempt))
+			log.Warn("⏳ 验证码识别失败，3秒后重试获取验证码...", zap.Int("attempt", attempt))
 			time.Sleep(3 * time.Second)
 			continue
 		}
 
+		captchaValue := captchaCands[0].Text
+
 		// 规范化验证码：仅保留前4位字母数字并大写
 		norm := make([]rune, 0, 4)
 		for _, r := range captchaValue {
@@ -354,9 +442,9 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 			lastError = "验证码识别失败或长度异常"
 			if attempt == maxRetries {
 				// 达到本轮最大重试，进入“冷却+重登+再试”的流程一次
-				s.logger.Warn("⏳ 验证码长度异常多次，冷却60秒并重新登录后再试一次...")
+				log.Warn("⏳ 验证码长度异常多次，冷却60秒并重新登录后再试一次...")
 				time.Sleep(60 * time.Second)
-				reLoginResult, loginErr := s.gameClient.Login(fid)
+				reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 				if loginErr != nil {
 					return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: fmt.Sprintf("冷却后重新登录请求异常: %v", loginErr), Stage: "relogin_exception", ProcessingTime: int(time.Since(startTime).Milliseconds())}, nil
 				}
@@ -367,7 +455,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				continue
 			}
 			// 长度异常：同样冷却3秒再重试
-			s.logger.Warn("⏳ 验证码长度异常，3秒后重试获取验证码...", zap.Int("attempt", attempt))
+			log.Warn("⏳ 验证码长度异常，3秒后重试获取验证码...", zap.Int("attempt", attempt))
 			time.Sleep(3 * time.Second)
 			continue
 		}
@@ -375,7 +463,10 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 		lastCaptchaValue = captchaValue
 
 		// 2.3 执行兑换（严格使用OCR识别结果）
-		redeemResult, redeemErr := s.gameClient.RedeemCode(giftCode, captchaValue)
+		redeemResult, redeemErr := s.gameClient.RedeemCode(ctx, giftCode, captchaValue)
+		if redeemErr == nil {
+			s.reportCaptcha(ctx, captchaImg, captchaCands, captchaValue, redeemResult.Success, redeemResult.ErrCode)
+		}
 		if redeemErr != nil {
 			// 视为服务器繁忙，走冷却+重登+重试
 			lastError = fmt.Sprintf("兑换请求异常: %v", redeemErr)
@@ -392,14 +483,14 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				}, nil
 			}
 
-			s.logger.Warn("⏳ 兑换请求异常，可能服务器繁忙，冷却60秒后重试",
+			log.Warn("⏳ 兑换请求异常，可能服务器繁忙，冷却60秒后重试",
 				zap.Int("attempt", attempt),
 				zap.Int("max_retries", maxRetries),
 				zap.Error(err))
 			time.Sleep(60 * time.Second)
 
 			// 冷却后重新登录
-			reLoginResult, loginErr := s.gameClient.Login(fid)
+			reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 			if loginErr != nil {
 				return &RedeemResult{
 					Success:           false,
@@ -412,7 +503,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 				}, nil
 			}
 			if !reLoginResult.Success {
-				s.logger.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
+				log.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
 				lastError = fmt.Sprintf("冷却后重新登录失败: %s", reLoginResult.Error)
 				if attempt == maxRetries {
 					return &RedeemResult{
@@ -427,17 +518,17 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 						Attempts:          attempt,
 					}, nil
 				}
-				s.logger.Info("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
+				log.Info("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
 				continue
 			}
-			s.logger.Info("✅ 冷却后重新登录成功，继续重试兑换...")
+			log.Info("✅ 冷却后重新登录成功，继续重试兑换...")
 			continue
 		}
 
 		if redeemResult.Success {
 			// 兑换成功
 			processingTime := int(time.Since(startTime).Milliseconds())
-			s.logger.Debug("✅ 兑换成功！", zap.Int("attempt", attempt))
+			log.Debug("✅ 兑换成功！", zap.Int("attempt", attempt))
 
 			redeemData := redeemResult.Data.(map[string]interface{})
 			reward := ""
@@ -462,7 +553,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 
 			// 检查是否为致命错误（不需要重试）
 			if redeemResult.IsFatal {
-				s.logger.Info("💀 遇到致命错误，停止重试", zap.String("error", redeemResult.Error))
+				log.Info("💀 遇到致命错误，停止重试", zap.String("error", redeemResult.Error))
 				processingTime := int(time.Since(startTime).Milliseconds())
 				return &RedeemResult{
 					Success:           false,
@@ -480,9 +571,9 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 
 			// 40009 登录状态失效：立即尝试重新登录并继续下一轮
 			if redeemResult.ErrCode == 40009 {
-				s.logger.Warn("🔐 登录状态失效，尝试重新登录后重试",
+				log.Warn("🔐 登录状态失效，尝试重新登录后重试",
 					zap.Int("attempt", attempt))
-				reLoginResult, loginErr := s.gameClient.Login(fid)
+				reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 				if loginErr != nil {
 					if attempt == maxRetries {
 						return &RedeemResult{
@@ -515,11 +606,11 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 							Attempts:          attempt,
 						}, nil
 					}
-					s.logger.Debug("⚠️ 重新登录失败，继续重试...", zap.Int("attempt", attempt))
+					log.Debug("⚠️ 重新登录失败，继续重试...", zap.Int("attempt", attempt))
 					time.Sleep(3 * time.Second)
 					continue
 				}
-				s.logger.Debug("✅ 重新登录成功，继续尝试(将重新获取验证码)...")
+				log.Debug("✅ 重新登录成功，继续尝试(将重新获取验证码)...")
 				// 成功重登后直接进入下一轮（外层会重新获取验证码并尝试兑换）
 				time.Sleep(3 * time.Second)
 				continue
@@ -529,33 +620,33 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 			if redeemResult.ErrCode == 40103 || redeemResult.ErrCode == 40102 {
 				if attempt == maxRetries {
 					// 达到本轮上限，再进行一次“冷却60s+重新登录”的兜底后再试一次
-					s.logger.Warn("❌ 验证码类错误达到最大重试次数，将冷却60秒并重新登录后再试一次")
+					log.Warn("❌ 验证码类错误达到最大重试次数，将冷却60秒并重新登录后再试一次")
 					time.Sleep(60 * time.Second)
-					reLoginResult, loginErr := s.gameClient.Login(fid)
+					reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 					if loginErr != nil || !reLoginResult.Success {
-						s.logger.Error("❌ 冷却后重新登录失败(验证码类兜底)")
+						log.Error("❌ 冷却后重新登录失败(验证码类兜底)")
 						// 兜底也失败，返回
 						break
 					}
 					// 兜底成功，继续下一轮（外层 for 会迭代）
 				} else {
-					s.logger.Debug("🔄 验证码错误/过期，3秒后重新获取验证码...", zap.Int("attempt", attempt))
+					log.Debug("🔄 验证码错误/过期，3秒后重新获取验证码...", zap.Int("attempt", attempt))
 					time.Sleep(3 * time.Second)
 					continue
 				}
 			} else if redeemResult.ErrCode == 40101 { // 服务器繁忙
 				if attempt == maxRetries {
-					s.logger.Warn("❌ 服务器繁忙，已达到最大重试次数",
+					log.Warn("❌ 服务器繁忙，已达到最大重试次数",
 						zap.Int("attempt", attempt),
 						zap.Int("max_retries", maxRetries))
 				} else {
-					s.logger.Warn("⏳ 服务器繁忙，冷却60秒后重试兑换",
+					log.Warn("⏳ 服务器繁忙，冷却60秒后重试兑换",
 						zap.Int("attempt", attempt),
 						zap.Int("max_retries", maxRetries))
 					time.Sleep(60 * time.Second)
 
 					// 冷却后重新登录
-					reLoginResult, err := s.gameClient.Login(fid)
+					reLoginResult, err := s.gameClient.Login(ctx, fid)
 					if err != nil {
 						return &RedeemResult{
 							Success:           false,
@@ -568,7 +659,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 						}, nil
 					}
 					if !reLoginResult.Success {
-						s.logger.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
+						log.Error("❌ 冷却后重新登录失败", zap.String("error", reLoginResult.Error))
 						lastError = fmt.Sprintf("冷却后重新登录失败: %s", reLoginResult.Error)
 						if attempt == maxRetries {
 							processingTime := int(time.Since(startTime).Milliseconds())
@@ -585,16 +676,16 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 							}, nil
 						}
 						// 进入下一轮尝试
-						s.logger.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
+						log.Debug("⚠️ 冷却后重新登录失败，继续重试...", zap.Int("attempt", attempt))
 						continue
 					}
 					// 重新登录成功，进入下一轮重试（会重新获取验证码并兑换）
-					s.logger.Debug("✅ 冷却后重新登录成功，继续重试兑换...")
+					log.Debug("✅ 冷却后重新登录成功，继续重试兑换...")
 					continue
 				}
 			} else {
 				// 其他错误，直接返回
-				s.logger.Info("❌ 兑换失败 (非验证码问题)", zap.String("error", redeemResult.Error))
+				log.Info("❌ 兑换失败 (非验证码问题)", zap.String("error", redeemResult.Error))
 				processingTime := int(time.Since(startTime).Milliseconds())
 				return &RedeemResult{
 					Success:           false,
@@ -613,7 +704,7 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 	}
 
 	// 所有重试都失败了
-	s.logger.Info("❌ 所有重试都失败了", zap.Int("max_retries", maxRetries))
+	log.Info("❌ 所有重试都失败了", zap.Int("max_retries", maxRetries))
 	processingTime := int(time.Since(startTime).Milliseconds())
 	return &RedeemResult{
 		Success:           false,
@@ -628,7 +719,8 @@ func (s *AutomationService) RedeemSingle(fid, giftCode string) (*RedeemResult, e
 }
 
 // RedeemBatch 批量兑换（复刻Node版本逻辑）
-func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]BatchRedeemResult, error) {
+func (s *AutomationService) RedeemBatch(ctx context.Context, accounts []Account, giftCode string) ([]BatchRedeemResult, error) {
+	log := logging.FromContext(ctx, s.logger)
 	// 新的调度器：避免在单账号内阻塞60秒冷却；将需要冷却的账号延后至队列末尾，并在所有可处理账号完成后再回头处理
 	type accountState struct {
 		acc             Account
@@ -636,13 +728,19 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 		attemptsInCycle int // 自上次冷却以来的非冷却尝试次数（用于3次后触发一次冷却）
 		nextReadyAt     time.Time
 		startedAt       time.Time // 首次开始处理该账号的时间，用于统计包含冷却/等待的总耗时
+		attempts        int       // 累计尝试次数（用于进度事件）
 		finalized       bool
 	}
 
-	s.logger.Info("📦 开始批量兑换(调度)",
+	log.Info("📦 开始批量兑换(调度)",
 		zap.Int("accounts_count", len(accounts)),
 		zap.String("gift_code", giftCode))
 
+	ctx, batchSpan := tracing.Start(ctx, "redeem.batch",
+		attribute.Int("accounts_count", len(accounts)),
+		attribute.String("gift_code", giftCode))
+	defer batchSpan.End()
+
 	states := make([]*accountState, 0, len(accounts))
 	for _, a := range accounts {
 		states = append(states, &accountState{acc: a, nextReadyAt: time.Now()})
@@ -654,6 +752,36 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 	minSwitchDelay := 3 * time.Second
 	lastSwitchAt := time.Time{}
 
+	// 进度事件：账号最终结果与冷却调度统一经由以下闭包，保证事件与状态一致
+	publish := func(st *accountState, ev events.Event) {
+		ev.GiftCode = giftCode
+		ev.AccountID = st.acc.ID
+		ev.FID = st.acc.FID
+		s.events.Publish(ev)
+	}
+	finalize := func(st *accountState, res BatchRedeemResult) {
+		results = append(results, res)
+		st.finalized = true
+		pending--
+		publish(st, events.Event{
+			Type:     events.TypeAccountResult,
+			Attempt:  st.attempts,
+			Success:  res.Success,
+			ErrCode:  res.ErrCode,
+			Category: res.Category,
+			Message:  res.Error,
+		})
+	}
+	schedule := func(st *accountState, delay time.Duration, errCode int) {
+		st.nextReadyAt = time.Now().Add(delay)
+		publish(st, events.Event{
+			Type:         events.TypeCooldownScheduled,
+			Attempt:      st.attempts,
+			ErrCode:      errCode,
+			DelaySeconds: int(delay.Seconds()),
+		})
+	}
+
 	// 选择下一个可执行的账号索引；若都在冷却，返回最早可执行的索引与需等待时长
 	pickNext := func(now time.Time) (idx int, wait time.Duration, found bool) {
 		earliestIdx := -1
@@ -685,7 +813,7 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 		if wait > 0 {
 			// 所有账号均在冷却：仅等待到最早可执行时间，避免空转
 			if wait > 0 {
-				s.logger.Debug("⏳ 所有账号冷却中，等待下一可执行窗口", zap.Duration("wait", wait))
+				log.Debug("⏳ 所有账号冷却中，等待下一可执行窗口", zap.Duration("wait", wait))
 				time.Sleep(wait)
 			}
 			continue
@@ -696,7 +824,7 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 			since := time.Since(lastSwitchAt)
 			if since < minSwitchDelay {
 				sleep := minSwitchDelay - since
-				s.logger.Debug("⏳ 账号切换节流等待", zap.Duration("wait", sleep))
+				log.Debug("⏳ 账号切换节流等待", zap.Duration("wait", sleep))
 				time.Sleep(sleep)
 			}
 		}
@@ -705,10 +833,29 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 		// 首次处理该账号时记录起始时间（用于累计包含冷却/等待的总历时）
 		if st.startedAt.IsZero() {
 			st.startedAt = time.Now()
+			publish(st, events.Event{Type: events.TypeAccountStarted})
 		}
 
 		// 单次尝试（不在内部执行60s睡眠）
-		stepRes := s.tryOnceNoCooldown(st.acc.FID, giftCode)
+		attemptCtx, _ := logging.With(ctx, s.logger, zap.String("fid", st.acc.FID))
+		attemptCtx, attemptSpan := tracing.Start(attemptCtx, "redeem.attempt",
+			attribute.Int("account_id", st.acc.ID),
+			attribute.String("fid", st.acc.FID))
+		stepRes := s.tryOnceNoCooldown(attemptCtx, st.acc.FID, giftCode)
+		attemptSpan.SetAttributes(
+			attribute.Bool("success", stepRes.Success),
+			attribute.String("stage", stepRes.Stage),
+			attribute.Int("err_code", stepRes.ErrCode))
+		attemptSpan.End()
+		st.attempts++
+		publish(st, events.Event{
+			Type:    events.TypeCaptchaAttempt,
+			Attempt: st.attempts,
+			Captcha: stepRes.CaptchaRecognized,
+			ErrCode: stepRes.ErrCode,
+			Success: stepRes.Success,
+			Message: stepRes.Stage,
+		})
 		// 账号总耗时（墙钟时间，包含冷却/等待），单位毫秒
 		wallMs := int(time.Since(st.startedAt).Milliseconds())
 		lastSwitchAt = time.Now()
@@ -723,25 +870,22 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 			ProcessingTime:    wallMs,
 			ErrCode:           stepRes.ErrCode,
 			Success:           stepRes.Success,
+			Category:          ClassifyRedeemResult(stepRes.Success, stepRes.ErrCode),
 		}
 
 		if stepRes.Success {
-			s.logger.Info("✅ 账号兑换成功",
+			log.Info("✅ 账号兑换成功",
 				zap.String("fid", st.acc.FID),
 				zap.String("code", giftCode))
 			tmp.Result = "success"
 			tmp.Error = ""
-			results = append(results, tmp)
-			st.finalized = true
-			pending--
+			finalize(st, tmp)
 			continue
 		}
 
 		// 致命错误：直接终止该账号
 		if stepRes.IsFatal {
-			results = append(results, tmp)
-			st.finalized = true
-			pending--
+			finalize(st, tmp)
 			continue
 		}
 
@@ -752,52 +896,46 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 			st.attemptsInCycle = 0
 			if st.cooldowns >= 3 {
 				// 超过3次冷却依然失败
-				s.logger.Warn("❌ 账号多次冷却仍失败",
+				log.Warn("❌ 账号多次冷却仍失败",
 					zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
-				results = append(results, tmp)
-				st.finalized = true
-				pending--
+				finalize(st, tmp)
 			} else {
-				st.nextReadyAt = time.Now().Add(60 * time.Second)
-				s.logger.Warn("⏳ 服务器繁忙，账号进入冷却队列", zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
+				schedule(st, 60*time.Second, stepRes.ErrCode)
+				log.Warn("⏳ 服务器繁忙，账号进入冷却队列", zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
 			}
 		case 40009: // 登录状态失效 → 短退避3秒后重试（下次会先登录）
 			st.attemptsInCycle++
-			st.nextReadyAt = time.Now().Add(3 * time.Second)
-			s.logger.Debug("🔐 登录状态失效，短暂退避后重试", zap.String("fid", st.acc.FID), zap.Int("attempt_in_cycle", st.attemptsInCycle))
+			schedule(st, 3*time.Second, stepRes.ErrCode)
+			log.Debug("🔐 登录状态失效，短暂退避后重试", zap.String("fid", st.acc.FID), zap.Int("attempt_in_cycle", st.attemptsInCycle))
 		case 40102, 40103: // 验证码过期/错误 → 3次内快速重试；超过3次触发一次60s冷却
 			st.attemptsInCycle++
 			if st.attemptsInCycle >= 3 {
 				st.cooldowns++
 				st.attemptsInCycle = 0
 				if st.cooldowns >= 3 {
-					s.logger.Warn("❌ 账号验证码问题多次冷却仍失败",
+					log.Warn("❌ 账号验证码问题多次冷却仍失败",
 						zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
-					results = append(results, tmp)
-					st.finalized = true
-					pending--
+					finalize(st, tmp)
 				} else {
-					st.nextReadyAt = time.Now().Add(60 * time.Second)
-					s.logger.Warn("⏳ 验证码错误多次，账号进入冷却队列", zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
+					schedule(st, 60*time.Second, stepRes.ErrCode)
+					log.Warn("⏳ 验证码错误多次，账号进入冷却队列", zap.String("fid", st.acc.FID), zap.Int("cooldowns", st.cooldowns))
 				}
 			} else {
-				st.nextReadyAt = time.Now().Add(3 * time.Second)
-				s.logger.Debug("🔄 验证码问题，短暂冷却后重试", zap.String("fid", st.acc.FID), zap.Int("attempt_in_cycle", st.attemptsInCycle))
+				schedule(st, 3*time.Second, stepRes.ErrCode)
+				log.Debug("🔄 验证码问题，短暂冷却后重试", zap.String("fid", st.acc.FID), zap.Int("attempt_in_cycle", st.attemptsInCycle))
 			}
 		case 40100: // 验证码获取过多 → 视为短暂退避
 			st.attemptsInCycle++
-			st.nextReadyAt = time.Now().Add(3 * time.Second)
-			s.logger.Debug("🔁 验证码获取过多，短暂退避", zap.String("fid", st.acc.FID))
+			schedule(st, 3*time.Second, stepRes.ErrCode)
+			log.Debug("🔁 验证码获取过多，短暂退避", zap.String("fid", st.acc.FID))
 		default:
 			// 其他错误：视为终止（避免无休止重试），直接记失败
-			s.logger.Error("❌ 账号兑换失败(非致命)",
+			log.Error("❌ 账号兑换失败(非致命)",
 				zap.String("fid", st.acc.FID),
 				zap.String("code", giftCode),
 				zap.String("error", stepRes.Error),
 				zap.Int("err_code", stepRes.ErrCode))
-			results = append(results, tmp)
-			st.finalized = true
-			pending--
+			finalize(st, tmp)
 		}
 	}
 
@@ -808,7 +946,7 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 			successCount++
 		}
 	}
-	s.logger.Info("📊 批量兑换完成(调度)",
+	log.Info("📊 批量兑换完成(调度)",
 		zap.Int("success", successCount),
 		zap.Int("total", len(results)))
 
@@ -816,11 +954,12 @@ func (s *AutomationService) RedeemBatch(accounts []Account, giftCode string) ([]
 }
 
 // tryOnceNoCooldown 单次尝试，不在内部执行60s冷却等待；需要外层调度器根据返回的错误码进行队列冷却
-func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResult {
+func (s *AutomationService) tryOnceNoCooldown(ctx context.Context, fid, giftCode string) *RedeemResult {
+	log := logging.FromContext(ctx, s.logger)
 	startTime := time.Now()
 
 	// 1. 登录（失败直接分类返回）
-	loginResult, err := s.gameClient.Login(fid)
+	loginResult, err := s.gameClient.Login(ctx, fid)
 	if err != nil {
 		return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: "登录请求异常", Stage: "login_exception", Attempts: 1}
 	}
@@ -834,7 +973,7 @@ func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResul
 
 	// 2. 获取验证码
 	time.Sleep(time.Duration(200+rand.Intn(600)) * time.Millisecond)
-	captchaResult, err := s.gameClient.GetCaptcha()
+	captchaResult, err := s.gameClient.GetCaptcha(ctx)
 	if err != nil {
 		// 视为服务器繁忙类问题
 		return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: "获取验证码异常", Stage: "captcha_exception", ErrCode: 40101}
@@ -863,17 +1002,14 @@ func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResul
 		normalized = strings.ReplaceAll(normalized, "\r", "")
 		normalized = strings.TrimSpace(normalized)
 		sum := md5.Sum([]byte(normalized))
-		s.logger.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
+		log.Info("🧩 验证码刷新", zap.String("hash", hex.EncodeToString(sum[:])[:8]))
 	}
-	// 先对验证码进行轻量预处理（放大、灰度、二值化）后再识别
-	processedImg, perr := preprocessCaptchaBase64(captchaImg)
-	if perr != nil {
-		processedImg = captchaImg
-	}
-	captchaValue, err := s.ocr.RecognizeCaptcha(processedImg)
-	if err != nil || captchaValue == "" {
+	// 预处理由识别器按 provider 的流水线完成（见 CaptchaPipeline）
+	captchaCands, err := s.recognizeCaptcha(ctx, captchaImg)
+	if err != nil || len(captchaCands) == 0 {
 		return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: "验证码识别失败", Stage: "ocr", ErrCode: 40103}
 	}
+	captchaValue := captchaCands[0].Text
 	// 规范化为4位
 	norm := make([]rune, 0, 4)
 	for _, r := range captchaValue {
@@ -893,7 +1029,10 @@ func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResul
 	captchaValue = string(norm)
 
 	// 4. 兑换
-	redeemResult, err := s.gameClient.RedeemCode(giftCode, captchaValue)
+	redeemResult, err := s.gameClient.RedeemCode(ctx, giftCode, captchaValue)
+	if err == nil {
+		s.reportCaptcha(ctx, captchaImg, captchaCands, captchaValue, redeemResult.Success, redeemResult.ErrCode)
+	}
 	if err != nil {
 		// 视为服务器繁忙类问题
 		return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, Error: "兑换请求异常", Stage: "redeem_exception", ErrCode: 40101}
@@ -911,7 +1050,7 @@ func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResul
 		return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, CaptchaRecognized: captchaValue, Error: redeemResult.Error, Stage: "redeem", ErrCode: redeemResult.ErrCode}
 	}
 	if redeemResult.ErrCode == 40009 { // 登录状态失效：立即尝试重新登录并再兑换一次（不做60s冷却）
-		reLoginResult, loginErr := s.gameClient.Login(fid)
+		reLoginResult, loginErr := s.gameClient.Login(ctx, fid)
 		if loginErr != nil || !reLoginResult.Success {
 			// 重登失败则直接返回本次错误，由外层调度决定是否继续
 			if reLoginResult != nil && !reLoginResult.Success {
@@ -920,7 +1059,7 @@ func (s *AutomationService) tryOnceNoCooldown(fid, giftCode string) *RedeemResul
 			return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, CaptchaRecognized: captchaValue, Error: "重新登录请求异常", Stage: "relogin_exception", ErrCode: 40101}
 		}
 		// 重登成功后立刻再试一次兑换
-		second, err2 := s.gameClient.RedeemCode(giftCode, captchaValue)
+		second, err2 := s.gameClient.RedeemCode(ctx, giftCode, captchaValue)
 		if err2 != nil {
 			return &RedeemResult{Success: false, FID: fid, GiftCode: giftCode, CaptchaRecognized: captchaValue, Error: "兑换请求异常", Stage: "redeem_exception", ErrCode: 40101}
 		}
@@ -958,6 +1097,7 @@ type BatchRedeemResult struct {
 	ProcessingTime    int    `json:"processingTime"`
 	ErrCode           int    `json:"errCode"`
 	Success           bool   `json:"success"`
+	Category          string `json:"category"` // 结果分类，见 model.ResultCategory*
 	Skipped           bool   `json:"skipped,omitempty"`
 }
 
@@ -0,0 +1,320 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// 尺寸上限：验证码通常约 100x40，超出的输入直接拒绝；流水线累计放大倍数受限，避免中间图片耗尽内存
+const (
+	maxCaptchaPixels = 1 << 18 // 输入图片像素上限（约 512x512）
+	maxPipelineScale = 4.0     // 各 scale 步骤倍数之积的上限
+)
+
+// DefaultCaptchaPipeline 默认预处理：放大 2 倍（最近邻）、灰度化、阈值 128 二值化、轻度平滑
+const DefaultCaptchaPipeline = "scale(2,nearest)|threshold(128)|smooth"
+
+// defaultProviderPipelines 未单独配置时各 provider 的内置流水线；crnn 模型以原图训练，预处理反而降低识别率
+var defaultProviderPipelines = map[string]string{
+	"crnn": "none",
+}
+
+// CaptchaPipeline 验证码预处理流水线，由若干步骤按顺序组成，格式如 "scale(2,bilinear)|otsu|delines(1)|crop(2)"：
+//
+//	scale(倍数[,nearest|bilinear])  缩放（默认最近邻，各 scale 步骤倍数之积不超过 4）
+//	gray                            灰度化
+//	threshold(阈值)                 固定阈值二值化（0~255）
+//	otsu                            大津法自动阈值二值化
+//	adaptive(邻域[,偏移])           自适应阈值二值化（邻域默认 15，偏移默认 10）
+//	bgremove(距离)                  按边框平均色去除背景（颜色距离 0~441，默认 60）
+//	delines(线宽)                   去除不超过该宽度的干扰线（二值化后使用，默认 1）
+//	smooth                          3x3 轻度平滑
+//	crop([边距]) / crop(x,y,w,h)    裁剪到字符外接矩形（保留边距）或固定区域
+//
+// 空串或 none 表示不做预处理，直接发送原图
+type CaptchaPipeline struct {
+	spec  string
+	steps []pipelineStep
+}
+
+type pipelineStep struct {
+	name  string
+	apply func(image.Image) image.Image
+	scale float64 // 缩放倍数（非 scale 步骤为 1）
+}
+
+// PipelineStage 流水线中间结果（base64 PNG），用于管理端调参
+type PipelineStage struct {
+	Step  string `json:"step"`
+	Image string `json:"image"`
+}
+
+// ParseCaptchaPipeline 解析流水线描述
+func ParseCaptchaPipeline(spec string) (*CaptchaPipeline, error) {
+	spec = strings.TrimSpace(spec)
+	p := &CaptchaPipeline{spec: spec}
+	if spec == "" || strings.EqualFold(spec, "none") {
+		p.spec = "none"
+		return p, nil
+	}
+	scale := 1.0
+	for _, raw := range strings.Split(spec, "|") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		step, err := parsePipelineStep(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid captcha pipeline %q: %w", spec, err)
+		}
+		if scale *= step.scale; scale > maxPipelineScale {
+			return nil, fmt.Errorf("invalid captcha pipeline %q: total scale exceeds %g", spec, maxPipelineScale)
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+func parsePipelineStep(raw string) (pipelineStep, error) {
+	name, argStr := raw, ""
+	if i := strings.Index(raw, "("); i >= 0 {
+		if !strings.HasSuffix(raw, ")") {
+			return pipelineStep{}, fmt.Errorf("step %s: missing )", raw)
+		}
+		name, argStr = raw[:i], raw[i+1:len(raw)-1]
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	var args []string
+	for _, a := range strings.Split(argStr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			args = append(args, a)
+		}
+	}
+	num := func(i int, def float64) (float64, error) {
+		if i >= len(args) {
+			return def, nil
+		}
+		v, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("step %s: invalid number %q", name, args[i])
+		}
+		return v, nil
+	}
+	step := pipelineStep{name: raw, scale: 1}
+	switch name {
+	case "scale":
+		factor, err := num(0, 2)
+		if err != nil {
+			return step, err
+		}
+		if factor <= 0 || factor > maxPipelineScale {
+			return step, fmt.Errorf("step scale: factor must be in (0, %g]", maxPipelineScale)
+		}
+		bilinear := false
+		if len(args) > 1 {
+			switch strings.ToLower(args[1]) {
+			case "nearest":
+			case "bilinear":
+				bilinear = true
+			default:
+				return step, fmt.Errorf("step scale: unknown interpolation %s", args[1])
+			}
+		}
+		step.scale = factor
+		step.apply = func(img image.Image) image.Image { return scaleImage(img, factor, bilinear) }
+	case "gray":
+		step.apply = func(img image.Image) image.Image { return toGray(img) }
+	case "threshold":
+		level, err := num(0, 128)
+		if err != nil {
+			return step, err
+		}
+		if level < 0 || level > 255 {
+			return step, fmt.Errorf("step threshold: level must be in [0, 255]")
+		}
+		step.apply = func(img image.Image) image.Image { return thresholdImage(img, uint8(level)) }
+	case "otsu":
+		step.apply = func(img image.Image) image.Image {
+			gray := toGray(img)
+			return thresholdImage(gray, otsuLevel(gray))
+		}
+	case "adaptive":
+		block, err := num(0, 15)
+		if err != nil {
+			return step, err
+		}
+		offset, err := num(1, 10)
+		if err != nil {
+			return step, err
+		}
+		if block < 3 {
+			return step, fmt.Errorf("step adaptive: block must be >= 3")
+		}
+		step.apply = func(img image.Image) image.Image { return adaptiveThreshold(img, int(block), int(offset)) }
+	case "bgremove":
+		dist, err := num(0, 60)
+		if err != nil {
+			return step, err
+		}
+		step.apply = func(img image.Image) image.Image { return removeBackground(img, dist) }
+	case "delines":
+		width, err := num(0, 1)
+		if err != nil {
+			return step, err
+		}
+		if width < 1 {
+			return step, fmt.Errorf("step delines: width must be >= 1")
+		}
+		step.apply = func(img image.Image) image.Image { return removeThinLines(img, int(width)) }
+	case "smooth":
+		step.apply = func(img image.Image) image.Image { return applyLightSmoothing(toGray(img)) }
+	case "crop":
+		switch len(args) {
+		case 0, 1:
+			pad, err := num(0, 2)
+			if err != nil {
+				return step, err
+			}
+			step.apply = func(img image.Image) image.Image { return cropImage(img, image.Rectangle{}, int(pad)) }
+		case 4:
+			var v [4]float64
+			for i := range v {
+				var err error
+				if v[i], err = num(i, 0); err != nil {
+					return step, err
+				}
+			}
+			rect := image.Rect(int(v[0]), int(v[1]), int(v[0]+v[2]), int(v[1]+v[3]))
+			if rect.Empty() {
+				return step, fmt.Errorf("step crop: empty rectangle")
+			}
+			step.apply = func(img image.Image) image.Image { return cropImage(img, rect, 0) }
+		default:
+			return step, fmt.Errorf("step crop: expected (pad) or (x,y,w,h)")
+		}
+	default:
+		return step, fmt.Errorf("unknown step %s", name)
+	}
+	return step, nil
+}
+
+// String 流水线描述
+func (p *CaptchaPipeline) String() string {
+	return p.spec
+}
+
+// Empty 是否为空流水线（不做预处理）
+func (p *CaptchaPipeline) Empty() bool {
+	return p == nil || len(p.steps) == 0
+}
+
+// Process 处理 base64 图片并返回 base64 PNG；空流水线原样返回
+func (p *CaptchaPipeline) Process(base64Image string) (string, error) {
+	if p.Empty() {
+		return base64Image, nil
+	}
+	img, err := decodeCaptchaImage(base64Image)
+	if err != nil {
+		return "", err
+	}
+	for _, step := range p.steps {
+		img = step.apply(img)
+	}
+	return encodePNGBase64(img)
+}
+
+// Trace 逐步处理并返回原图与每一步的中间结果
+func (p *CaptchaPipeline) Trace(base64Image string) ([]PipelineStage, error) {
+	img, err := decodeCaptchaImage(base64Image)
+	if err != nil {
+		return nil, err
+	}
+	original, err := encodePNGBase64(img)
+	if err != nil {
+		return nil, err
+	}
+	stages := []PipelineStage{{Step: "original", Image: original}}
+	if p == nil {
+		return stages, nil
+	}
+	for _, step := range p.steps {
+		img = step.apply(img)
+		out, err := encodePNGBase64(img)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, PipelineStage{Step: step.name, Image: out})
+	}
+	return stages, nil
+}
+
+// CaptchaPipelines 按 OCR provider 选择预处理流水线（未单独配置的 provider 使用其内置流水线，没有内置流水线时使用默认流水线）
+type CaptchaPipelines struct {
+	Default    *CaptchaPipeline
+	ByProvider map[string]*CaptchaPipeline
+}
+
+// NewCaptchaPipelines 解析默认与各 provider 的流水线描述
+func NewCaptchaPipelines(defaultSpec string, byProvider map[string]string) (*CaptchaPipelines, error) {
+	def, err := ParseCaptchaPipeline(defaultSpec)
+	if err != nil {
+		return nil, err
+	}
+	ps := &CaptchaPipelines{Default: def, ByProvider: map[string]*CaptchaPipeline{}}
+	for provider, spec := range defaultProviderPipelines {
+		ps.ByProvider[provider], _ = ParseCaptchaPipeline(spec)
+	}
+	for provider, spec := range byProvider {
+		p, err := ParseCaptchaPipeline(spec)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", provider, err)
+		}
+		ps.ByProvider[strings.ToLower(strings.TrimSpace(provider))] = p
+	}
+	return ps, nil
+}
+
+// For 返回 provider 使用的流水线
+func (ps *CaptchaPipelines) For(provider string) *CaptchaPipeline {
+	if ps == nil {
+		return nil
+	}
+	if p, ok := ps.ByProvider[strings.ToLower(strings.TrimSpace(provider))]; ok {
+		return p
+	}
+	return ps.Default
+}
+
+func decodeCaptchaImage(base64Image string) (image.Image, error) {
+	imgBytes, err := decodeCaptchaBase64(base64Image)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode failed: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, fmt.Errorf("image decode failed: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxCaptchaPixels {
+		return nil, fmt.Errorf("image size %dx%d exceeds %d pixels", cfg.Width, cfg.Height, maxCaptchaPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, fmt.Errorf("image decode failed: %w", err)
+	}
+	return img, nil
+}
+
+func encodePNGBase64(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("png encode failed: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
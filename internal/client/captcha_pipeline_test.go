@@ -0,0 +1,42 @@
+package client
+
+import "testing"
+
+func TestCaptchaPipelinesProviderDefaults(t *testing.T) {
+	ps, err := NewCaptchaPipelines(DefaultCaptchaPipeline, nil)
+	if err != nil {
+		t.Fatalf("NewCaptchaPipelines: %v", err)
+	}
+	want := map[string]string{
+		"baidu":  DefaultCaptchaPipeline,
+		"paddle": DefaultCaptchaPipeline,
+		"http":   DefaultCaptchaPipeline,
+		"crnn":   "none",
+		"CRNN":   "none",
+	}
+	for provider, spec := range want {
+		if got := ps.For(provider).String(); got != spec {
+			t.Errorf("%s: pipeline = %q, want %q", provider, got, spec)
+		}
+	}
+	if !ps.For("crnn").Empty() {
+		t.Errorf("crnn: default pipeline should send the original image")
+	}
+}
+
+func TestCaptchaPipelinesProviderOverride(t *testing.T) {
+	ps, err := NewCaptchaPipelines("otsu", map[string]string{"crnn": "gray", "paddle": "none"})
+	if err != nil {
+		t.Fatalf("NewCaptchaPipelines: %v", err)
+	}
+	want := map[string]string{
+		"crnn":   "gray",
+		"paddle": "none",
+		"baidu":  "otsu",
+	}
+	for provider, spec := range want {
+		if got := ps.For(provider).String(); got != spec {
+			t.Errorf("%s: pipeline = %q, want %q", provider, got, spec)
+		}
+	}
+}
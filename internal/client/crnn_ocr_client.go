@@ -0,0 +1,319 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"wjdr-backend-go/internal/onnx"
+
+	"go.uber.org/zap"
+)
+
+// CRNNOCRClient 进程内运行导出为 ONNX 的 CRNN 验证码模型（与 PaddleOCRClient 使用同一模型与 dict_cap36 字典），
+// 纯 Go 实现、仅用 CPU，不依赖 Python 环境
+// 依赖环境变量（可选）覆盖默认路径：
+// - CRNN_OCR_MODEL: 单文件 ONNX 模型（默认 ./third_party/wjdr_OCR/output/rec_crnn.onnx）
+// - CRNN_OCR_CHAR_DICT: 字典路径（默认 ./third_party/wjdr_OCR/data/dict_cap36.txt）
+// - CRNN_OCR_IMAGE_SHAPE: 例如 3,32,160（默认 3,32,160）
+// - CRNN_OCR_MIN_CONFIDENCE: 置信度下限 0~1（默认 0，不限制），低于下限视为识别失败
+type CRNNOCRClient struct {
+	recognizer    *crnnRecognizer
+	imageC        int
+	imageH        int
+	imageW        int
+	minConfidence float64
+	logger        *zap.Logger
+}
+
+// crnnRecognizer 已加载的模型与字符表；OCRKeyManager 每次 Reload 都会重建客户端，因此按路径在进程内复用
+type crnnRecognizer struct {
+	model   *onnx.Model
+	charset []string // 下标0为 CTC blank
+	err     error
+}
+
+var (
+	crnnMu     sync.Mutex
+	crnnLoaded = map[string]*crnnRecognizer{}
+)
+
+func NewCRNNOCRClient(logger *zap.Logger) *CRNNOCRClient {
+	modelPath := getenvDefault("CRNN_OCR_MODEL", "./third_party/wjdr_OCR/output/rec_crnn.onnx")
+	dictPath := getenvDefault("CRNN_OCR_CHAR_DICT", "./third_party/wjdr_OCR/data/dict_cap36.txt")
+
+	c := &CRNNOCRClient{imageC: 3, imageH: 32, imageW: 160, logger: logger}
+	if parts := strings.Split(getenvDefault("CRNN_OCR_IMAGE_SHAPE", "3,32,160"), ","); len(parts) == 3 {
+		ch, _ := strconvAtoiSafe(parts[0])
+		h, _ := strconvAtoiSafe(parts[1])
+		w, _ := strconvAtoiSafe(parts[2])
+		if (ch == 1 || ch == 3) && h > 0 && w > 0 {
+			c.imageC, c.imageH, c.imageW = ch, h, w
+		}
+	}
+	if v, err := strconv.ParseFloat(getenvDefault("CRNN_OCR_MIN_CONFIDENCE", "0"), 64); err == nil {
+		c.minConfidence = v
+	}
+	c.recognizer = loadCRNNRecognizer(modelPath, dictPath, logger)
+	return c
+}
+
+func loadCRNNRecognizer(modelPath, dictPath string, logger *zap.Logger) *crnnRecognizer {
+	key := modelPath + "|" + dictPath
+	crnnMu.Lock()
+	defer crnnMu.Unlock()
+	if r, ok := crnnLoaded[key]; ok {
+		return r
+	}
+
+	r := &crnnRecognizer{}
+	if charset, err := loadCharDict(dictPath); err != nil {
+		r.err = fmt.Errorf("crnn ocr: load char dict: %w", err)
+	} else if model, err := onnx.Load(modelPath); err != nil {
+		r.err = fmt.Errorf("crnn ocr: load model: %w", err)
+	} else if len(model.Inputs) != 1 || len(model.Outputs) == 0 {
+		r.err = fmt.Errorf("crnn ocr: expect 1 input, got inputs=%v outputs=%v", model.Inputs, model.Outputs)
+	} else {
+		r.model, r.charset = model, charset
+	}
+
+	if r.err != nil {
+		logger.Error("❌ CRNN 模型加载失败", zap.String("model", modelPath), zap.Error(r.err))
+	} else {
+		logger.Info("✅ CRNN 模型已加载", zap.String("model", modelPath), zap.Int("classes", len(r.charset)))
+	}
+	crnnLoaded[key] = r
+	return r
+}
+
+// loadCharDict 每行一个字符，与 PaddleOCR 的 CTCLabelDecode 一致在首位加入 blank
+func loadCharDict(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	charset := []string{""}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+		if line != "" {
+			charset = append(charset, line)
+		}
+	}
+	if len(charset) == 1 {
+		return nil, errors.New("empty char dict")
+	}
+	return charset, scanner.Err()
+}
+
+func (c *CRNNOCRClient) RecognizeCaptcha(base64Image string) (string, error) {
+	return c.RecognizeCaptchaContext(context.Background(), base64Image)
+}
+
+// RecognizeCaptchaContext 推理为纯 CPU 计算，ctx 仅用于调用前检查是否已取消
+func (c *CRNNOCRClient) RecognizeCaptchaContext(ctx context.Context, base64Image string) (string, error) {
+	cands, err := c.RecognizeCandidates(ctx, base64Image)
+	if err != nil {
+		return "", err
+	}
+	return cands[0].Text, nil
+}
+
+// RecognizeCandidates 返回规范为4位的识别结果及其置信度
+func (c *CRNNOCRClient) RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	text, confidence, err := c.RecognizeWithConfidence(base64Image)
+	if err != nil {
+		return nil, err
+	}
+	norm := normalizeTo4(text)
+	if len(norm) != 4 {
+		return nil, fmt.Errorf("%w: %s", errCaptchaLength, norm)
+	}
+	if confidence < c.minConfidence {
+		c.logger.Warn("CRNN 识别置信度过低", zap.String("text", norm), zap.Float64("confidence", confidence))
+		return nil, fmt.Errorf("crnn ocr confidence too low: %.3f", confidence)
+	}
+	return []CaptchaCandidate{{Text: norm, Confidence: confidence}}, nil
+}
+
+// RecognizeWithConfidence 返回原始识别文本（未规范为4位）及置信度（保留字符最大概率的均值）
+func (c *CRNNOCRClient) RecognizeWithConfidence(base64Image string) (string, float64, error) {
+	r := c.recognizer
+	if r.err != nil {
+		return "", 0, r.err
+	}
+
+	raw, err := decodeCaptchaBase64(base64Image)
+	if err != nil {
+		return "", 0, fmt.Errorf("crnn ocr: decode base64: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return "", 0, fmt.Errorf("crnn ocr: decode image: %w", err)
+	}
+
+	outputs, err := r.model.Run(map[string]*onnx.Tensor{r.model.Inputs[0]: c.preprocess(img)})
+	if err != nil {
+		c.logger.Error("CRNN 推理失败", zap.Error(err))
+		return "", 0, err
+	}
+	return ctcGreedyDecode(outputs[r.model.Outputs[0]], r.charset)
+}
+
+// preprocess 与 PaddleOCR resize_norm_img 一致：等比缩放到目标高度（宽度不足补0），BGR 通道，归一化到 [-1,1]
+func (c *CRNNOCRClient) preprocess(img image.Image) *onnx.Tensor {
+	b := img.Bounds()
+	ratio := float64(b.Dx()) / float64(b.Dy())
+	// 与 predict_rec.py 单张推理一致：画布宽度取配置宽度与图片等比宽度中的较大者
+	width := c.imageW
+	if w := int(float64(c.imageH) * ratio); w > width {
+		width = w
+	}
+	resizedW := int(math.Ceil(float64(c.imageH) * ratio))
+	if resizedW > width {
+		resizedW = width
+	}
+	if resizedW < 1 {
+		resizedW = 1
+	}
+
+	h := c.imageH
+	t := onnx.NewTensor([]int{1, c.imageC, h, width}, nil)
+	plane := h * width
+	scaleX := float64(b.Dx()) / float64(resizedW)
+	scaleY := float64(b.Dy()) / float64(h)
+	for y := 0; y < h; y++ {
+		sy, y0, y1 := bilinearSource(y, scaleY, b.Dy())
+		for x := 0; x < resizedW; x++ {
+			sx, x0, x1 := bilinearSource(x, scaleX, b.Dx())
+			var rgb [3]float64
+			for _, p := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - sx) * (1 - sy)},
+				{x1, y0, sx * (1 - sy)},
+				{x0, y1, (1 - sx) * sy},
+				{x1, y1, sx * sy},
+			} {
+				r, g, bl, _ := img.At(b.Min.X+p.x, b.Min.Y+p.y).RGBA()
+				rgb[0] += p.w * float64(r>>8)
+				rgb[1] += p.w * float64(g>>8)
+				rgb[2] += p.w * float64(bl>>8)
+			}
+			idx := y*width + x
+			if c.imageC == 1 {
+				gray := 0.299*rgb[0] + 0.587*rgb[1] + 0.114*rgb[2]
+				t.Data[idx] = float32((gray/255 - 0.5) / 0.5)
+				continue
+			}
+			// OpenCV 读图为 BGR 顺序
+			t.Data[idx] = float32((rgb[2]/255 - 0.5) / 0.5)
+			t.Data[plane+idx] = float32((rgb[1]/255 - 0.5) / 0.5)
+			t.Data[2*plane+idx] = float32((rgb[0]/255 - 0.5) / 0.5)
+		}
+	}
+	return t
+}
+
+// bilinearSource 按 OpenCV INTER_LINEAR 的像素中心对齐计算源坐标，返回小数部分与相邻两个下标
+func bilinearSource(dst int, scale float64, size int) (float64, int, int) {
+	src := (float64(dst)+0.5)*scale - 0.5
+	if src < 0 {
+		src = 0
+	}
+	i0 := int(src)
+	if i0 > size-1 {
+		i0 = size - 1
+	}
+	i1 := i0 + 1
+	if i1 > size-1 {
+		i1 = size - 1
+	}
+	return src - float64(i0), i0, i1
+}
+
+// ctcGreedyDecode 逐时间步取最大概率类别，合并连续重复并去掉 blank
+func ctcGreedyDecode(out *onnx.Tensor, charset []string) (string, float64, error) {
+	if out == nil || len(out.Shape) < 2 {
+		return "", 0, errors.New("crnn ocr: unexpected model output")
+	}
+	// 输出为 [N, T, C] 或 [T, C]，只取第一张
+	classes := out.Shape[len(out.Shape)-1]
+	steps := out.Shape[len(out.Shape)-2]
+	// PaddleOCR 默认 use_space_char=True，模型类别数会比字典多出一个空格
+	if classes == len(charset)+1 {
+		charset = append(charset[:len(charset):len(charset)], " ")
+	}
+	if classes != len(charset) {
+		return "", 0, fmt.Errorf("crnn ocr: model has %d classes, char dict has %d", classes, len(charset))
+	}
+
+	var (
+		text  strings.Builder
+		sum   float64
+		kept  int
+		prev  = -1
+		probs = make([]float64, classes)
+	)
+	for t := 0; t < steps; t++ {
+		row := out.Data[t*classes : (t+1)*classes]
+		softmaxRow(row, probs)
+		best := 0
+		for i := 1; i < classes; i++ {
+			if probs[i] > probs[best] {
+				best = i
+			}
+		}
+		if best != 0 && best != prev {
+			text.WriteString(charset[best])
+			sum += probs[best]
+			kept++
+		}
+		prev = best
+	}
+	if kept == 0 {
+		return "", 0, errors.New("crnn ocr: empty result")
+	}
+	return text.String(), sum / float64(kept), nil
+}
+
+// softmaxRow 模型已含 softmax 时原样返回概率，否则对 logits 做 softmax
+func softmaxRow(row []float32, dst []float64) {
+	sum, normalized := 0.0, true
+	for i, v := range row {
+		dst[i] = float64(v)
+		sum += dst[i]
+		if v < 0 || v > 1 {
+			normalized = false
+		}
+	}
+	if normalized && math.Abs(sum-1) < 1e-3 {
+		return
+	}
+	maxV := dst[0]
+	for _, v := range dst {
+		if v > maxV {
+			maxV = v
+		}
+	}
+	sum = 0
+	for i := range dst {
+		dst[i] = math.Exp(dst[i] - maxV)
+		sum += dst[i]
+	}
+	for i := range dst {
+		dst[i] /= sum
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
@@ -11,8 +12,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/metrics"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -24,8 +31,10 @@ type GameClient struct {
 	nickname          string
 	client            *http.Client
 	logger            *zap.Logger
-	retryCount        int // 统计重试次数
-	networkErrorCount int // 统计网络错误次数
+	retryCount        int          // 统计重试次数
+	networkErrorCount int          // 统计网络错误次数
+	lastSuccessAt     atomic.Int64 // 最近一次成功调用（UnixNano），供健康检查
+	lastFailureAt     atomic.Int64 // 最近一次失败调用（UnixNano）
 }
 
 // GameResponse 游戏API通用响应
@@ -127,6 +136,11 @@ func (c *GameClient) parseErrCode(errCodeAny interface{}) int {
 	}
 }
 
+// observeErrCode 记录游戏接口返回的 err_code 分布（0 表示成功）
+func observeErrCode(endpoint string, errCode int) {
+	metrics.GameAPIErrCodes.WithLabelValues(endpoint, strconv.Itoa(errCode)).Inc()
+}
+
 // getErrorMessage 错误码映射（与Node版本对齐）
 func (c *GameClient) getErrorMessage(errCode int) string {
 	errorMap := map[int]string{
@@ -178,6 +192,26 @@ func (c *GameClient) isSuccess(errCode int) bool {
 	return errCode == 20000 // 兑换成功
 }
 
+// ClassifyRedeemResult 根据兑换结果与错误码推导结果分类（与 getErrorMessage 的错误码对应）
+// 分类在写入日志时确定并存入 result_category；旧日志由修复统计按本函数回填
+func ClassifyRedeemResult(success bool, errCode int) string {
+	if success || errCode == 20000 {
+		return model.ResultCategorySuccess
+	}
+	switch errCode {
+	case 40008, 40011: // 已经兑换过此礼品码 / 已兑换过同类型兑换码
+		return model.ResultCategoryAlreadyRedeemed
+	case 40005: // 超出领取次数
+		return model.ResultCategoryLimitReached
+	case 40007, 40014: // 兑换码已过期 / 不存在
+		return model.ResultCategoryExpired
+	case 40006: // 不满足活动领取条件
+		return model.ResultCategoryIneligible
+	default:
+		return model.ResultCategoryTechnical
+	}
+}
+
 // isTemporaryError 检查是否为临时错误（可重试）
 func (c *GameClient) isTemporaryError(err error) bool {
 	if err == nil {
@@ -200,43 +234,60 @@ func (c *GameClient) isTemporaryError(err error) bool {
 
 // doRequestWithRetry 执行HTTP请求，带重试机制
 func (c *GameClient) doRequestWithRetry(req *http.Request, maxRetries int) (*http.Response, error) {
+	log := logging.FromContext(req.Context(), c.logger)
 	var lastErr error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			// 等待一段时间再重试，使用指数退避
 			waitTime := time.Duration(attempt) * 2 * time.Second
-			c.logger.Debug("等待重试",
+			log.Debug("等待重试",
 				zap.Int("attempt", attempt),
 				zap.Duration("wait_time", waitTime))
 			time.Sleep(waitTime)
 		}
 
+		start := time.Now()
+		_, span := tracing.Start(req.Context(), "game_api "+req.URL.Path,
+			attribute.String("http.method", req.Method),
+			attribute.Int("attempt", attempt))
 		resp, err := c.client.Do(req)
 		if err == nil {
+			if resp.StatusCode < http.StatusInternalServerError {
+				c.lastSuccessAt.Store(time.Now().UnixNano())
+			} else {
+				c.lastFailureAt.Store(time.Now().UnixNano())
+			}
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+			tracing.End(span, nil)
+			metrics.GameAPIDuration.WithLabelValues(req.URL.Path, "ok").Observe(time.Since(start).Seconds())
 			return resp, nil
 		}
+		tracing.End(span, err)
+		c.lastFailureAt.Store(time.Now().UnixNano())
+		metrics.GameAPIDuration.WithLabelValues(req.URL.Path, "error").Observe(time.Since(start).Seconds())
 
 		lastErr = err
 
 		// 如果不是临时错误，直接返回
 		if !c.isTemporaryError(err) {
-			c.logger.Error("非临时错误，停止重试",
+			log.Error("非临时错误，停止重试",
 				zap.Error(err),
 				zap.Int("attempt", attempt))
 			return nil, err
 		}
 
-		c.logger.Warn("网络请求失败，准备重试",
+		log.Warn("网络请求失败，准备重试",
 			zap.Error(err),
 			zap.Int("attempt", attempt+1),
 			zap.Int("max_retries", maxRetries+1))
 
 		// 统计重试次数
 		c.retryCount++
+		metrics.GameAPIRetries.WithLabelValues(req.URL.Path).Inc()
 	}
 
-	c.logger.Error("重试次数已用完",
+	log.Error("重试次数已用完",
 		zap.Error(lastErr),
 		zap.Int("max_retries", maxRetries+1),
 		zap.Int("total_retries", c.retryCount),
@@ -248,6 +299,17 @@ func (c *GameClient) doRequestWithRetry(req *http.Request, maxRetries int) (*htt
 	return nil, lastErr
 }
 
+// LastCallTimes 返回最近一次成功/失败调用游戏接口的时间（从未发生时为零值）
+func (c *GameClient) LastCallTimes() (success, failure time.Time) {
+	if ns := c.lastSuccessAt.Load(); ns > 0 {
+		success = time.Unix(0, ns)
+	}
+	if ns := c.lastFailureAt.Load(); ns > 0 {
+		failure = time.Unix(0, ns)
+	}
+	return success, failure
+}
+
 // generateSign 生成签名（与Node版本完全对齐）
 func (c *GameClient) generateSign(fid, timeMs string, init, cdk, captchaCode *string) string {
 	params := map[string]string{
@@ -286,7 +348,8 @@ func (c *GameClient) generateSign(fid, timeMs string, init, cdk, captchaCode *st
 }
 
 // Login 登录验证（与Node版本对齐）
-func (c *GameClient) Login(fid string) (*GameResult, error) {
+func (c *GameClient) Login(ctx context.Context, fid string) (*GameResult, error) {
+	log := logging.FromContext(ctx, c.logger)
 	currentTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	init := "1"
 	sign := c.generateSign(fid, currentTime, &init, nil, nil)
@@ -298,9 +361,9 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 	data.Set("sign", sign)
 
 	// 降噪：登录开始改为调试级别
-	c.logger.Debug("🔐 登录验证", zap.String("fid", fid))
+	log.Debug("🔐 登录验证")
 
-	req, err := http.NewRequest("POST", c.baseURL+"/player", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/player", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -310,9 +373,8 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 
 	resp, err := c.doRequestWithRetry(req, 2) // 最多重试2次
 	if err != nil {
-		c.logger.Error("❌ 登录请求异常",
-			zap.Error(err),
-			zap.String("fid", fid))
+		log.Error("❌ 登录请求异常",
+			zap.Error(err))
 
 		// 提供更友好的错误信息
 		errorMsg := "网络连接异常"
@@ -329,23 +391,24 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Error("❌ 登录响应读取失败", zap.Error(err))
+		log.Error("❌ 登录响应读取失败", zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("❌ 登录HTTP状态异常",
+		log.Error("❌ 登录HTTP状态异常",
 			zap.Int("status", resp.StatusCode))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	var gameResp GameResponse
 	if err := json.Unmarshal(body, &gameResp); err != nil {
-		c.logger.Error("❌ 登录响应解析失败",
+		log.Error("❌ 登录响应解析失败",
 			zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	if gameResp.Code == 0 {
+		observeErrCode("/player", 0)
 		c.fid = fid
 
 		// 解析用户数据
@@ -355,9 +418,8 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 		c.nickname = userData.Nickname
 
 		// 降噪：登录成功改为调试级别
-		c.logger.Debug("✅ 登录成功！",
+		log.Debug("✅ 登录成功！",
 			zap.String("user", userData.Nickname),
-			zap.String("fid", fid),
 			zap.Int("level", userData.StoveLv))
 
 		return &GameResult{
@@ -375,6 +437,7 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 		}, nil
 	} else {
 		errCodeInt := c.parseErrCode(gameResp.ErrCode)
+		observeErrCode("/player", errCodeInt)
 		errorText := c.getErrorMessage(errCodeInt)
 		if errorText == "" {
 			errorText = c.messageToString(gameResp.Msg)
@@ -383,7 +446,7 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 			errorText = "登录失败"
 		}
 
-		c.logger.Error("❌ 登录失败",
+		log.Error("❌ 登录失败",
 			zap.String("error", errorText),
 			zap.Int("err_code", errCodeInt))
 
@@ -396,7 +459,8 @@ func (c *GameClient) Login(fid string) (*GameResult, error) {
 }
 
 // GetCaptcha 获取验证码（与Node版本对齐）
-func (c *GameClient) GetCaptcha() (*GameResult, error) {
+func (c *GameClient) GetCaptcha(ctx context.Context) (*GameResult, error) {
+	log := logging.FromContext(ctx, c.logger)
 	currentTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	sign := c.generateSign(c.fid, currentTime, nil, nil, nil)
 
@@ -406,11 +470,10 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 	data.Set("sign", sign)
 
 	// 降噪：获取验证码改为调试级别
-	c.logger.Debug("🔍 获取验证码...",
-		zap.String("fid", c.fid),
+	log.Debug("🔍 获取验证码...",
 		zap.String("user", c.nickname))
 
-	req, err := http.NewRequest("POST", c.baseURL+"/captcha", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/captcha", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -420,9 +483,8 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 
 	resp, err := c.doRequestWithRetry(req, 2) // 最多重试2次
 	if err != nil {
-		c.logger.Error("❌ 获取验证码异常",
+		log.Error("❌ 获取验证码异常",
 			zap.Error(err),
-			zap.String("fid", c.fid),
 			zap.String("user", c.nickname))
 
 		// 提供更友好的错误信息
@@ -440,25 +502,26 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Error("❌ 验证码响应读取失败", zap.Error(err))
+		log.Error("❌ 验证码响应读取失败", zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("❌ 获取验证码HTTP状态异常",
+		log.Error("❌ 获取验证码HTTP状态异常",
 			zap.Int("status", resp.StatusCode))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	var gameResp GameResponse
 	if err := json.Unmarshal(body, &gameResp); err != nil {
-		c.logger.Error("❌ 获取验证码响应解析失败",
+		log.Error("❌ 获取验证码响应解析失败",
 			zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	if gameResp.Code == 0 {
+		observeErrCode("/captcha", 0)
 		// 降噪：验证码获取成功改为调试级别
-		c.logger.Debug("✅ 验证码获取成功")
+		log.Debug("✅ 验证码获取成功")
 
 		// 解析验证码数据
 		dataBytes, _ := json.Marshal(gameResp.Data)
@@ -473,6 +536,7 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 		}, nil
 	} else {
 		errCodeInt := c.parseErrCode(gameResp.ErrCode)
+		observeErrCode("/captcha", errCodeInt)
 		errorText := c.getErrorMessage(errCodeInt)
 		if errorText == "" {
 			errorText = c.messageToString(gameResp.Msg)
@@ -482,16 +546,14 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 		}
 
 		if errCodeInt == 40100 {
-			c.logger.Warn("⚠️ 验证码获取过多，需要重新登录",
+			log.Warn("⚠️ 验证码获取过多，需要重新登录",
 				zap.String("error", errorText),
 				zap.Int("err_code", errCodeInt),
-				zap.String("fid", c.fid),
 				zap.String("user", c.nickname))
 		} else {
-			c.logger.Error("❌ 获取验证码失败",
+			log.Error("❌ 获取验证码失败",
 				zap.String("error", errorText),
 				zap.Int("err_code", errCodeInt),
-				zap.String("fid", c.fid),
 				zap.String("user", c.nickname))
 		}
 
@@ -504,7 +566,8 @@ func (c *GameClient) GetCaptcha() (*GameResult, error) {
 }
 
 // RedeemCode 兑换礼品码（与Node版本对齐）
-func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, error) {
+func (c *GameClient) RedeemCode(ctx context.Context, giftCode, captchaValue string) (*GameResult, error) {
+	log := logging.FromContext(ctx, c.logger)
 	currentTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	sign := c.generateSign(c.fid, currentTime, nil, &giftCode, &captchaValue)
 
@@ -516,13 +579,12 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 	data.Set("sign", sign)
 
 	// 降噪：兑换动作改为调试级别
-	c.logger.Debug("🎁 兑换礼品码",
+	log.Debug("🎁 兑换礼品码",
 		zap.String("code", giftCode),
 		zap.String("captcha", captchaValue),
-		zap.String("fid", c.fid),
 		zap.String("user", c.nickname))
 
-	req, err := http.NewRequest("POST", c.baseURL+"/gift_code", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/gift_code", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -532,10 +594,9 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 
 	resp, err := c.doRequestWithRetry(req, 2) // 最多重试2次
 	if err != nil {
-		c.logger.Error("❌ 兑换请求异常",
+		log.Error("❌ 兑换请求异常",
 			zap.Error(err),
 			zap.String("code", giftCode),
-			zap.String("fid", c.fid),
 			zap.String("user", c.nickname))
 
 		// 提供更友好的错误信息
@@ -553,23 +614,24 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Error("❌ 兑换响应读取失败", zap.Error(err))
+		log.Error("❌ 兑换响应读取失败", zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("❌ 兑换HTTP状态异常",
+		log.Error("❌ 兑换HTTP状态异常",
 			zap.Int("status", resp.StatusCode))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	var gameResp GameResponse
 	if err := json.Unmarshal(body, &gameResp); err != nil {
-		c.logger.Error("❌ 兑换响应解析失败",
+		log.Error("❌ 兑换响应解析失败",
 			zap.Error(err))
 		return &GameResult{Success: false, Error: "服务器繁忙", ErrCode: 40101}, nil
 	}
 
 	errCodeInt := c.parseErrCode(gameResp.ErrCode)
+	observeErrCode("/gift_code", errCodeInt)
 	isSuccess := c.isSuccess(errCodeInt)
 
 	if isSuccess {
@@ -579,9 +641,8 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 		json.Unmarshal(dataBytes, &redeemData)
 
 		// 兑换成功改为 info 并带上用户标识
-		c.logger.Info("✅ 兑换成功",
+		log.Info("✅ 兑换成功",
 			zap.String("reward", redeemData.Reward),
-			zap.String("fid", c.fid),
 			zap.String("user", c.nickname),
 			zap.String("code", giftCode))
 
@@ -610,32 +671,30 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 		// 根据错误码提供详细信息（与Node逻辑一致）
 		switch errCodeInt {
 		case 40005:
-			c.logger.Info("🚫 账号超出领取次数", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Info("🚫 账号超出领取次数", zap.String("code", giftCode), zap.String("user", c.nickname))
 		case 40006:
-			c.logger.Info("🎯 不满足活动领取条件", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Info("🎯 不满足活动领取条件", zap.String("code", giftCode), zap.String("user", c.nickname))
 		case 40008:
-			c.logger.Info("💫 账号已兑换过", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Info("💫 账号已兑换过", zap.String("code", giftCode), zap.String("user", c.nickname))
 		case 40011:
-			c.logger.Info("🔄 账号已兑换过同类型兑换码", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Info("🔄 账号已兑换过同类型兑换码", zap.String("code", giftCode), zap.String("user", c.nickname))
 		case 40103:
-			c.logger.Error("🤖 验证码识别错误",
+			log.Error("🤖 验证码识别错误",
 				zap.String("captcha", captchaValue),
 				zap.String("error", errorText),
-				zap.String("fid", c.fid),
 				zap.String("user", c.nickname))
 		case 40009:
-			c.logger.Error("🔐 登录状态失效", zap.String("error", errorText), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Error("🔐 登录状态失效", zap.String("error", errorText), zap.String("user", c.nickname))
 		case 40101:
-			c.logger.Error("🔄 服务器繁忙", zap.String("error", errorText), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Error("🔄 服务器繁忙", zap.String("error", errorText), zap.String("user", c.nickname))
 		case 40007:
-			c.logger.Error("⏰ 兑换码已过期", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Error("⏰ 兑换码已过期", zap.String("code", giftCode), zap.String("user", c.nickname))
 		case 40014:
-			c.logger.Error("❓ 兑换码不存在", zap.String("code", giftCode), zap.String("fid", c.fid), zap.String("user", c.nickname))
+			log.Error("❓ 兑换码不存在", zap.String("code", giftCode), zap.String("user", c.nickname))
 		default:
-			c.logger.Error("❌ 兑换失败",
+			log.Error("❌ 兑换失败",
 				zap.String("error", errorText),
 				zap.Int("err_code", errCodeInt),
-				zap.String("fid", c.fid),
 				zap.String("user", c.nickname))
 		}
 
@@ -649,6 +708,6 @@ func (c *GameClient) RedeemCode(giftCode, captchaValue string) (*GameResult, err
 }
 
 // VerifyAccount 验证账号有效性（与Node版本对齐）
-func (c *GameClient) VerifyAccount(fid string) (*GameResult, error) {
-	return c.Login(fid)
+func (c *GameClient) VerifyAccount(ctx context.Context, fid string) (*GameResult, error) {
+	return c.Login(ctx, fid)
 }
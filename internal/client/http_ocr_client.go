@@ -0,0 +1,320 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPOCROptions provider=http 的请求模板，保存在 ocr_keys.options（JSON）
+// OCR Key 记录中 api_key 为识别服务地址，secret_key 为鉴权头的值（可留空）
+type HTTPOCROptions struct {
+	Method string `json:"method,omitempty"` // 默认 POST
+	// Format 请求体格式：json（默认）/ form（x-www-form-urlencoded）/ multipart（图片以文件上传）
+	Format string `json:"format,omitempty"`
+	// ImageField 图片字段名（默认 image）
+	ImageField string `json:"image_field,omitempty"`
+	// DataURL json/form 模式下以 data:image/png;base64, 前缀发送
+	DataURL bool `json:"data_url,omitempty"`
+	// Extra 额外的固定字段，如 {"model": "cap36"}
+	Extra map[string]string `json:"extra,omitempty"`
+	// AuthHeader 鉴权头名称（默认 Authorization），值取 secret_key
+	AuthHeader string `json:"auth_header,omitempty"`
+	// ResultPath 识别结果在响应 JSON 中的路径，如 data.text、result[0].words；为空时整个响应体即结果
+	ResultPath string `json:"result_path,omitempty"`
+	// ConfidencePath 置信度（0~1 的数值）在响应 JSON 中的路径，可为空
+	ConfidencePath string `json:"confidence_path,omitempty"`
+	TimeoutMs      int    `json:"timeout_ms,omitempty"` // 默认 10000
+}
+
+// ParseHTTPOCROptions 解析并校验请求模板，未填写的字段补默认值
+func ParseHTTPOCROptions(raw string) (*HTTPOCROptions, error) {
+	opts := &HTTPOCROptions{}
+	if s := strings.TrimSpace(raw); s != "" {
+		if err := json.Unmarshal([]byte(s), opts); err != nil {
+			return nil, fmt.Errorf("invalid http ocr options: %w", err)
+		}
+	}
+	opts.Method = strings.ToUpper(strings.TrimSpace(opts.Method))
+	if opts.Method == "" {
+		opts.Method = http.MethodPost
+	}
+	if opts.Method != http.MethodPost && opts.Method != http.MethodPut {
+		return nil, fmt.Errorf("invalid http ocr options: unsupported method %s", opts.Method)
+	}
+	opts.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	switch opts.Format {
+	case "":
+		opts.Format = "json"
+	case "json", "form", "multipart":
+	default:
+		return nil, fmt.Errorf("invalid http ocr options: unsupported format %s", opts.Format)
+	}
+	if opts.ImageField == "" {
+		opts.ImageField = "image"
+	}
+	if opts.AuthHeader == "" {
+		opts.AuthHeader = "Authorization"
+	}
+	if opts.TimeoutMs <= 0 {
+		opts.TimeoutMs = 10000
+	}
+	for _, path := range []string{opts.ResultPath, opts.ConfidencePath} {
+		if _, err := splitResultPath(path); err != nil {
+			return nil, fmt.Errorf("invalid http ocr options: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// ValidateHTTPOCREndpoint 校验识别服务地址
+func ValidateHTTPOCREndpoint(endpoint string) error {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid http ocr endpoint: %q", endpoint)
+	}
+	return nil
+}
+
+// HTTPOCRClient 通用 HTTP 识别服务客户端（ddddocr 等自建服务），请求格式由 HTTPOCROptions 描述
+type HTTPOCRClient struct {
+	endpoint  string
+	authValue string
+	opts      *HTTPOCROptions
+	optsErr   error
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+func NewHTTPOCRClient(endpoint, authValue, options string, logger *zap.Logger) *HTTPOCRClient {
+	c := &HTTPOCRClient{
+		endpoint:  strings.TrimSpace(endpoint),
+		authValue: strings.TrimSpace(authValue),
+		logger:    logger,
+	}
+	c.opts, c.optsErr = ParseHTTPOCROptions(options)
+	if c.optsErr == nil {
+		c.optsErr = ValidateHTTPOCREndpoint(c.endpoint)
+	}
+	if c.optsErr != nil {
+		logger.Error("❌ HTTP OCR 配置无效", zap.String("endpoint", c.endpoint), zap.Error(c.optsErr))
+		return c
+	}
+	c.client = &http.Client{Timeout: time.Duration(c.opts.TimeoutMs) * time.Millisecond}
+	return c
+}
+
+func (c *HTTPOCRClient) RecognizeCaptcha(base64Image string) (string, error) {
+	return c.RecognizeCaptchaContext(context.Background(), base64Image)
+}
+
+func (c *HTTPOCRClient) RecognizeCaptchaContext(ctx context.Context, base64Image string) (string, error) {
+	cands, err := c.RecognizeCandidates(ctx, base64Image)
+	if err != nil {
+		return "", err
+	}
+	return cands[0].Text, nil
+}
+
+// RecognizeCandidates 按模板发送图片并从响应中提取识别结果（配置了 confidence_path 时附带置信度）
+func (c *HTTPOCRClient) RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
+	if c.optsErr != nil {
+		return nil, c.optsErr
+	}
+	req, err := c.buildRequest(ctx, base64Image)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.logger.Error("❌ HTTP OCR 请求失败", zap.String("endpoint", c.endpoint), zap.Error(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		category := "other"
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			category = "auth"
+		case http.StatusTooManyRequests:
+			category = "throttle"
+		}
+		return nil, &OCRError{Code: strconv.Itoa(resp.StatusCode), Msg: truncateBody(body), Category: category}
+	}
+
+	text, err := extractResult(body, c.opts.ResultPath)
+	if err != nil {
+		c.logger.Warn("HTTP OCR 响应解析失败", zap.String("endpoint", c.endpoint), zap.String("body", truncateBody(body)), zap.Error(err))
+		return nil, err
+	}
+	norm := normalizeTo4(text)
+	if len(norm) != 4 {
+		return nil, fmt.Errorf("%w: %s", errCaptchaLength, norm)
+	}
+	confidence := ConfidenceUnknown
+	if c.opts.ConfidencePath != "" {
+		if v, err := extractResult(body, c.opts.ConfidencePath); err == nil {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				confidence = f
+			}
+		}
+	}
+	c.logger.Debug("✅ HTTP OCR 识别成功", zap.String("result", norm), zap.String("original", text), zap.Float64("confidence", confidence))
+	return []CaptchaCandidate{{Text: norm, Confidence: confidence}}, nil
+}
+
+func (c *HTTPOCRClient) buildRequest(ctx context.Context, base64Image string) (*http.Request, error) {
+	opts := c.opts
+	b64 := stripDataURL(base64Image)
+	value := b64
+	if opts.DataURL {
+		value = "data:image/png;base64," + b64
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch opts.Format {
+	case "json":
+		fields := make(map[string]string, len(opts.Extra)+1)
+		for k, v := range opts.Extra {
+			fields[k] = v
+		}
+		fields[opts.ImageField] = value
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	case "form":
+		form := url.Values{}
+		for k, v := range opts.Extra {
+			form.Set(k, v)
+		}
+		form.Set(opts.ImageField, value)
+		body, contentType = strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+	case "multipart":
+		raw, err := decodeCaptchaBase64(base64Image)
+		if err != nil {
+			return nil, fmt.Errorf("http ocr: decode base64: %w", err)
+		}
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for k, v := range opts.Extra {
+			if err := w.WriteField(k, v); err != nil {
+				return nil, err
+			}
+		}
+		part, err := w.CreateFormFile(opts.ImageField, "captcha.png")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		body, contentType = &buf, w.FormDataContentType()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, opts.Method, c.endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json, text/plain")
+	if c.authValue != "" {
+		req.Header.Set(opts.AuthHeader, c.authValue)
+	}
+	return req, nil
+}
+
+// splitResultPath 将 data.items[0].text 拆为 ["data", "items", "0", "text"]
+func splitResultPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, nil
+	}
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	parts := strings.Split(strings.TrimPrefix(path, "."), ".")
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid result path %q", path)
+		}
+	}
+	return parts, nil
+}
+
+// extractResult 按路径取出识别文本；路径为空时响应体为纯文本或 JSON 字符串
+func extractResult(body []byte, path string) (string, error) {
+	parts, err := splitResultPath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		var s string
+		if json.Unmarshal(body, &s) == nil {
+			return s, nil
+		}
+		return strings.TrimSpace(string(body)), nil
+	}
+
+	var cur interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&cur); err != nil {
+		return "", fmt.Errorf("http ocr: response is not json: %w", err)
+	}
+	for _, p := range parts {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[p]
+			if !ok {
+				return "", fmt.Errorf("http ocr: result path %s not found", path)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("http ocr: result path %s not found", path)
+			}
+			cur = v[i]
+		default:
+			return "", fmt.Errorf("http ocr: result path %s not found", path)
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", errors.New("http ocr: result is not a string")
+	}
+}
+
+func truncateBody(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -1,94 +1,298 @@
 package client
 
 import (
-	"bytes"
-	"encoding/base64"
-	"fmt"
 	"image"
 	"image/color"
-	_ "image/gif"
-	_ "image/jpeg"
-	"image/png"
 	"math"
-	"strings"
 )
 
-// preprocessCaptchaBase64 对验证码图片进行预处理：去前缀、解码、放大、灰度化、二值化、重编码
-func preprocessCaptchaBase64(base64Image string) (string, error) {
-	// 1. 去掉 data:image/xxx;base64, 前缀
-	normalized := base64Image
-	if p := strings.Index(normalized, ","); p > 0 && strings.Contains(strings.ToLower(normalized[:p]), "base64") {
-		normalized = normalized[p+1:]
+// 验证码预处理的基础图像操作，由 CaptchaPipeline 按配置组合调用
+
+// scaleImage 按倍数缩放：nearest 最近邻（保持锐利边缘）/ bilinear 双线性（边缘更平滑）
+func scaleImage(src image.Image, factor float64, bilinear bool) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	scaledWidth := int(math.Round(float64(width) * factor))
+	scaledHeight := int(math.Round(float64(height) * factor))
+	if scaledWidth < 1 {
+		scaledWidth = 1
 	}
-	normalized = strings.ReplaceAll(normalized, "\n", "")
-	normalized = strings.ReplaceAll(normalized, "\r", "")
-	normalized = strings.TrimSpace(normalized)
+	if scaledHeight < 1 {
+		scaledHeight = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
 
-	// 2. 解码为图像
-	imgBytes, err := base64.StdEncoding.DecodeString(normalized)
-	if err != nil {
-		return "", fmt.Errorf("base64 decode failed: %w", err)
+	for y := 0; y < scaledHeight; y++ {
+		for x := 0; x < scaledWidth; x++ {
+			if !bilinear {
+				// 最近邻插值
+				srcX := int(float64(x) / factor)
+				srcY := int(float64(y) / factor)
+				if srcX >= width {
+					srcX = width - 1
+				}
+				if srcY >= height {
+					srcY = height - 1
+				}
+				scaled.Set(x, y, src.At(bounds.Min.X+srcX, bounds.Min.Y+srcY))
+				continue
+			}
+			// 双线性插值：按像素中心对齐
+			fx := math.Max(0, (float64(x)+0.5)/factor-0.5)
+			fy := math.Max(0, (float64(y)+0.5)/factor-0.5)
+			x0, y0 := int(fx), int(fy)
+			x1, y1 := min(x0+1, width-1), min(y0+1, height-1)
+			x0, y0 = min(x0, width-1), min(y0, height-1)
+			wx, wy := fx-float64(x0), fy-float64(y0)
+			var out [4]float64
+			for _, p := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - wx) * (1 - wy)},
+				{x1, y0, wx * (1 - wy)},
+				{x0, y1, (1 - wx) * wy},
+				{x1, y1, wx * wy},
+			} {
+				r, g, b, a := src.At(bounds.Min.X+p.x, bounds.Min.Y+p.y).RGBA()
+				out[0] += float64(r) * p.w
+				out[1] += float64(g) * p.w
+				out[2] += float64(b) * p.w
+				out[3] += float64(a) * p.w
+			}
+			scaled.Set(x, y, color.RGBA64{R: uint16(out[0]), G: uint16(out[1]), B: uint16(out[2]), A: uint16(out[3])})
+		}
 	}
+	return scaled
+}
 
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
-	if err != nil {
-		return "", fmt.Errorf("image decode failed: %w", err)
+// toGray 灰度化（加权平均）；已是灰度图时原样返回
+func toGray(src image.Image) *image.Gray {
+	if g, ok := src.(*image.Gray); ok {
+		return g
+	}
+	bounds := src.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			r, g, b, _ := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			gray.SetGray(x, y, color.Gray{Y: uint8((0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 256)})
+		}
 	}
+	return gray
+}
 
-	// 3. 图像预处理：放大 2x + 灰度化 + 二值化
-	processed := preprocessImage(img)
+// thresholdImage 固定阈值二值化：大于阈值设为白色，小于等于阈值设为黑色
+func thresholdImage(src image.Image, level uint8) *image.Gray {
+	gray := toGray(src)
+	bounds := gray.Bounds()
+	binary := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if gray.GrayAt(x, y).Y > level {
+				binary.SetGray(x, y, color.Gray{Y: 255})
+			} else {
+				binary.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return binary
+}
 
-	// 4. 编码回 base64
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, processed); err != nil {
-		return "", fmt.Errorf("png encode failed: %w", err)
+// otsuLevel 大津法：选取使前景/背景类间方差最大的阈值
+func otsuLevel(gray *image.Gray) uint8 {
+	var hist [256]int
+	bounds := gray.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			hist[gray.GrayAt(x, y).Y]++
+		}
 	}
+	total := bounds.Dx() * bounds.Dy()
+	sumAll := 0.0
+	for i, n := range hist {
+		sumAll += float64(i * n)
+	}
+	var (
+		sumBg, bestVar float64
+		weightBg       int
+		best           uint8
+	)
+	for t := 0; t < 256; t++ {
+		weightBg += hist[t]
+		if weightBg == 0 {
+			continue
+		}
+		weightFg := total - weightBg
+		if weightFg == 0 {
+			break
+		}
+		sumBg += float64(t * hist[t])
+		meanBg := sumBg / float64(weightBg)
+		meanFg := (sumAll - sumBg) / float64(weightFg)
+		between := float64(weightBg) * float64(weightFg) * (meanBg - meanFg) * (meanBg - meanFg)
+		if between > bestVar {
+			bestVar, best = between, uint8(t)
+		}
+	}
+	return best
+}
 
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+// adaptiveThreshold 自适应阈值：像素亮度低于 block×block 邻域均值减 c 时为黑色（适合背景亮度不均的验证码）
+func adaptiveThreshold(src image.Image, block int, c int) *image.Gray {
+	gray := toGray(src)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// 积分图加速邻域求和
+	integral := make([]int, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		row := 0
+		for x := 0; x < width; x++ {
+			row += int(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + row
+		}
+	}
+	half := block / 2
+	binary := image.NewGray(bounds)
+	for y := 0; y < height; y++ {
+		y0, y1 := max(0, y-half), min(height, y+half+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := max(0, x-half), min(width, x+half+1)
+			sum := integral[y1*(width+1)+x1] - integral[y0*(width+1)+x1] - integral[y1*(width+1)+x0] + integral[y0*(width+1)+x0]
+			mean := sum / ((x1 - x0) * (y1 - y0))
+			v := uint8(255)
+			if int(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y) < mean-c {
+				v = 0
+			}
+			binary.SetGray(bounds.Min.X+x, bounds.Min.Y+y, color.Gray{Y: v})
+		}
+	}
+	return binary
 }
 
-// preprocessImage 对图像进行预处理：放大2倍、灰度化、二值化
-func preprocessImage(src image.Image) image.Image {
+// removeBackground 以四周边框像素的平均色为背景色，与其颜色距离（0~441）不超过 maxDist 的像素置为白色
+func removeBackground(src image.Image, maxDist float64) image.Image {
 	bounds := src.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
-
-	// 放大 2 倍
-	scaledWidth, scaledHeight := width*2, height*2
-	scaled := image.NewRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
-
-	for y := 0; y < scaledHeight; y++ {
-		for x := 0; x < scaledWidth; x++ {
-			// 最近邻插值
-			srcX := x / 2
-			srcY := y / 2
-			if srcX >= width {
-				srcX = width - 1
+	var sr, sg, sb, n float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x != 0 && y != 0 && x != width-1 && y != height-1 {
+				continue
 			}
-			if srcY >= height {
-				srcY = height - 1
+			r, g, b, _ := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			sr, sg, sb, n = sr+float64(r), sg+float64(g), sb+float64(b), n+1
+		}
+	}
+	if n == 0 {
+		return src
+	}
+	bg := color.RGBA64{R: uint16(sr / n), G: uint16(sg / n), B: uint16(sb / n), A: 0xffff}
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := src.At(bounds.Min.X+x, bounds.Min.Y+y)
+			// RGBA() 为 16 位分量，换算到 8 位尺度
+			if colorDistance(c, bg)/257 <= maxDist {
+				out.Set(x, y, color.White)
+			} else {
+				out.Set(x, y, c)
 			}
-			scaled.Set(x, y, src.At(bounds.Min.X+srcX, bounds.Min.Y+srcY))
 		}
 	}
+	return out
+}
 
-	// 灰度化 + 二值化（阈值 128）
-	binary := image.NewGray(image.Rect(0, 0, scaledWidth, scaledHeight))
-	for y := 0; y < scaledHeight; y++ {
-		for x := 0; x < scaledWidth; x++ {
-			r, g, b, _ := scaled.At(x, y).RGBA()
-			// 灰度值（加权平均）
-			gray := uint8((0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 256)
-			// 二值化：大于 128 设为白色，小于等于 128 设为黑色
-			if gray > 128 {
-				binary.SetGray(x, y, color.Gray{Y: 255})
-			} else {
-				binary.SetGray(x, y, color.Gray{Y: 0})
+// removeThinLines 去除干扰线：二值图中横向或纵向连续黑色像素不超过 maxWidth 的像素视为细线并置白
+// （字符笔画在放大后通常宽于干扰线）
+func removeThinLines(src image.Image, maxWidth int) *image.Gray {
+	gray := toGray(src)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dark := func(x, y int) bool { return gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y < 128 }
+	hRun := make([]int, width*height)
+	vRun := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; {
+			if !dark(x, y) {
+				x++
+				continue
+			}
+			end := x
+			for end < width && dark(end, y) {
+				end++
+			}
+			for i := x; i < end; i++ {
+				hRun[y*width+i] = end - x
+			}
+			x = end
+		}
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; {
+			if !dark(x, y) {
+				y++
+				continue
 			}
+			end := y
+			for end < height && dark(x, end) {
+				end++
+			}
+			for i := y; i < end; i++ {
+				vRun[i*width+x] = end - y
+			}
+			y = end
+		}
+	}
+	out := image.NewGray(bounds)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y)
+			if dark(x, y) && min(hRun[y*width+x], vRun[y*width+x]) <= maxWidth {
+				v = color.Gray{Y: 255}
+			}
+			out.SetGray(bounds.Min.X+x, bounds.Min.Y+y, v)
 		}
 	}
+	return out
+}
 
-	// 可选：轻度高斯模糊去噪（简化版）
-	return applyLightSmoothing(binary)
+// cropImage 裁剪：rect 非空时按固定区域裁剪；否则裁剪到深色像素（亮度 < 128）的外接矩形并保留 pad 像素边距
+func cropImage(src image.Image, rect image.Rectangle, pad int) image.Image {
+	bounds := src.Bounds()
+	if rect.Empty() {
+		gray := toGray(src)
+		gb := gray.Bounds()
+		found := false
+		for y := gb.Min.Y; y < gb.Max.Y; y++ {
+			for x := gb.Min.X; x < gb.Max.X; x++ {
+				if gray.GrayAt(x, y).Y >= 128 {
+					continue
+				}
+				p := image.Rect(x, y, x+1, y+1)
+				if !found {
+					rect, found = p, true
+				} else {
+					rect = rect.Union(p)
+				}
+			}
+		}
+		if !found {
+			return src
+		}
+		rect = rect.Inset(-pad).Intersect(gb).Sub(gb.Min)
+	}
+	rect = rect.Add(bounds.Min).Intersect(bounds)
+	if rect.Empty() {
+		return src
+	}
+	out := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			out.Set(x, y, src.At(rect.Min.X+x, rect.Min.Y+y))
+		}
+	}
+	return out
 }
 
 // applyLightSmoothing 应用轻度平滑滤波，减少噪点
@@ -140,7 +344,3 @@ func colorDistance(c1, c2 color.Color) float64 {
 	db := float64(b1) - float64(b2)
 	return math.Sqrt(dr*dr + dg*dg + db*db)
 }
-
-
-
-
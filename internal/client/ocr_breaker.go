@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/metrics"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常调度
+	BreakerOpen     = "open"      // 连续失败后暂停调度，冷却结束后进入半开
+	BreakerHalfOpen = "half_open" // 放行单个探测请求：成功则恢复，失败则加倍冷却后重新熔断
+)
+
+// admit 拒绝原因
+const (
+	admitBreaker   = "breaker"
+	admitThrottled = "throttled"
+)
+
+// defaultProviderQPS 各 provider 的默认 QPS 上限（Key 未单独设置 qps_limit 时使用，未列出的不限流）
+var defaultProviderQPS = map[string]float64{
+	"baidu": 2, // 百度 OCR 免费额度 2 QPS
+}
+
+// errCaptchaLength 识别结果不是4位（识别质量问题，不计入熔断）
+var errCaptchaLength = errors.New("验证码长度异常")
+
+// BreakerConfig 熔断策略
+type BreakerConfig struct {
+	Failures    int           // 连续失败达到该次数时熔断
+	Cooldown    time.Duration // 熔断后到首次半开探测的等待时间
+	MaxCooldown time.Duration // 探测失败时冷却时间翻倍的上限
+}
+
+// keyBreaker 单个 Key 的熔断器（由 OCRKeyManager.mu 保护，Reload 后保留）
+type keyBreaker struct {
+	state     string
+	failures  int
+	cooldown  time.Duration
+	openUntil time.Time
+	probing   bool
+	lastError string
+}
+
+// BreakerStatus 熔断器与限流状态（管理端展示）
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenUntil           *time.Time `json:"openUntil,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	QPSLimit            float64    `json:"qpsLimit"` // 0 表示不限流
+}
+
+// keyQPS Key 的实际 QPS 上限：qps_limit > 0 时使用自身设置，否则使用 provider 默认值
+func keyQPS(provider string, qpsLimit float64) float64 {
+	if qpsLimit > 0 {
+		return qpsLimit
+	}
+	return defaultProviderQPS[strings.ToLower(strings.TrimSpace(provider))]
+}
+
+// newKeyLimiter 令牌桶容量为 1：请求按 1/QPS 的间隔均匀放行，避免瞬时突发触发服务端限流
+func newKeyLimiter(qps float64) *rate.Limiter {
+	if qps <= 0 {
+		return rate.NewLimiter(rate.Inf, 1)
+	}
+	return rate.NewLimiter(rate.Limit(qps), 1)
+}
+
+// breakerFor 获取或创建 Key 的熔断器（调用方持有写锁）
+func (m *OCRKeyManager) breakerFor(keyID int) *keyBreaker {
+	b, ok := m.breakers[keyID]
+	if !ok {
+		b = &keyBreaker{state: BreakerClosed}
+		m.breakers[keyID] = b
+	}
+	return b
+}
+
+// admit 判断本次能否使用该 Key：熔断中或已达 QPS 上限时返回拒绝原因；冷却结束的熔断 Key 放行一个探测请求
+func (m *OCRKeyManager) admit(wk *weightedKey) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.breakerFor(wk.key.ID)
+	halfOpen := false
+	switch b.state {
+	case BreakerOpen:
+		if time.Now().Before(b.openUntil) {
+			return admitBreaker
+		}
+		halfOpen = true
+	case BreakerHalfOpen:
+		if b.probing {
+			return admitBreaker
+		}
+		halfOpen = true
+	}
+	if !wk.limiter.Allow() {
+		metrics.OCRThrottled.WithLabelValues(wk.key.Provider, strconv.Itoa(wk.key.ID)).Inc()
+		return admitThrottled
+	}
+	if halfOpen {
+		b.state, b.probing = BreakerHalfOpen, true
+		m.setBreakerGauge(wk, b)
+		m.logger.Info("OCR Key 熔断冷却结束，放行探测请求", zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider))
+	}
+	return ""
+}
+
+// waitForToken 所有可调度的 Key 均已达到 QPS 上限时，等待其中最早可用的令牌
+func (m *OCRKeyManager) waitForToken(ctx context.Context, throttled []*weightedKey) error {
+	var delay time.Duration
+	for i, wk := range throttled {
+		r := wk.limiter.Reserve()
+		d := r.Delay()
+		r.Cancel()
+		if i == 0 || d < delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recordOutcome 更新熔断器：服务端/网络错误累计连续失败，限流错误立即熔断；识别结果异常视为 Key 正常
+func (m *OCRKeyManager) recordOutcome(ctx context.Context, wk *weightedKey, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.breakerFor(wk.key.ID)
+	log := logging.FromContext(ctx, m.logger).With(zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider))
+	failed := err != nil && !errors.Is(err, errCaptchaLength)
+	if failed && ctx.Err() != nil {
+		// 调用方取消/超时，不归咎于 Key
+		b.probing = false
+		return
+	}
+	if !failed {
+		if b.state != BreakerClosed {
+			log.Info("OCR Key 探测成功，熔断恢复")
+		}
+		b.state, b.failures, b.cooldown, b.probing, b.lastError = BreakerClosed, 0, 0, false, ""
+		m.setBreakerGauge(wk, b)
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	var oe *OCRError
+	throttled := errors.As(err, &oe) && oe.Category == "throttle"
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.cooldown *= 2
+	case throttled || b.failures >= m.breaker.Failures:
+		b.cooldown = m.breaker.Cooldown
+	default:
+		return
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = m.breaker.Cooldown
+	}
+	if b.cooldown > m.breaker.MaxCooldown {
+		b.cooldown = m.breaker.MaxCooldown
+	}
+	b.state, b.probing = BreakerOpen, false
+	b.openUntil = time.Now().Add(b.cooldown)
+	m.setBreakerGauge(wk, b)
+	log.Warn("OCR Key 已熔断", zap.Int("consecutive_failures", b.failures), zap.Bool("throttled", throttled),
+		zap.Duration("cooldown", b.cooldown), zap.Error(err))
+}
+
+// setBreakerGauge 上报熔断状态：0 正常 / 1 半开 / 2 熔断（调用方持有锁）
+func (m *OCRKeyManager) setBreakerGauge(wk *weightedKey, b *keyBreaker) {
+	v := 0.0
+	switch b.state {
+	case BreakerHalfOpen:
+		v = 1
+	case BreakerOpen:
+		v = 2
+	}
+	metrics.OCRBreakerState.WithLabelValues(wk.key.Provider, strconv.Itoa(wk.key.ID)).Set(v)
+}
+
+// BreakerStates 当前已加载 Key 的熔断与限流状态
+func (m *OCRKeyManager) BreakerStates() map[int]BreakerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[int]BreakerStatus, len(m.keys))
+	now := time.Now()
+	for _, wk := range m.keys {
+		st := BreakerStatus{State: BreakerClosed, QPSLimit: keyQPS(wk.key.Provider, wk.key.QPSLimit)}
+		if b, ok := m.breakers[wk.key.ID]; ok {
+			st.State, st.ConsecutiveFailures, st.LastError = b.state, b.failures, b.lastError
+			if b.state == BreakerOpen {
+				until := b.openUntil
+				st.OpenUntil = &until
+				if !now.Before(until) {
+					st.State = BreakerHalfOpen // 冷却已结束，下次调度即探测
+				}
+			}
+		}
+		out[wk.key.ID] = st
+	}
+	return out
+}
+
+// SetBreaker 设置熔断策略
+func (m *OCRKeyManager) SetBreaker(cfg BreakerConfig) {
+	if cfg.Failures < 1 {
+		cfg.Failures = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.MaxCooldown < cfg.Cooldown {
+		cfg.MaxCooldown = cfg.Cooldown
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breaker = cfg
+}
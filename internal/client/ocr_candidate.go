@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// ConfidenceUnknown 识别器不提供置信度时的取值
+const ConfidenceUnknown = -1.0
+
+// CaptchaCandidate 一个识别候选（Text 已规范为4位大写字母数字）
+type CaptchaCandidate struct {
+	Text string `json:"text"`
+	// Confidence 0~1；识别器不提供时为 ConfidenceUnknown
+	Confidence float64 `json:"confidence"`
+	Provider   string  `json:"provider,omitempty"`
+	KeyID      int     `json:"key_id,omitempty"`
+	// Image 实际发送给识别器的图片（按 provider 预处理后的 base64）
+	Image string `json:"-"`
+}
+
+// CandidateRecognizer 可选接口：返回带置信度的候选，按置信度从高到低排列
+type CandidateRecognizer interface {
+	RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error)
+}
+
+// ErrNoConsensus 多 Provider 投票未达成一致且置信度均未达到阈值（fallback=refetch 时返回，调用方应重新获取验证码）
+var ErrNoConsensus = errors.New("ocr providers did not reach consensus")
+
+// recognizeCandidates 统一调用入口：优先使用 CandidateRecognizer，否则将单个结果包装为置信度未知的候选
+func recognizeCandidates(ctx context.Context, r OCRRecognizer, base64Image string) ([]CaptchaCandidate, error) {
+	if cr, ok := r.(CandidateRecognizer); ok {
+		return cr.RecognizeCandidates(ctx, base64Image)
+	}
+	var (
+		text string
+		err  error
+	)
+	if cr, ok := r.(ContextRecognizer); ok {
+		text, err = cr.RecognizeCaptchaContext(ctx, base64Image)
+	} else {
+		text, err = r.RecognizeCaptcha(base64Image)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if norm := normalizeTo4(text); len(norm) == 4 {
+		return []CaptchaCandidate{{Text: norm, Confidence: ConfidenceUnknown}}, nil
+	}
+	return nil, nil
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -37,6 +38,11 @@ type OCRRecognizeResponse struct {
 	LogID       int64 `json:"log_id"`
 	WordsResult []struct {
 		Words string `json:"words"`
+		// Probability 请求 probability=true 时返回的行置信度
+		Probability *struct {
+			Average float64 `json:"average"`
+			Min     float64 `json:"min"`
+		} `json:"probability,omitempty"`
 	} `json:"words_result"`
 	ErrorCode int    `json:"error_code,omitempty"`
 	ErrorMsg  string `json:"error_msg,omitempty"`
@@ -164,8 +170,11 @@ func (c *OCRClient) recognizeGeneral(base64Image string) (string, error) {
 			zap.Int("error_code", result.ErrorCode),
 			zap.String("error_msg", result.ErrorMsg))
 		category := "other"
-		quotaCodes := []int{4, 17, 18, 19, 216604}
+		quotaCodes := []int{4, 17, 19, 216604}
 		authCodes := []int{6, 14, 110, 111}
+		if result.ErrorCode == 18 {
+			category = "throttle" // QPS 超限：由熔断器暂停调度，不视为额度用尽
+		}
 		for _, code := range quotaCodes {
 			if code == result.ErrorCode {
 				category = "quota"
@@ -213,11 +222,11 @@ func (c *OCRClient) recognizeGeneral(base64Image string) (string, error) {
 	return "", nil
 }
 
-// recognizeAccurate 百度高精度文字识别（与Node版本对齐）
-func (c *OCRClient) recognizeAccurate(base64Image string) (string, error) {
+// recognizeAccurate 百度高精度文字识别（与Node版本对齐），同时返回各行平均置信度中的最小值
+func (c *OCRClient) recognizeAccurate(base64Image string) (string, float64, error) {
 	accessToken, err := c.getAccessToken()
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	// 清理base64数据
@@ -235,24 +244,24 @@ func (c *OCRClient) recognizeAccurate(base64Image string) (string, error) {
 	data.Set("image", base64Image)
 	data.Set("detect_direction", "false")
 	data.Set("paragraph", "false")
-	data.Set("probability", "false")
+	data.Set("probability", "true")
 
 	resp, err := c.client.PostForm(apiURL, data)
 	if err != nil {
 		// 降噪：请求异常保留错误级别
 		c.logger.Error("❌ 百度高精度OCR请求失败", zap.Error(err))
-		return "", err
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	var result OCRRecognizeResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	if result.ErrorCode != 0 {
@@ -260,8 +269,11 @@ func (c *OCRClient) recognizeAccurate(base64Image string) (string, error) {
 			zap.Int("error_code", result.ErrorCode),
 			zap.String("error_msg", result.ErrorMsg))
 		category := "other"
-		quotaCodes := []int{4, 17, 18, 19, 216604}
+		quotaCodes := []int{4, 17, 19, 216604}
 		authCodes := []int{6, 14, 110, 111}
+		if result.ErrorCode == 18 {
+			category = "throttle" // QPS 超限：由熔断器暂停调度，不视为额度用尽
+		}
 		for _, code := range quotaCodes {
 			if code == result.ErrorCode {
 				category = "quota"
@@ -276,14 +288,18 @@ func (c *OCRClient) recognizeAccurate(base64Image string) (string, error) {
 				}
 			}
 		}
-		return "", &OCRError{Code: fmt.Sprintf("%d", result.ErrorCode), Msg: result.ErrorMsg, Category: category}
+		return "", 0, &OCRError{Code: fmt.Sprintf("%d", result.ErrorCode), Msg: result.ErrorMsg, Category: category}
 	}
 
 	if len(result.WordsResult) > 0 {
 		// 拼接所有识别到的文字
 		var words []string
+		confidence := ConfidenceUnknown
 		for _, item := range result.WordsResult {
 			words = append(words, strings.TrimSpace(item.Words))
+			if item.Probability != nil && (confidence == ConfidenceUnknown || item.Probability.Average < confidence) {
+				confidence = item.Probability.Average
+			}
 		}
 		fullText := strings.Join(words, "")
 
@@ -300,25 +316,42 @@ func (c *OCRClient) recognizeAccurate(base64Image string) (string, error) {
 			c.logger.Debug("✅ 百度高精度OCR识别成功",
 				zap.String("result", cleanedText),
 				zap.String("original", fullText))
-			return cleanedText, nil
+			return cleanedText, confidence, nil
 		}
 	}
 
 	// 降噪：保持一次警告
 	c.logger.Warn("❌ 百度高精度OCR未识别到有效文字")
-	return "", nil
+	return "", ConfidenceUnknown, nil
 }
 
 // RecognizeCaptcha 直接使用高精度识别（验证码专用，与Node版本对齐）
 func (c *OCRClient) RecognizeCaptcha(base64Image string) (string, error) {
+	text, _, err := c.recognizeCaptcha(base64Image)
+	return text, err
+}
+
+// RecognizeCandidates 与 RecognizeCaptcha 相同，附带百度返回的置信度
+func (c *OCRClient) RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	text, confidence, err := c.recognizeCaptcha(base64Image)
+	if err != nil {
+		return nil, err
+	}
+	return []CaptchaCandidate{{Text: normalizeTo4(text), Confidence: confidence}}, nil
+}
+
+func (c *OCRClient) recognizeCaptcha(base64Image string) (string, float64, error) {
 	// 降噪：识别起始改为调试级别
 	c.logger.Debug("🤖 使用高精度OCR识别验证码...")
 
 	// 直接使用高精度版本（与Node逻辑一致）
-	result, err := c.recognizeAccurate(base64Image)
+	result, confidence, err := c.recognizeAccurate(base64Image)
 	if err != nil {
 		c.logger.Error("❌ 验证码识别失败", zap.Error(err))
-		return "", err
+		return "", 0, err
 	}
 
 	if result != "" && len(result) == 4 {
@@ -326,17 +359,17 @@ func (c *OCRClient) RecognizeCaptcha(base64Image string) (string, error) {
 		c.logger.Debug("✅ 验证码识别成功",
 			zap.String("result", result),
 			zap.Int("length", len(result)))
-		return result, nil
+		return result, confidence, nil
 	} else if result != "" && len(result) != 4 {
 		c.logger.Warn("⚠️ 验证码长度异常",
 			zap.String("result", result),
 			zap.Int("length", len(result)),
 			zap.Int("expected", 4))
-		return "", fmt.Errorf("验证码长度异常: %d (期望: 4)", len(result))
+		return "", 0, fmt.Errorf("%w: %d (期望: 4)", errCaptchaLength, len(result))
 	}
 
 	c.logger.Warn("❌ 验证码识别失败")
-	return "", fmt.Errorf("验证码识别失败")
+	return "", 0, fmt.Errorf("验证码识别失败")
 }
 
 // RecognizeWithRetry 保留原有的通用识别方法（与Node版本对齐）
@@ -349,7 +382,7 @@ func (c *OCRClient) RecognizeWithRetry(base64Image string) (string, error) {
 
 	// 标准版失败，尝试高精度版
 	c.logger.Warn("⚠️ 标准版识别效果不佳，尝试高精度版...")
-	result, err = c.recognizeAccurate(base64Image)
+	result, _, err = c.recognizeAccurate(base64Image)
 	if err == nil && result != "" && len(result) >= 3 {
 		return result, nil
 	}
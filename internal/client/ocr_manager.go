@@ -1,15 +1,21 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/metrics"
 	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 // OCRRecognizer 定义识别接口，便于替换实现
@@ -17,13 +23,24 @@ type OCRRecognizer interface {
 	RecognizeCaptcha(base64Image string) (string, error)
 }
 
+// ContextRecognizer 可选接口：支持透传上下文的识别器（用于链路追踪）
+type ContextRecognizer interface {
+	RecognizeCaptchaContext(ctx context.Context, base64Image string) (string, error)
+}
+
 // weightedKey 内部结构体
 type weightedKey struct {
 	key        model.OCRKey
 	recognizer OCRRecognizer
 	current    int // 平滑加权轮询当前值
+	baseWeight int // 配置权重按剩余额度调整后的权重
+	weight     int // baseWeight 再按游戏判定的准确率调整后的实际调度权重
+	limiter    *rate.Limiter
 }
 
+// accuracyScale 准确率调整时的放大倍数（保持整数权重的精度）
+const accuracyScale = 100
+
 // OCRKeyManager 多 Key 调度器（线程安全）
 type OCRKeyManager struct {
 	logger *zap.Logger
@@ -31,21 +48,60 @@ type OCRKeyManager struct {
 	keys   []*weightedKey
 	total  int
 	rnd    *rand.Rand
-	// onKeyExhausted 在检测到额度问题时回调（由上层注入，负责更新DB并触发热更新）
-	onKeyExhausted func(keyID int, code int, msg string)
-	// onUsage 每次调用后上报一次使用统计（成功/失败）
-	onUsage func(keyID int, success bool, errMsg *string)
+	// onKeyExhausted 在检测到额度/鉴权问题时回调（由上层注入，负责更新DB并触发热更新）；reason 为 model.OCRKeyDisabled*
+	onKeyExhausted func(keyID int, code int, msg, reason string)
+	// onUsage 每次调用后上报一次使用统计（成功/失败；billable 表示请求已被服务端处理、消耗额度）
+	onUsage func(keyID int, success, billable bool, errMsg *string)
+	// consensus 多 Provider 投票策略（默认关闭）
+	consensus ConsensusConfig
+	// stats 按 Key ID 的滚动统计（Reload 后保留）
+	stats map[int]*keyStats
+	// onVerdict 游戏判定回调（由上层持久化累计判定数）
+	onVerdict func(keyID int, correct bool)
+	// pipelines 按 provider 选择的验证码预处理流水线（未设置时发送原图）
+	pipelines *CaptchaPipelines
+	// breakers 按 Key ID 的熔断器（Reload 后保留，凭据变更时重置）
+	breakers map[int]*keyBreaker
+	breaker  BreakerConfig
+	// credentials 解密数据库中的凭据（未设置时按明文使用）
+	credentials CredentialDecrypter
+}
+
+// CredentialDecrypter 解密 OCR Key 凭据（由 utils.CredentialCipher 实现）
+type CredentialDecrypter interface {
+	Decrypt(value string) (string, error)
+}
+
+// 投票未达成一致时的处理方式
+const (
+	ConsensusFallbackBest    = "best"    // 提交置信度最高（无置信度时票数最多）的答案
+	ConsensusFallbackRefetch = "refetch" // 放弃本次验证码，由调用方重新获取
+)
+
+// ConsensusConfig 多 Provider 投票：并行询问 Voters 个不同 Provider 的 Key，
+// 至少 MinAgree 个结果一致或任一结果置信度不低于 Confidence 时才采用
+type ConsensusConfig struct {
+	Enabled    bool
+	Voters     int
+	MinAgree   int
+	Confidence float64 // 0 表示不按置信度采用
+	Fallback   string
 }
 
 func NewOCRKeyManager(logger *zap.Logger) *OCRKeyManager {
-	return &OCRKeyManager{logger: logger, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	return &OCRKeyManager{logger: logger, rnd: rand.New(rand.NewSource(time.Now().UnixNano())), stats: map[int]*keyStats{}, breakers: map[int]*keyBreaker{},
+		breaker: BreakerConfig{Failures: 5, Cooldown: 30 * time.Second, MaxCooldown: 10 * time.Minute}}
 }
 
 // Reload 用最新 key 列表重建内部结构
 func (m *OCRKeyManager) Reload(keys []model.OCRKey) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.keys = m.keys[:0]
+	previous := make(map[int]*weightedKey, len(m.keys))
+	for _, wk := range m.keys {
+		previous[wk.key.ID] = wk
+	}
+	m.keys = nil
 	m.total = 0
 	// 统计不同provider数量
 	providerCount := map[string]int{}
@@ -59,19 +115,48 @@ func (m *OCRKeyManager) Reload(keys []model.OCRKey) {
 			provider = "baidu"
 		}
 		if factory, ok := getOCRProvider(provider); ok {
-			recognizer = factory(k.APIKey, k.SecretKey, "", m.logger)
+			options := ""
+			if k.Options != nil {
+				options = *k.Options
+			}
+			apiKey, secretKey, err := m.decryptCredentials(k)
+			if err != nil {
+				m.logger.Error("解密OCR Key凭据失败，已跳过", zap.Int("key_id", k.ID), zap.Error(err))
+				continue
+			}
+			recognizer = factory(apiKey, secretKey, options, m.logger)
 		} else {
 			// 未注册的 provider：跳过（未来可通过配置/插件注册）
 			m.logger.Warn("未注册的OCR Provider，已跳过", zap.String("provider", provider))
 			continue
 		}
 		wk := &weightedKey{key: k, recognizer: recognizer, current: 0}
+		qps := keyQPS(provider, k.QPSLimit)
+		if old, ok := previous[k.ID]; ok {
+			// 凭据或配置变更后重新评估：清除熔断状态
+			if old.key.APIKey != k.APIKey || old.key.SecretKey != k.SecretKey || !sameOptions(old.key.Options, k.Options) {
+				delete(m.breakers, k.ID)
+			}
+			// QPS 未变时沿用原令牌桶，避免热更新瞬间放行突发请求
+			if keyQPS(provider, old.key.QPSLimit) == qps {
+				wk.limiter = old.limiter
+			}
+		}
+		if wk.limiter == nil {
+			wk.limiter = newKeyLimiter(qps)
+		}
 		m.keys = append(m.keys, wk)
-		// 剩余额度越高，实际权重可适当抬升（简易：剩余占比 * weight）
+		// 首次加载（如进程重启）时用数据库中的累计判定数初始化准确率窗口
+		if _, ok := m.stats[k.ID]; !ok {
+			m.statsFor(k.ID, k.Provider).seed(k.VerifiedCorrect, k.VerifiedWrong)
+		} else {
+			m.statsFor(k.ID, k.Provider)
+		}
+		// 剩余额度越高，实际权重可适当抬升（简易：剩余占比 * weight；同时设置日/月额度时取较低的占比）
 		effWeight := k.Weight
-		if k.MonthlyQuota > 0 && k.RemainingQuota >= 0 {
+		if ratio, ok := remainingRatio(k); ok {
 			// 提升比例：1 + 剩余占比（最多2倍）
-			eff := 1.0 + float64(k.RemainingQuota)/float64(k.MonthlyQuota)
+			eff := 1.0 + ratio
 			if eff > 2.0 {
 				eff = 2.0
 			}
@@ -80,13 +165,67 @@ func (m *OCRKeyManager) Reload(keys []model.OCRKey) {
 				effWeight = 1
 			}
 		}
-		m.total += effWeight
+		wk.baseWeight = effWeight
 		providerCount[provider]++
 	}
+	m.recomputeWeights()
 	// 打印一次加载结果（信息级别，便于诊断）
 	m.logger.Info("OCR keys reloaded", zap.Int("usable_keys", len(m.keys)), zap.Any("by_provider", providerCount))
 }
 
+// recomputeWeights 按平滑后的真实准确率调整调度权重：准确率越高分到的流量越多（调用方持有写锁）
+func (m *OCRKeyManager) recomputeWeights() {
+	m.total = 0
+	for _, wk := range m.keys {
+		acc := 0.5
+		if s, ok := m.stats[wk.key.ID]; ok {
+			acc = s.smoothedAccuracy()
+		}
+		wk.weight = int(float64(wk.baseWeight*accuracyScale) * acc)
+		if wk.weight < 1 {
+			wk.weight = 1
+		}
+		m.total += wk.weight
+	}
+}
+
+// isMeteredProvider 是否为按次计费的 provider（自研本地模型 paddle/crnn 与自建服务 http 不扣减额度）
+func isMeteredProvider(provider string) bool {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "paddle", "crnn", "http":
+		return false
+	}
+	return true
+}
+
+// remainingRatio 剩余额度占比（未设置任何额度时返回 false）
+func remainingRatio(k model.OCRKey) (float64, bool) {
+	ratio, ok := 0.0, false
+	if k.MonthlyQuota != nil && *k.MonthlyQuota > 0 && k.RemainingQuota >= 0 {
+		ratio, ok = float64(k.RemainingQuota)/float64(*k.MonthlyQuota), true
+	}
+	if k.DailyQuota != nil && *k.DailyQuota > 0 && k.RemainingDailyQuota >= 0 {
+		if r := float64(k.RemainingDailyQuota) / float64(*k.DailyQuota); !ok || r < ratio {
+			ratio, ok = r, true
+		}
+	}
+	return ratio, ok
+}
+
+func sameOptions(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// UsableKeyCount 当前已加载的可用 Key 数量
+func (m *OCRKeyManager) UsableKeyCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.keys)
+}
+
 // pick 使用平滑加权轮询（SWRR）选择一个 key
 func (m *OCRKeyManager) pick() *weightedKey {
 	m.mu.Lock()
@@ -96,7 +235,7 @@ func (m *OCRKeyManager) pick() *weightedKey {
 	}
 	var best *weightedKey
 	for _, wk := range m.keys {
-		wk.current += wk.key.Weight
+		wk.current += wk.weight
 		if best == nil || wk.current > best.current {
 			best = wk
 		}
@@ -109,71 +248,352 @@ func (m *OCRKeyManager) pick() *weightedKey {
 
 // RecognizeCaptcha 多 Key 调度识别
 func (m *OCRKeyManager) RecognizeCaptcha(base64Image string) (string, error) {
-	// 最多尝试 len(keys) 次
+	return m.RecognizeCaptchaContext(context.Background(), base64Image)
+}
+
+// RecognizeCaptchaContext 多 Key 调度识别，返回最终采用的候选文本
+func (m *OCRKeyManager) RecognizeCaptchaContext(ctx context.Context, base64Image string) (string, error) {
+	cands, err := m.RecognizeCandidates(ctx, base64Image)
+	if err != nil {
+		return "", err
+	}
+	return cands[0].Text, nil
+}
+
+// RecognizeCandidates 多 Key 调度识别：启用投票时并行询问多个 Provider，否则逐个 Key 尝试直到得到结果。
+// 第一个候选为最终采用的答案
+func (m *OCRKeyManager) RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
 	m.mu.RLock()
 	tries := len(m.keys)
+	consensus := m.consensus
 	m.mu.RUnlock()
 	if tries == 0 {
-		return "", errors.New("no usable OCR keys")
+		return nil, errors.New("no usable OCR keys")
+	}
+	if consensus.Enabled {
+		voters := m.pickVoters(consensus.Voters)
+		if len(voters) >= 2 {
+			return m.recognizeConsensus(ctx, base64Image, voters, consensus)
+		}
+		logging.FromContext(ctx, m.logger).Debug("可用 Provider 不足2个，跳过投票")
+		if len(voters) == 1 {
+			// 已占用该 Key 的令牌/探测名额，直接使用；失败再逐个尝试其他 Key
+			if cands, err := m.attempt(ctx, voters[0], base64Image); err == nil {
+				return cands, nil
+			}
+		}
 	}
+	return m.recognizeSequential(ctx, base64Image, tries)
+}
+
+// recognizeSequential 按 SWRR 顺序逐个 Key 尝试，返回第一个有效结果；熔断中或已达 QPS 上限的 Key 跳过，
+// 若所有 Key 都因限流被跳过，等待最早可用的令牌后再试一轮
+func (m *OCRKeyManager) recognizeSequential(ctx context.Context, base64Image string, tries int) ([]CaptchaCandidate, error) {
+	log := logging.FromContext(ctx, m.logger)
 	var lastErr error
-	for i := 0; i < tries; i++ {
-		wk := m.pick()
-		if wk == nil {
+	for round := 0; round < 2; round++ {
+		attempted := false
+		var throttled []*weightedKey
+		for i := 0; i < tries; i++ {
+			wk := m.pick()
+			if wk == nil {
+				break
+			}
+			if reason := m.admit(wk); reason != "" {
+				if reason == admitThrottled {
+					throttled = append(throttled, wk)
+				}
+				continue
+			}
+			attempted = true
+			// 记录选择的key及provider，协助定位未命中阿里云的问题
+			log.Info("OCR selecting key", zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider))
+			cands, err := m.attempt(ctx, wk, base64Image)
+			if err == nil {
+				log.Info("OCR recognition success", zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider),
+					zap.Float64("confidence", cands[0].Confidence))
+				return cands, nil
+			}
+			lastErr = err
+			// 失败则尝试下一个 key（不在这里修改 has_quota，交由上层服务判断具体错误类型后更新 DB 并触发 Reload）
+		}
+		if attempted || len(throttled) == 0 {
 			break
 		}
-		// 记录选择的key及provider，协助定位未命中阿里云的问题
-		m.logger.Info("OCR selecting key", zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider))
-		result, err := wk.recognizer.RecognizeCaptcha(base64Image)
-		if err == nil && result != "" {
-			if m.onUsage != nil {
-				m.onUsage(wk.key.ID, true, nil)
+		if err := m.waitForToken(ctx, throttled); err != nil {
+			return nil, err
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("all ocr keys failed or circuit open")
+	}
+	return nil, lastErr
+}
+
+// recognizeConsensus 并行询问多个 Provider：达到一致票数或单个置信度超过阈值时采用，否则按 Fallback 处理
+func (m *OCRKeyManager) recognizeConsensus(ctx context.Context, base64Image string, voters []*weightedKey, cfg ConsensusConfig) ([]CaptchaCandidate, error) {
+	log := logging.FromContext(ctx, m.logger)
+	results := make([][]CaptchaCandidate, len(voters))
+	var wg sync.WaitGroup
+	for i, wk := range voters {
+		wg.Add(1)
+		go func(i int, wk *weightedKey) {
+			defer wg.Done()
+			if cands, err := m.attempt(ctx, wk, base64Image); err == nil {
+				results[i] = cands
 			}
-			m.logger.Info("OCR recognition success", zap.Int("key_id", wk.key.ID), zap.String("provider", wk.key.Provider))
-			return result, nil
+		}(i, wk)
+	}
+	wg.Wait()
+
+	// 每个 Key 只取其首选答案投票；按选中顺序保留，便于平票时优先权重更高的 Key
+	var answers []CaptchaCandidate
+	for _, cands := range results {
+		if len(cands) > 0 {
+			answers = append(answers, cands[0])
 		}
-		lastErr = err
-		if m.onUsage != nil {
-			var emsg *string
-			if err != nil {
-				s := err.Error()
-				emsg = &s
+	}
+	if len(answers) == 0 {
+		log.Warn("OCR 投票的 Provider 全部失败，改为逐个 Key 尝试")
+		m.mu.RLock()
+		tries := len(m.keys)
+		m.mu.RUnlock()
+		return m.recognizeSequential(ctx, base64Image, tries)
+	}
+
+	outcome, chosen := decideConsensus(answers, cfg)
+	metrics.OCRConsensus.WithLabelValues(outcome).Inc()
+	fields := []zap.Field{zap.String("outcome", outcome), zap.Any("answers", answers)}
+	if chosen == nil {
+		log.Warn("OCR 投票未达成一致，放弃本次验证码", fields...)
+		return nil, ErrNoConsensus
+	}
+	log.Info("OCR consensus", append(fields, zap.String("text", chosen.Text))...)
+
+	// 采用的答案排在首位，其余答案按原顺序附后
+	out := []CaptchaCandidate{*chosen}
+	for _, a := range answers {
+		if a != *chosen {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// decideConsensus 根据各 Provider 的答案决定采用哪个；返回 nil 表示放弃（fallback=refetch）
+func decideConsensus(answers []CaptchaCandidate, cfg ConsensusConfig) (string, *CaptchaCandidate) {
+	votes := map[string]int{}
+	for _, a := range answers {
+		votes[a.Text]++
+	}
+	// 1) 票数达到要求：取票数最多的答案（同一答案取置信度最高的那个 Key 的候选）
+	var agreed *CaptchaCandidate
+	for i := range answers {
+		a := &answers[i]
+		if votes[a.Text] < cfg.MinAgree {
+			continue
+		}
+		if agreed == nil || votes[a.Text] > votes[agreed.Text] ||
+			(a.Text == agreed.Text && a.Confidence > agreed.Confidence) {
+			agreed = a
+		}
+	}
+	if agreed != nil {
+		return "agreed", agreed
+	}
+	// 2) 置信度达到阈值：取置信度最高的答案
+	best := &answers[0]
+	for i := range answers {
+		if answers[i].Confidence > best.Confidence {
+			best = &answers[i]
+		}
+	}
+	if cfg.Confidence > 0 && best.Confidence >= cfg.Confidence {
+		return "confident", best
+	}
+	// 3) 未达成一致
+	if cfg.Fallback == ConsensusFallbackRefetch {
+		return "rejected", nil
+	}
+	if best.Confidence == ConfidenceUnknown {
+		// 均无置信度时按票数（平票取选中顺序靠前者）
+		for i := range answers {
+			if votes[answers[i].Text] > votes[best.Text] {
+				best = &answers[i]
 			}
-			m.onUsage(wk.key.ID, false, emsg)
-		}
-		// 若是额度/权限相关错误，回调上层标记 has_quota=false
-		if oe, ok := err.(*OCRError); ok {
-			if codeInt, convErr := strconv.Atoi(oe.Code); convErr == nil {
-				switch codeInt {
-				// 结合 error_code.md：与额度/权限/QPS强相关的错误
-				case 4, 17, 18, 19, 216604:
-					if m.onKeyExhausted != nil {
-						m.onKeyExhausted(wk.key.ID, codeInt, oe.Msg)
-					}
-				case 6, 14, 110, 111: // 权限/鉴权/token 失效
-					if m.onKeyExhausted != nil {
-						m.onKeyExhausted(wk.key.ID, codeInt, oe.Msg)
-					}
+		}
+	}
+	return "fallback", best
+}
+
+// attempt 使用单个 Key 识别一次，记录子 span、指标与使用统计，并在额度/权限错误时回调上层
+func (m *OCRKeyManager) attempt(ctx context.Context, wk *weightedKey, base64Image string) ([]CaptchaCandidate, error) {
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ocr.provider",
+		attribute.String("provider", wk.key.Provider),
+		attribute.Int("key_id", wk.key.ID))
+	img := m.preprocess(ctx, wk.key.Provider, base64Image)
+	cands, err := recognizeCandidates(spanCtx, wk.recognizer, img)
+	ok := err == nil && len(cands) > 0
+	switch {
+	case ok:
+		span.SetAttributes(attribute.Float64("confidence", cands[0].Confidence))
+		tracing.End(span, nil)
+	case err == nil:
+		tracing.End(span, errors.New("empty result"))
+	default:
+		tracing.End(span, err)
+	}
+	metrics.ObserveOCR(wk.key.Provider, wk.key.ID, ok, time.Since(start))
+	m.recordLatency(wk, time.Since(start))
+	m.recordOutcome(ctx, wk, err)
+
+	m.mu.RLock()
+	onUsage, onKeyExhausted := m.onUsage, m.onKeyExhausted
+	m.mu.RUnlock()
+	metered := isMeteredProvider(wk.key.Provider)
+	if ok {
+		for i := range cands {
+			cands[i].Provider, cands[i].KeyID, cands[i].Image = wk.key.Provider, wk.key.ID, img
+		}
+		if onUsage != nil {
+			onUsage(wk.key.ID, true, metered, nil)
+		}
+		return cands, nil
+	}
+
+	if onUsage != nil {
+		var emsg *string
+		if err != nil {
+			s := err.Error()
+			emsg = &s
+		}
+		// 返回了识别结果但长度异常同样计费；服务端/网络错误不计费
+		onUsage(wk.key.ID, false, metered && (err == nil || errors.Is(err, errCaptchaLength)), emsg)
+	}
+	// 若是额度/权限相关错误，回调上层标记 has_quota=false
+	if oe, ok := err.(*OCRError); ok {
+		if codeInt, convErr := strconv.Atoi(oe.Code); convErr == nil {
+			switch codeInt {
+			// 结合 error_code.md：与额度/权限/QPS强相关的错误
+			// 18（QPS 超限）不禁用 Key，由熔断器暂停调度
+			case 4, 17, 19, 216604:
+				if onKeyExhausted != nil {
+					onKeyExhausted(wk.key.ID, codeInt, oe.Msg, model.OCRKeyDisabledQuota)
+				}
+			case 6, 14, 110, 111: // 权限/鉴权/token 失效
+				if onKeyExhausted != nil {
+					onKeyExhausted(wk.key.ID, codeInt, oe.Msg, model.OCRKeyDisabledAuth)
 				}
 			}
 		}
-		// 失败则尝试下一个 key（不在这里修改 has_quota，交由上层服务判断具体错误类型后更新 DB 并触发 Reload）
 	}
-	if lastErr == nil {
-		lastErr = errors.New("all ocr keys failed")
+	if err == nil {
+		err = errors.New("empty result")
+	}
+	return nil, err
+}
+
+// preprocess 按 provider 的流水线预处理验证码；失败时回退使用原图
+func (m *OCRKeyManager) preprocess(ctx context.Context, provider, base64Image string) string {
+	m.mu.RLock()
+	pipeline := m.pipelines.For(provider)
+	m.mu.RUnlock()
+	if pipeline.Empty() {
+		return base64Image
 	}
-	return "", lastErr
+	out, err := pipeline.Process(base64Image)
+	if err != nil {
+		logging.FromContext(ctx, m.logger).Debug("验证码预处理失败，使用原图", zap.String("provider", provider), zap.Error(err))
+		return base64Image
+	}
+	return out
+}
+
+// pickVoters 按 SWRR 顺序选出最多 n 个不同 Provider 的 Key（同一 Provider 的多个 Key 结果相同，投票无意义）
+func (m *OCRKeyManager) pickVoters(n int) []*weightedKey {
+	m.mu.RLock()
+	tries := len(m.keys)
+	m.mu.RUnlock()
+	seen := map[string]bool{}
+	var voters []*weightedKey
+	for i := 0; i < tries && len(voters) < n; i++ {
+		wk := m.pick()
+		if wk == nil {
+			break
+		}
+		provider := strings.ToLower(wk.key.Provider)
+		if seen[provider] || m.admit(wk) != "" {
+			continue
+		}
+		seen[provider] = true
+		voters = append(voters, wk)
+	}
+	return voters
+}
+
+// SetConsensus 设置多 Provider 投票策略
+func (m *OCRKeyManager) SetConsensus(cfg ConsensusConfig) {
+	if cfg.Voters < 2 {
+		cfg.Voters = 2
+	}
+	if cfg.MinAgree < 2 {
+		cfg.MinAgree = 2
+	}
+	if cfg.Fallback != ConsensusFallbackRefetch {
+		cfg.Fallback = ConsensusFallbackBest
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.consensus = cfg
+}
+
+// SetPipelines 设置验证码预处理流水线
+func (m *OCRKeyManager) SetPipelines(ps *CaptchaPipelines) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pipelines = ps
+}
+
+// Pipelines 当前的预处理流水线配置
+func (m *OCRKeyManager) Pipelines() *CaptchaPipelines {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pipelines
 }
 
-// SetOnKeyExhausted 设置额度回调
-func (m *OCRKeyManager) SetOnKeyExhausted(fn func(keyID int, code int, msg string)) {
+// SetOnKeyExhausted 设置额度/鉴权错误回调
+func (m *OCRKeyManager) SetOnKeyExhausted(fn func(keyID int, code int, msg, reason string)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.onKeyExhausted = fn
 }
 
+// decryptCredentials 解密凭据（仅在构建 recognizer 时使用，明文不保存在 weightedKey 中）
+func (m *OCRKeyManager) decryptCredentials(k model.OCRKey) (string, string, error) {
+	if m.credentials == nil {
+		return k.APIKey, k.SecretKey, nil
+	}
+	apiKey, err := m.credentials.Decrypt(k.APIKey)
+	if err != nil {
+		return "", "", err
+	}
+	secretKey, err := m.credentials.Decrypt(k.SecretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return apiKey, secretKey, nil
+}
+
+// SetCredentialDecrypter 设置凭据解密器（需在首次 Reload 前调用）
+func (m *OCRKeyManager) SetCredentialDecrypter(d CredentialDecrypter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credentials = d
+}
+
 // SetOnUsage 设置使用统计回调
-func (m *OCRKeyManager) SetOnUsage(fn func(keyID int, success bool, errMsg *string)) {
+func (m *OCRKeyManager) SetOnUsage(fn func(keyID int, success, billable bool, errMsg *string)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.onUsage = fn
@@ -6,8 +6,8 @@ import (
 	"go.uber.org/zap"
 )
 
-// ProviderFactory 工厂方法：根据 apiKey/secret/region 构造 OCRRecognizer
-type ProviderFactory func(apiKey, secret, region string, logger *zap.Logger) OCRRecognizer
+// ProviderFactory 工厂方法：根据 apiKey/secret 及 provider 自定义配置（ocr_keys.options，JSON）构造 OCRRecognizer
+type ProviderFactory func(apiKey, secret, options string, logger *zap.Logger) OCRRecognizer
 
 var providerFactories = map[string]ProviderFactory{}
 
@@ -26,12 +26,18 @@ func getOCRProvider(name string) (ProviderFactory, bool) {
 	return f, ok
 }
 
-// 预注册内置 Provider：baidu + paddle
+// 预注册内置 Provider：baidu + paddle + crnn + http
 func init() {
-	RegisterOCRProvider("baidu", func(apiKey, secret, region string, logger *zap.Logger) OCRRecognizer {
+	RegisterOCRProvider("baidu", func(apiKey, secret, options string, logger *zap.Logger) OCRRecognizer {
 		return NewOCRClient(apiKey, secret, logger)
 	})
-	RegisterOCRProvider("paddle", func(apiKey, secret, region string, logger *zap.Logger) OCRRecognizer {
+	RegisterOCRProvider("paddle", func(apiKey, secret, options string, logger *zap.Logger) OCRRecognizer {
 		return NewPaddleOCRClient(logger)
 	})
+	RegisterOCRProvider("crnn", func(apiKey, secret, options string, logger *zap.Logger) OCRRecognizer {
+		return NewCRNNOCRClient(logger)
+	})
+	RegisterOCRProvider("http", func(apiKey, secret, options string, logger *zap.Logger) OCRRecognizer {
+		return NewHTTPOCRClient(apiKey, secret, options, logger)
+	})
 }
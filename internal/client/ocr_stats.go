@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// 滚动窗口大小：最近 N 次游戏判定 / 最近 N 次识别耗时
+const (
+	verdictWindow = 200
+	latencyWindow = 200
+)
+
+// FeedbackRecognizer 可选接口：接收游戏对已提交验证码的判定（40103 为错误），用于统计真实准确率
+type FeedbackRecognizer interface {
+	ReportCaptchaVerdict(ctx context.Context, cands []CaptchaCandidate, submitted string, correct bool)
+}
+
+// keyStats 单个 Key 的滚动统计（由 OCRKeyManager.mu 保护）
+type keyStats struct {
+	provider  string
+	verdicts  [verdictWindow]bool
+	vNext     int
+	vLen      int
+	latencies [latencyWindow]time.Duration
+	lNext     int
+	lLen      int
+}
+
+func (s *keyStats) addVerdict(correct bool) {
+	s.verdicts[s.vNext] = correct
+	s.vNext = (s.vNext + 1) % verdictWindow
+	if s.vLen < verdictWindow {
+		s.vLen++
+	}
+}
+
+func (s *keyStats) addLatency(d time.Duration) {
+	s.latencies[s.lNext] = d
+	s.lNext = (s.lNext + 1) % latencyWindow
+	if s.lLen < latencyWindow {
+		s.lLen++
+	}
+}
+
+func (s *keyStats) counts() (correct, total int) {
+	for i := 0; i < s.vLen; i++ {
+		if s.verdicts[i] {
+			correct++
+		}
+	}
+	return correct, s.vLen
+}
+
+// smoothedAccuracy 拉普拉斯平滑的准确率：无判定时为 0.5，随样本增加逐渐接近真实值
+func (s *keyStats) smoothedAccuracy() float64 {
+	correct, total := s.counts()
+	return float64(correct+1) / float64(total+2)
+}
+
+// seed 用数据库中的累计判定数初始化窗口（重启后不必从零开始），按比例缩放到窗口大小
+func (s *keyStats) seed(correct, wrong int) {
+	total := correct + wrong
+	if total == 0 {
+		return
+	}
+	n := total
+	if n > verdictWindow {
+		n = verdictWindow
+	}
+	c := int(float64(correct) / float64(total) * float64(n))
+	for i := 0; i < n; i++ {
+		s.addVerdict(i < c)
+	}
+}
+
+// OCRKeyStats 滚动窗口内的识别统计（Accuracy 为游戏判定的真实准确率，无判定时为 -1）
+type OCRKeyStats struct {
+	KeyID        int     `json:"keyId,omitempty"`
+	Provider     string  `json:"provider"`
+	Correct      int     `json:"correct"`
+	Wrong        int     `json:"wrong"`
+	Accuracy     float64 `json:"accuracy"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	P90LatencyMs float64 `json:"p90LatencyMs"`
+}
+
+func buildStats(keyID int, provider string, parts []*keyStats) OCRKeyStats {
+	st := OCRKeyStats{KeyID: keyID, Provider: provider, Accuracy: -1}
+	var latencies []float64
+	for _, s := range parts {
+		correct, total := s.counts()
+		st.Correct += correct
+		st.Wrong += total - correct
+		for i := 0; i < s.lLen; i++ {
+			latencies = append(latencies, float64(s.latencies[i].Microseconds())/1000)
+		}
+	}
+	if total := st.Correct + st.Wrong; total > 0 {
+		st.Accuracy = float64(st.Correct) / float64(total)
+	}
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		sum := 0.0
+		for _, v := range latencies {
+			sum += v
+		}
+		st.AvgLatencyMs = sum / float64(len(latencies))
+		st.P90LatencyMs = latencies[(len(latencies)*9)/10]
+	}
+	return st
+}
+
+// KeyStats 各 Key 的滚动统计（含已从调度中移除但仍有统计的 Key）
+func (m *OCRKeyManager) KeyStats() map[int]OCRKeyStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[int]OCRKeyStats, len(m.stats))
+	for id, s := range m.stats {
+		out[id] = buildStats(id, s.provider, []*keyStats{s})
+	}
+	return out
+}
+
+// ProviderStats 按 provider 汇总的滚动统计
+func (m *OCRKeyManager) ProviderStats() []OCRKeyStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	byProvider := map[string][]*keyStats{}
+	for _, s := range m.stats {
+		byProvider[s.provider] = append(byProvider[s.provider], s)
+	}
+	out := make([]OCRKeyStats, 0, len(byProvider))
+	for provider, parts := range byProvider {
+		out = append(out, buildStats(0, provider, parts))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// ReportCaptchaVerdict 记录游戏判定：与提交答案相同的候选按判定计；答案不同的候选仅在提交正确时计为错误
+// （提交错误时无法得知其他答案是否正确）。判定会即时影响调度权重
+func (m *OCRKeyManager) ReportCaptchaVerdict(ctx context.Context, cands []CaptchaCandidate, submitted string, correct bool) {
+	type verdict struct {
+		keyID   int
+		correct bool
+	}
+	var verdicts []verdict
+	seen := map[int]bool{}
+	for _, c := range cands {
+		if c.KeyID == 0 || seen[c.KeyID] {
+			continue
+		}
+		seen[c.KeyID] = true
+		switch {
+		case strings.EqualFold(c.Text, submitted):
+			verdicts = append(verdicts, verdict{c.KeyID, correct})
+		case correct:
+			verdicts = append(verdicts, verdict{c.KeyID, false})
+		}
+	}
+	if len(verdicts) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	for _, v := range verdicts {
+		s := m.statsFor(v.keyID, "")
+		s.addVerdict(v.correct)
+		label := "wrong"
+		if v.correct {
+			label = "correct"
+		}
+		metrics.OCRVerdicts.WithLabelValues(s.provider, strconv.Itoa(v.keyID), label).Inc()
+	}
+	m.recomputeWeights()
+	onVerdict := m.onVerdict
+	m.mu.Unlock()
+
+	log := logging.FromContext(ctx, m.logger)
+	for _, v := range verdicts {
+		log.Debug("OCR verdict", zap.Int("key_id", v.keyID), zap.Bool("correct", v.correct))
+		if onVerdict != nil {
+			onVerdict(v.keyID, v.correct)
+		}
+	}
+}
+
+// SetOnVerdict 设置游戏判定回调（由上层持久化累计判定数）
+func (m *OCRKeyManager) SetOnVerdict(fn func(keyID int, correct bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onVerdict = fn
+}
+
+// statsFor 获取或创建 Key 的统计（调用方持有写锁）
+func (m *OCRKeyManager) statsFor(keyID int, provider string) *keyStats {
+	s, ok := m.stats[keyID]
+	if !ok {
+		s = &keyStats{}
+		m.stats[keyID] = s
+	}
+	if provider != "" {
+		s.provider = provider
+	}
+	return s
+}
+
+// recordLatency 记录一次识别耗时
+func (m *OCRKeyManager) recordLatency(wk *weightedKey, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statsFor(wk.key.ID, wk.key.Provider).addLatency(d)
+}
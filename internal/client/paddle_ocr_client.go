@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,7 +27,12 @@ import (
 // - PADDLE_OCR_IMAGE_SHAPE: 例如 3,32,160（默认 3,32,160）
 // - PADDLE_OCR_EXTRA_ARGS: 其他附加参数（可为空）
 // - PADDLE_OCR_TIMEOUT_MS: 识别超时（默认 15000）
-// 临时图片将写入 ./tmp/ocr 目录
+// - PADDLE_OCR_MODE: server（默认，常驻进程池）或 cli（每张图片启动一次 predict_rec.py）
+// - PADDLE_OCR_SERVER_SCRIPT: 常驻进程脚本（默认 ./scripts/paddle_ocr_server.py）
+// - PADDLE_OCR_POOL_SIZE: 常驻进程数（默认 2）
+// - PADDLE_OCR_STARTUP_TIMEOUT_MS: 常驻进程加载模型的超时（默认 60000）
+// - PADDLE_OCR_HEALTH_INTERVAL_MS: 常驻进程健康检查间隔（默认 30000，0 表示不检查）
+// cli 模式的临时图片将写入 ./tmp/ocr 目录；server 模式下进程池不可用（未就绪/全部重启中）时回退到 cli 模式
 
 type PaddleOCRClient struct {
 	pythonExe  string
@@ -36,6 +42,7 @@ type PaddleOCRClient struct {
 	imageShape string
 	extraArgs  []string
 	timeout    time.Duration
+	pool       *paddleWorkerPool // 为 nil 表示 cli 模式
 	logger     *zap.Logger
 }
 
@@ -73,6 +80,32 @@ func NewPaddleOCRClient(logger *zap.Logger) *PaddleOCRClient {
 		timeout:    time.Duration(to) * time.Millisecond,
 		logger:     logger,
 	}
+
+	if strings.ToLower(getenvDefault("PADDLE_OCR_MODE", "server")) == "server" {
+		poolSize, _ := strconvAtoiSafe(getenvDefault("PADDLE_OCR_POOL_SIZE", "2"))
+		startupMs, _ := strconvAtoiSafe(getenvDefault("PADDLE_OCR_STARTUP_TIMEOUT_MS", "60000"))
+		healthMs, _ := strconvAtoiSafe(getenvDefault("PADDLE_OCR_HEALTH_INTERVAL_MS", "30000"))
+		if startupMs <= 0 {
+			startupMs = 60000
+		}
+		args := []string{
+			// predict_rec.py 位于 <PaddleOCR>/tools/infer/ 下
+			"--paddleocr_dir", filepath.Dir(filepath.Dir(filepath.Dir(client.scriptPath))),
+			"--use_gpu=False",
+			"--rec_algorithm=CRNN",
+			"--rec_model_dir", client.modelDir,
+			"--rec_char_dict_path", client.charDict,
+			"--rec_image_shape", client.imageShape,
+		}
+		client.pool = sharedPaddlePool(paddleWorkerOptions{
+			pythonExe:      python,
+			serverScript:   resolve(getenvDefault("PADDLE_OCR_SERVER_SCRIPT", "./scripts/paddle_ocr_server.py")),
+			args:           append(args, client.extraArgs...),
+			poolSize:       poolSize,
+			startupTimeout: time.Duration(startupMs) * time.Millisecond,
+			healthInterval: time.Duration(healthMs) * time.Millisecond,
+		}, logger)
+	}
 	return client
 }
 
@@ -102,18 +135,73 @@ func strconvAtoiSafe(s string) (int, error) {
 }
 
 func (c *PaddleOCRClient) RecognizeCaptcha(base64Image string) (string, error) {
+	return c.RecognizeCaptchaContext(context.Background(), base64Image)
+}
+
+func (c *PaddleOCRClient) RecognizeCaptchaContext(ctx context.Context, base64Image string) (string, error) {
+	cands, err := c.RecognizeCandidates(ctx, base64Image)
+	if err != nil {
+		return "", err
+	}
+	return cands[0].Text, nil
+}
+
+// RecognizeCandidates 优先使用常驻进程池，进程池不可用时回退到命令行模式；置信度为 CTC 解码得分
+func (c *PaddleOCRClient) RecognizeCandidates(ctx context.Context, base64Image string) ([]CaptchaCandidate, error) {
+	var (
+		text, raw string
+		score     float64
+		err       error
+	)
+	if c.pool == nil {
+		text, score, raw, err = c.recognizeCLI(ctx, base64Image)
+	} else {
+		text, score, raw, err = c.recognizePool(ctx, base64Image)
+	}
+	if err != nil {
+		return nil, err
+	}
+	norm, err := c.normalizeResult(text, raw)
+	if err != nil {
+		return nil, err
+	}
+	return []CaptchaCandidate{{Text: norm, Confidence: score}}, nil
+}
+
+func (c *PaddleOCRClient) recognizePool(ctx context.Context, base64Image string) (string, float64, string, error) {
+	poolCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	text, score, err := c.pool.Recognize(poolCtx, c.stripDataURL(base64Image))
+	if errors.Is(err, errPaddlePoolUnavailable) {
+		// 沿用调用方的 ctx（取消与截止时间），命令行模式自行设置超时
+		c.logger.Warn("PaddleOCR 常驻进程不可用，回退到命令行模式")
+		return c.recognizeCLI(ctx, base64Image)
+	}
+	if err != nil {
+		if poolCtx.Err() == context.DeadlineExceeded {
+			c.logger.Error("PaddleOCR 调用超时")
+			return "", 0, "", errors.New("paddle ocr timeout")
+		}
+		c.logger.Error("PaddleOCR 调用失败", zap.Error(err))
+		return "", 0, "", err
+	}
+	return text, score, text, nil
+}
+
+// recognizeCLI 写临时图片并调用 predict_rec.py（每次都会重新加载模型），返回识别文本、得分与原始输出
+func (c *PaddleOCRClient) recognizeCLI(parent context.Context, base64Image string) (string, float64, string, error) {
 	// 写临时文件
 	imgBytes, err := c.decodeBase64Image(base64Image)
 	if err != nil {
-		return "", err
+		return "", 0, "", err
 	}
 	if err := os.MkdirAll("./tmp/ocr", 0o755); err != nil {
-		return "", err
+		return "", 0, "", err
 	}
 	name := fmt.Sprintf("tmp_%d_%04d.png", time.Now().UnixNano()/1e6, rand.Intn(10000))
 	imgPath := filepath.Join("./tmp/ocr", name)
 	if err := os.WriteFile(imgPath, imgBytes, 0o644); err != nil {
-		return "", err
+		return "", 0, "", err
 	}
 	defer os.Remove(imgPath)
 
@@ -130,60 +218,82 @@ func (c *PaddleOCRClient) RecognizeCaptcha(base64Image string) (string, error) {
 	if len(c.extraArgs) > 0 {
 		args = append(args, c.extraArgs...)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	ctx, cancel := context.WithTimeout(parent, c.timeout)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, c.pythonExe, args...)
 	out, err := cmd.CombinedOutput()
 	if ctx.Err() == context.DeadlineExceeded {
 		c.logger.Error("PaddleOCR 调用超时")
-		return "", errors.New("paddle ocr timeout")
+		return "", 0, "", errors.New("paddle ocr timeout")
 	}
 	if err != nil {
 		c.logger.Error("PaddleOCR 调用失败", zap.Error(err), zap.String("output", string(out)))
-		return "", fmt.Errorf("paddle ocr failed: %v", err)
+		return "", 0, "", fmt.Errorf("paddle ocr failed: %v", err)
 	}
 
-	text := parsePaddleOutput(string(out))
+	text, score := parsePaddleOutput(string(out))
+	return text, score, string(out), nil
+}
+
+// normalizeResult 校验识别文本并规范为4位，raw 为便于排查的原始输出
+func (c *PaddleOCRClient) normalizeResult(text, raw string) (string, error) {
 	if text == "" {
-		c.logger.Warn("PaddleOCR 输出无法解析", zap.String("output", string(out)))
+		c.logger.Warn("PaddleOCR 输出无法解析", zap.String("output", raw))
 		return "", errors.New("paddle ocr parse failed")
 	}
 	// 规范为4位
 	norm := normalizeTo4(text)
 	if len(norm) != 4 {
-		return "", fmt.Errorf("验证码长度异常: %s", norm)
+		return "", fmt.Errorf("%w: %s", errCaptchaLength, norm)
 	}
 	return norm, nil
 }
 
+func (c *PaddleOCRClient) stripDataURL(s string) string {
+	return stripDataURL(s)
+}
+
 func (c *PaddleOCRClient) decodeBase64Image(s string) ([]byte, error) {
+	return decodeCaptchaBase64(s)
+}
+
+// stripDataURL 去掉 data URL 前缀与换行，得到纯 base64
+func stripDataURL(s string) string {
 	ss := strings.TrimSpace(s)
 	if i := strings.Index(ss, ","); i > 0 && strings.Contains(strings.ToLower(ss[:i]), "base64") {
 		ss = ss[i+1:]
 	}
 	ss = strings.ReplaceAll(ss, "\n", "")
 	ss = strings.ReplaceAll(ss, "\r", "")
-	ss = strings.TrimSpace(ss)
-	return base64.StdEncoding.DecodeString(ss)
+	return strings.TrimSpace(ss)
+}
+
+// decodeCaptchaBase64 解码验证码图片（兼容 data URL）
+func decodeCaptchaBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(stripDataURL(s))
 }
 
 var (
-	rePredict = regexp.MustCompile(`Predicts of .*?:\('([A-Za-z0-9]{3,12})'\s*,\s*[0-9.]+\)`) // ('XXXX', 0.99)
+	rePredict = regexp.MustCompile(`Predicts of .*?:\('([A-Za-z0-9]{3,12})'\s*,\s*([0-9.]+)\)`) // ('XXXX', 0.99)
 	reText    = regexp.MustCompile(`[\"']text[\"']\s*[:=]\s*[\"']([A-Za-z0-9]{3,12})[\"']`)
 	reAny     = regexp.MustCompile(`([A-Za-z0-9]{3,12})`)
 )
 
-func parsePaddleOutput(out string) string {
-	if m := rePredict.FindStringSubmatch(out); len(m) == 2 {
-		return m[1]
+// parsePaddleOutput 从 predict_rec.py 输出中提取识别文本与得分（无得分时为 ConfidenceUnknown）
+func parsePaddleOutput(out string) (string, float64) {
+	if m := rePredict.FindStringSubmatch(out); len(m) == 3 {
+		if score, err := strconv.ParseFloat(m[2], 64); err == nil {
+			return m[1], score
+		}
+		return m[1], ConfidenceUnknown
 	}
 	if m := reText.FindStringSubmatch(out); len(m) == 2 {
-		return m[1]
+		return m[1], ConfidenceUnknown
 	}
 	if ms := reAny.FindAllStringSubmatch(out, -1); len(ms) > 0 {
-		return ms[len(ms)-1][1]
+		return ms[len(ms)-1][1], ConfidenceUnknown
 	}
-	return ""
+	return "", ConfidenceUnknown
 }
 
 func normalizeTo4(s string) string {
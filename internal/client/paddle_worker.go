@@ -0,0 +1,418 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 常驻模式：每个 worker 是一个加载好模型的 Python 子进程（scripts/paddle_ocr_server.py），
+// 通过 stdin/stdout 逐行交换 JSON：
+//   请求 {"id":1,"image":"<base64>"} / {"id":2,"cmd":"ping"}
+//   响应 {"id":1,"text":"AB12","score":0.99} / {"id":2,"pong":true} / {"id":1,"error":"..."}
+// 子进程启动完成（模型加载完毕）后先输出一行 {"ready":true}。
+
+var errPaddlePoolUnavailable = errors.New("paddle ocr worker pool unavailable")
+
+// paddleWorkerOptions 常驻进程池参数
+type paddleWorkerOptions struct {
+	pythonExe      string
+	serverScript   string
+	args           []string // 透传给 paddle_ocr_server.py 的参数
+	poolSize       int
+	startupTimeout time.Duration
+	healthInterval time.Duration
+}
+
+type paddleRequest struct {
+	ID    int64  `json:"id"`
+	Cmd   string `json:"cmd,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type paddleResponse struct {
+	ID    int64   `json:"id"`
+	Ready bool    `json:"ready,omitempty"`
+	Pong  bool    `json:"pong,omitempty"`
+	Text  string  `json:"text,omitempty"`
+	Score float64 `json:"score,omitempty"`
+	Error string  `json:"error,omitempty"`
+}
+
+// paddleWorker 单个常驻子进程；同一时刻只处理一个请求（由进程池保证）
+type paddleWorker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan []byte
+	done   chan struct{}
+	nextID int64
+}
+
+func startPaddleWorker(opts paddleWorkerOptions, quit <-chan struct{}, logger *zap.Logger) (*paddleWorker, error) {
+	args := append([]string{opts.serverScript}, opts.args...)
+	cmd := exec.Command(opts.pythonExe, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Paddle 的推理日志走 stderr，仅在 debug 级别输出
+	cmd.Stderr = &paddleStderrWriter{logger: logger}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	w := &paddleWorker{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan []byte, 1),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case w.lines <- line:
+			case <-w.done:
+				return
+			}
+		}
+	}()
+	go func() {
+		_ = cmd.Wait()
+		close(w.done)
+	}()
+
+	// 等待模型加载完成
+	timer := time.NewTimer(opts.startupTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case line := <-w.lines:
+			var resp paddleResponse
+			if json.Unmarshal(line, &resp) == nil && resp.Ready {
+				return w, nil
+			}
+		case <-w.done:
+			return nil, fmt.Errorf("paddle ocr worker exited during startup: %v", cmd.ProcessState)
+		case <-timer.C:
+			w.kill()
+			return nil, errors.New("paddle ocr worker startup timeout")
+		case <-quit:
+			w.kill()
+			return nil, errPaddlePoolUnavailable
+		}
+	}
+}
+
+// call 发送一个请求并等待对应 id 的响应；超时或进程退出时返回错误（调用方负责终止该 worker）
+func (w *paddleWorker) call(ctx context.Context, req paddleRequest) (*paddleResponse, error) {
+	w.nextID++
+	req.ID = w.nextID
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.stdin.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case line := <-w.lines:
+			var resp paddleResponse
+			if err := json.Unmarshal(line, &resp); err != nil || resp.ID != req.ID {
+				// 非协议输出或上一个超时请求的迟到响应，忽略
+				continue
+			}
+			return &resp, nil
+		case <-w.done:
+			return nil, errors.New("paddle ocr worker exited")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (w *paddleWorker) alive() bool {
+	select {
+	case <-w.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (w *paddleWorker) kill() {
+	if w.cmd.Process != nil {
+		_ = w.cmd.Process.Kill()
+	}
+}
+
+// stop 关闭 stdin 让子进程自行退出，超时后强制结束
+func (w *paddleWorker) stop(ctx context.Context) {
+	_ = w.stdin.Close()
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.kill()
+		<-w.done
+	}
+}
+
+// paddleWorkerPool 常驻进程池：每个槽位由监督协程负责启动与异常退出后的重启
+type paddleWorkerPool struct {
+	opts   paddleWorkerOptions
+	logger *zap.Logger
+	idle   chan *paddleWorker
+	live   atomic.Int32
+	quit   chan struct{}
+
+	mu      sync.Mutex
+	workers map[*paddleWorker]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func newPaddleWorkerPool(opts paddleWorkerOptions, logger *zap.Logger) *paddleWorkerPool {
+	if opts.poolSize <= 0 {
+		opts.poolSize = 1
+	}
+	p := &paddleWorkerPool{
+		opts:    opts,
+		logger:  logger,
+		idle:    make(chan *paddleWorker, opts.poolSize),
+		quit:    make(chan struct{}),
+		workers: make(map[*paddleWorker]struct{}),
+	}
+	for i := 0; i < opts.poolSize; i++ {
+		p.wg.Add(1)
+		go p.supervise(i)
+	}
+	if opts.healthInterval > 0 {
+		p.wg.Add(1)
+		go p.healthLoop()
+	}
+	logger.Info("🐍 PaddleOCR 常驻进程池启动", zap.Int("pool_size", opts.poolSize), zap.String("script", opts.serverScript))
+	return p
+}
+
+// supervise 维持一个槽位的 worker 存活，启动失败按指数退避重试（最长30秒）
+func (p *paddleWorkerPool) supervise(slot int) {
+	defer p.wg.Done()
+	backoff := time.Second
+	for {
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
+
+		start := time.Now()
+		w, err := startPaddleWorker(p.opts, p.quit, p.logger)
+		if err != nil {
+			p.logger.Warn("PaddleOCR worker 启动失败", zap.Int("slot", slot), zap.Duration("retry_in", backoff), zap.Error(err))
+			select {
+			case <-p.quit:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+			continue
+		}
+		backoff = time.Second
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			w.stop(context.Background())
+			return
+		}
+		p.workers[w] = struct{}{}
+		p.mu.Unlock()
+
+		p.live.Add(1)
+		p.logger.Info("PaddleOCR worker 就绪", zap.Int("slot", slot), zap.Int("pid", w.cmd.Process.Pid), zap.Duration("startup", time.Since(start)))
+		p.idle <- w
+
+		<-w.done
+		p.live.Add(-1)
+		p.mu.Lock()
+		delete(p.workers, w)
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			return
+		}
+		p.logger.Warn("PaddleOCR worker 已退出，准备重启", zap.Int("slot", slot), zap.String("state", fmt.Sprint(w.cmd.ProcessState)))
+	}
+}
+
+// healthLoop 定期 ping 空闲 worker，无响应的直接结束（由监督协程重启）
+func (p *paddleWorkerPool) healthLoop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.opts.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-ticker.C:
+		}
+		for i := 0; i < p.opts.poolSize; i++ {
+			var w *paddleWorker
+			select {
+			case w = <-p.idle:
+			default:
+			}
+			if w == nil {
+				break
+			}
+			if !w.alive() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			resp, err := w.call(ctx, paddleRequest{Cmd: "ping"})
+			cancel()
+			if err != nil || !resp.Pong {
+				p.logger.Warn("PaddleOCR worker 健康检查失败，重启", zap.Int("pid", w.cmd.Process.Pid), zap.Error(err))
+				w.kill()
+				continue
+			}
+			p.idle <- w
+		}
+	}
+}
+
+// acquire 取一个存活的空闲 worker；没有存活 worker 时立即返回 errPaddlePoolUnavailable
+func (p *paddleWorkerPool) acquire(ctx context.Context) (*paddleWorker, error) {
+	for {
+		if p.live.Load() == 0 {
+			return nil, errPaddlePoolUnavailable
+		}
+		select {
+		case w := <-p.idle:
+			if w.alive() {
+				return w, nil
+			}
+		case <-p.quit:
+			return nil, errPaddlePoolUnavailable
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Recognize 使用常驻进程识别一张 base64 图片，返回原始文本及模型置信度
+func (p *paddleWorkerPool) Recognize(ctx context.Context, base64Image string) (string, float64, error) {
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := w.call(ctx, paddleRequest{Image: base64Image})
+	if err != nil {
+		// 状态未知（可能仍在推理），直接结束，由监督协程重启
+		w.kill()
+		return "", 0, fmt.Errorf("paddle ocr worker: %w", err)
+	}
+	p.idle <- w
+	if resp.Error != "" {
+		return "", 0, fmt.Errorf("paddle ocr: %s", resp.Error)
+	}
+	return resp.Text, resp.Score, nil
+}
+
+// Close 停止所有 worker：先关闭 stdin 等待自行退出，ctx 到期后强制结束
+func (p *paddleWorkerPool) Close(ctx context.Context) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.quit)
+	workers := make([]*paddleWorker, 0, len(p.workers))
+	for w := range p.workers {
+		workers = append(workers, w)
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *paddleWorker) {
+			defer wg.Done()
+			w.stop(ctx)
+		}(w)
+	}
+	wg.Wait()
+	p.wg.Wait()
+	p.logger.Info("PaddleOCR 常驻进程池已关闭", zap.Int("workers", len(workers)))
+}
+
+// paddleStderrWriter 将子进程 stderr 按行写入 debug 日志
+type paddleStderrWriter struct {
+	logger *zap.Logger
+	buf    []byte
+}
+
+func (w *paddleStderrWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.buf[:i])); line != "" {
+			w.logger.Debug("paddle worker", zap.String("stderr", line))
+		}
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > 4096 {
+		w.buf = w.buf[:0]
+	}
+	return len(b), nil
+}
+
+var (
+	paddlePoolMu sync.Mutex
+	paddlePool   *paddleWorkerPool
+)
+
+// sharedPaddlePool 进程内共享的常驻进程池（Key 热更新会重建识别器，但不重启子进程）
+func sharedPaddlePool(opts paddleWorkerOptions, logger *zap.Logger) *paddleWorkerPool {
+	paddlePoolMu.Lock()
+	defer paddlePoolMu.Unlock()
+	if paddlePool == nil {
+		paddlePool = newPaddleWorkerPool(opts, logger)
+	}
+	return paddlePool
+}
+
+// ShutdownPaddleOCR 优雅关闭 PaddleOCR 常驻进程（未启用常驻模式时无操作）
+func ShutdownPaddleOCR(ctx context.Context) {
+	paddlePoolMu.Lock()
+	pool := paddlePool
+	paddlePool = nil
+	paddlePoolMu.Unlock()
+	if pool != nil {
+		pool.Close(ctx)
+	}
+}
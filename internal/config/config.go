@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -14,12 +16,20 @@ type Config struct {
 	Worker   WorkerConfig   `mapstructure:"worker"`
 	RSS      RSSConfig      `mapstructure:"rss"`
 	Security SecurityConfig `mapstructure:"security"`
+	CORS     CORSConfig     `mapstructure:"cors"`
+	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Events   EventsConfig   `mapstructure:"events"`
+	Webhook  WebhookConfig  `mapstructure:"webhook"`
 }
 
 type ServerConfig struct {
 	Port         string        `mapstructure:"port"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	// TrustedProxies 可信反向代理（IP 或 CIDR）：仅来自这些地址的 X-Forwarded-For/X-Real-IP 会被采信，为空时客户端IP取连接地址
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -35,8 +45,32 @@ type DatabaseConfig struct {
 type OCRConfig struct {
 	BaiduAPIKey    string `mapstructure:"baidu_api_key"`
 	BaiduSecretKey string `mapstructure:"baidu_secret_key"`
+	// 多 Provider 投票：并行询问多个 Provider，结果一致或置信度达标才提交验证码
+	ConsensusEnabled    bool    `mapstructure:"consensus_enabled"`
+	ConsensusVoters     int     `mapstructure:"consensus_voters"`     // 并行询问的 Provider 数
+	ConsensusMinAgree   int     `mapstructure:"consensus_min_agree"`  // 至少几个结果一致
+	ConsensusConfidence float64 `mapstructure:"consensus_confidence"` // 单个结果置信度达到该值即可提交，0 表示不启用
+	ConsensusFallback   string  `mapstructure:"consensus_fallback"`   // 未达成一致时：best 提交最优答案 / refetch 重新获取验证码
+	// 验证码样本采集：保存图片、OCR 答案与游戏判定，用于重新训练模型
+	CaptureEnabled   bool          `mapstructure:"capture_enabled"`
+	CaptureDir       string        `mapstructure:"capture_dir"`
+	CaptureMaxMB     int           `mapstructure:"capture_max_mb"`    // 目录总大小上限（MB），0 表示不限制
+	CaptureRetention time.Duration `mapstructure:"capture_retention"` // 样本保留时长，0 表示不过期
+	// 熔断：连续失败达到次数后暂停调度该 Key，冷却后放行单个探测请求
+	BreakerFailures    int           `mapstructure:"breaker_failures"`
+	BreakerCooldown    time.Duration `mapstructure:"breaker_cooldown"`     // 首次冷却时间
+	BreakerMaxCooldown time.Duration `mapstructure:"breaker_max_cooldown"` // 探测失败时冷却翻倍的上限
+	// 额度：使用统计批量写入间隔；剩余额度占比跌破阈值时发出 ocr_key.quota_low 事件
+	UsageFlushInterval  time.Duration `mapstructure:"usage_flush_interval"`
+	QuotaWarnThresholds []float64     `mapstructure:"quota_warn_thresholds"`
+	// 验证码预处理流水线（格式见 client.CaptchaPipeline），可按 provider 覆盖，none 表示发送原图
+	Preprocess           string            `mapstructure:"preprocess"`
+	PreprocessByProvider map[string]string `mapstructure:"preprocess_by_provider"`
 }
 
+// preprocessProviders 可通过 CAPTCHA_PREPROCESS_<PROVIDER> 单独配置预处理流水线的内置 provider
+var preprocessProviders = []string{"baidu", "paddle", "crnn", "http"}
+
 type WorkerConfig struct {
 	Concurrency  int `mapstructure:"concurrency"`
 	RateLimitQPS int `mapstructure:"rate_limit_qps"`
@@ -49,6 +83,108 @@ type RSSConfig struct {
 
 type SecurityConfig struct {
 	AccountAddSalt string `mapstructure:"account_add_salt"`
+	// 玩家登录验证码的 HMAC 密钥，为空时每次启动随机生成（多实例部署需配置相同的值）
+	PlayerChallengeSecret string `mapstructure:"player_challenge_secret"`
+	// 签名防重放：时间戳允许的时钟偏差（<=0 时不校验也不解析时间戳）、是否使用数据库持久化已用签名、按IP限流
+	SignMaxSkew         time.Duration `mapstructure:"sign_max_skew"`
+	SignNonceDB         bool          `mapstructure:"sign_nonce_db"`
+	SignRateLimitPerMin int           `mapstructure:"sign_rate_limit_per_min"`
+	SignRateLimitBurst  int           `mapstructure:"sign_rate_limit_burst"`
+	// 旧版共享密码登录（admin_passwords），迁移到具名管理员后可关闭
+	AdminLegacyLogin bool   `mapstructure:"admin_legacy_login"`
+	AdminLegacyRole  string `mapstructure:"admin_legacy_role"`
+	// 登录防爆破：单IP连续失败后指数退避锁定，全局每分钟失败上限
+	LoginMaxAttempts     int           `mapstructure:"login_max_attempts"`
+	LoginLockoutBase     time.Duration `mapstructure:"login_lockout_base"`
+	LoginLockoutMax      time.Duration `mapstructure:"login_lockout_max"`
+	LoginGlobalMaxPerMin int           `mapstructure:"login_global_max_per_min"`
+	// 第三方凭据（OCR Key）加密主密钥：ID -> base64 编码的 32 字节密钥；CredentialKeyID 为加密新凭据使用的主密钥
+	CredentialKeys  map[string]string `mapstructure:"credential_keys"`
+	CredentialKeyID string            `mapstructure:"credential_key_id"`
+}
+
+// MetricsConfig Prometheus 指标（默认关闭）：Addr 为空时挂载在主服务的 /metrics（无鉴权），否则单独监听（如 127.0.0.1:9100）
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Addr    string `mapstructure:"addr"`
+}
+
+// EventsConfig 兑换进度实时推送（SSE）
+type EventsConfig struct {
+	MaxSubscribers int           `mapstructure:"max_subscribers"` // 同时在线的订阅连接上限
+	Heartbeat      time.Duration `mapstructure:"heartbeat"`       // 空闲心跳间隔，避免代理断开长连接
+}
+
+// WebhookConfig 出站 Webhook 投递
+type WebhookConfig struct {
+	Timeout     time.Duration `mapstructure:"timeout"`      // 单次投递超时
+	MaxAttempts int           `mapstructure:"max_attempts"` // 含首次在内的最大投递次数
+}
+
+// HealthConfig 健康检查阈值
+type HealthConfig struct {
+	QueueMaxAge time.Duration `mapstructure:"queue_max_age"` // 任务积压超过该时长视为降级
+	MinOCRKeys  int           `mapstructure:"min_ocr_keys"`  // 可用OCR Key少于该数量视为降级
+}
+
+// TracingConfig OpenTelemetry 链路追踪，通过 OTLP/HTTP 导出到本地采集器
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"` // host:port，如 localhost:4318
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
+}
+
+// CORSConfig 跨域策略：Default 作用于全部接口，Player/Admin 分别覆盖玩家自助与需要管理员角色的接口（未设置的项沿用 Default）
+type CORSConfig struct {
+	Default CORSPolicy `mapstructure:"default"`
+	Player  CORSPolicy `mapstructure:"player"`
+	Admin   CORSPolicy `mapstructure:"admin"`
+}
+
+type CORSPolicy struct {
+	AllowOrigins     []string      `mapstructure:"allow_origins"` // "*" 表示任意来源，支持 "https://*.example.com"
+	AllowMethods     []string      `mapstructure:"allow_methods"`
+	AllowHeaders     []string      `mapstructure:"allow_headers"`
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+// loadCORSPolicy 读取 <prefix>ALLOW_ORIGINS 等配置，未设置的项使用 fallback
+func loadCORSPolicy(prefix string, fallback CORSPolicy) CORSPolicy {
+	policy := fallback
+	if viper.IsSet(prefix + "ALLOW_ORIGINS") {
+		policy.AllowOrigins = splitList(viper.GetString(prefix + "ALLOW_ORIGINS"))
+	}
+	if viper.IsSet(prefix + "ALLOW_METHODS") {
+		policy.AllowMethods = splitList(viper.GetString(prefix + "ALLOW_METHODS"))
+	}
+	if viper.IsSet(prefix + "ALLOW_HEADERS") {
+		policy.AllowHeaders = splitList(viper.GetString(prefix + "ALLOW_HEADERS"))
+	}
+	if viper.IsSet(prefix + "EXPOSE_HEADERS") {
+		policy.ExposeHeaders = splitList(viper.GetString(prefix + "EXPOSE_HEADERS"))
+	}
+	if viper.IsSet(prefix + "ALLOW_CREDENTIALS") {
+		policy.AllowCredentials = viper.GetBool(prefix + "ALLOW_CREDENTIALS")
+	}
+	if viper.IsSet(prefix + "MAX_AGE") {
+		policy.MaxAge = viper.GetDuration(prefix + "MAX_AGE")
+	}
+	return policy
+}
+
+// splitList 解析逗号分隔的配置值
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 func Load() *Config {
@@ -75,6 +211,50 @@ func Load() *Config {
 	viper.SetDefault("RSS_FEED_URL", "http://120.48.143.190:10082/feedAtom/4af6b7ea933926777b95712e9ec3fb1a")
 	viper.SetDefault("RSS_UPDATE_URL", "http://120.48.143.190:10082/updateFeedAll?key=313b1e3098a7e7765260e9b51e16a47a")
 	viper.SetDefault("ACCOUNT_ADD_SALT", "8$#@!@#J$%^&*T()_+L")
+	viper.SetDefault("SIGN_MAX_SKEW", "5m")
+	viper.SetDefault("SIGN_NONCE_DB", false)
+	viper.SetDefault("SIGN_RATE_LIMIT_PER_MIN", 10)
+	viper.SetDefault("SIGN_RATE_LIMIT_BURST", 5)
+	viper.SetDefault("ADMIN_LEGACY_LOGIN", true)
+	viper.SetDefault("ADMIN_LEGACY_ROLE", "owner")
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", "1m")
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", "1h")
+	viper.SetDefault("LOGIN_GLOBAL_MAX_PER_MIN", 60)
+	viper.SetDefault("CORS_ALLOW_ORIGINS", "*")
+	viper.SetDefault("CORS_ALLOW_METHODS", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	viper.SetDefault("CORS_ALLOW_HEADERS", "Content-Type, Authorization, Idempotency-Key")
+	viper.SetDefault("CORS_EXPOSE_HEADERS", "X-Request-ID")
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	viper.SetDefault("CORS_MAX_AGE", "12h")
+	viper.SetDefault("METRICS_ENABLED", false)
+	viper.SetDefault("METRICS_ADDR", "")
+	viper.SetDefault("HEALTH_QUEUE_MAX_AGE", "10m")
+	viper.SetDefault("EVENTS_MAX_SUBSCRIBERS", 100)
+	viper.SetDefault("EVENTS_HEARTBEAT", "15s")
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 4)
+	viper.SetDefault("HEALTH_MIN_OCR_KEYS", 1)
+	viper.SetDefault("OCR_CONSENSUS_ENABLED", false)
+	viper.SetDefault("OCR_CONSENSUS_VOTERS", 2)
+	viper.SetDefault("OCR_CONSENSUS_MIN_AGREE", 2)
+	viper.SetDefault("OCR_CONSENSUS_CONFIDENCE", 0.95)
+	viper.SetDefault("OCR_CONSENSUS_FALLBACK", "best")
+	viper.SetDefault("OCR_BREAKER_FAILURES", 5)
+	viper.SetDefault("OCR_BREAKER_COOLDOWN", "30s")
+	viper.SetDefault("OCR_BREAKER_MAX_COOLDOWN", "10m")
+	viper.SetDefault("OCR_USAGE_FLUSH_INTERVAL", "10s")
+	viper.SetDefault("OCR_QUOTA_WARN_THRESHOLDS", "0.2,0.05")
+	viper.SetDefault("CAPTCHA_CAPTURE_ENABLED", false)
+	viper.SetDefault("CAPTCHA_CAPTURE_DIR", "./data/captcha")
+	viper.SetDefault("CAPTCHA_CAPTURE_MAX_MB", 500)
+	viper.SetDefault("CAPTCHA_CAPTURE_RETENTION", "720h")
+	viper.SetDefault("CAPTCHA_PREPROCESS", "scale(2,nearest)|threshold(128)|smooth")
+	viper.SetDefault("TRACING_ENABLED", false)
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	viper.SetDefault("TRACING_OTLP_INSECURE", true)
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("TRACING_SERVICE_NAME", "wjdr-backend-go")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("配置文件读取失败，使用环境变量: %v", err)
@@ -85,6 +265,7 @@ func Load() *Config {
 	// 映射环境变量到配置结构
 	config.Server.Port = viper.GetString("PORT")
 	config.Server.ReadTimeout = viper.GetDuration("HTTP_READ_TIMEOUT")
+	config.Server.TrustedProxies = splitList(viper.GetString("TRUSTED_PROXIES"))
 	config.Server.WriteTimeout = viper.GetDuration("HTTP_WRITE_TIMEOUT")
 
 	config.Database.Host = viper.GetString("DB_HOST")
@@ -97,6 +278,32 @@ func Load() *Config {
 
 	config.OCR.BaiduAPIKey = viper.GetString("BAIDU_API_KEY")
 	config.OCR.BaiduSecretKey = viper.GetString("BAIDU_SECRET_KEY")
+	config.OCR.ConsensusEnabled = viper.GetBool("OCR_CONSENSUS_ENABLED")
+	config.OCR.ConsensusVoters = viper.GetInt("OCR_CONSENSUS_VOTERS")
+	config.OCR.ConsensusMinAgree = viper.GetInt("OCR_CONSENSUS_MIN_AGREE")
+	config.OCR.ConsensusConfidence = viper.GetFloat64("OCR_CONSENSUS_CONFIDENCE")
+	config.OCR.ConsensusFallback = strings.ToLower(viper.GetString("OCR_CONSENSUS_FALLBACK"))
+	config.OCR.BreakerFailures = viper.GetInt("OCR_BREAKER_FAILURES")
+	config.OCR.BreakerCooldown = viper.GetDuration("OCR_BREAKER_COOLDOWN")
+	config.OCR.BreakerMaxCooldown = viper.GetDuration("OCR_BREAKER_MAX_COOLDOWN")
+	config.OCR.UsageFlushInterval = viper.GetDuration("OCR_USAGE_FLUSH_INTERVAL")
+	for _, item := range splitList(viper.GetString("OCR_QUOTA_WARN_THRESHOLDS")) {
+		if v, err := strconv.ParseFloat(item, 64); err == nil {
+			config.OCR.QuotaWarnThresholds = append(config.OCR.QuotaWarnThresholds, v)
+		}
+	}
+	config.OCR.CaptureEnabled = viper.GetBool("CAPTCHA_CAPTURE_ENABLED")
+	config.OCR.CaptureDir = viper.GetString("CAPTCHA_CAPTURE_DIR")
+	config.OCR.CaptureMaxMB = viper.GetInt("CAPTCHA_CAPTURE_MAX_MB")
+	config.OCR.CaptureRetention = viper.GetDuration("CAPTCHA_CAPTURE_RETENTION")
+	config.OCR.Preprocess = viper.GetString("CAPTCHA_PREPROCESS")
+	config.OCR.PreprocessByProvider = map[string]string{}
+	for _, provider := range preprocessProviders {
+		key := "CAPTCHA_PREPROCESS_" + strings.ToUpper(provider)
+		if viper.IsSet(key) {
+			config.OCR.PreprocessByProvider[provider] = viper.GetString(key)
+		}
+	}
 
 	config.Worker.Concurrency = viper.GetInt("WORKER_CONCURRENCY")
 	config.Worker.RateLimitQPS = viper.GetInt("RATE_LIMIT_QPS")
@@ -105,6 +312,56 @@ func Load() *Config {
 	config.RSS.UpdateURL = viper.GetString("RSS_UPDATE_URL")
 
 	config.Security.AccountAddSalt = viper.GetString("ACCOUNT_ADD_SALT")
+	config.Security.PlayerChallengeSecret = viper.GetString("PLAYER_CHALLENGE_SECRET")
+	config.Security.SignMaxSkew = viper.GetDuration("SIGN_MAX_SKEW")
+	config.Security.SignNonceDB = viper.GetBool("SIGN_NONCE_DB")
+	config.Security.SignRateLimitPerMin = viper.GetInt("SIGN_RATE_LIMIT_PER_MIN")
+	config.Security.SignRateLimitBurst = viper.GetInt("SIGN_RATE_LIMIT_BURST")
+	config.Security.AdminLegacyLogin = viper.GetBool("ADMIN_LEGACY_LOGIN")
+	config.Security.AdminLegacyRole = viper.GetString("ADMIN_LEGACY_ROLE")
+	config.Security.LoginMaxAttempts = viper.GetInt("LOGIN_MAX_ATTEMPTS")
+	config.Security.LoginLockoutBase = viper.GetDuration("LOGIN_LOCKOUT_BASE")
+	config.Security.LoginLockoutMax = viper.GetDuration("LOGIN_LOCKOUT_MAX")
+	config.Security.LoginGlobalMaxPerMin = viper.GetInt("LOGIN_GLOBAL_MAX_PER_MIN")
+	// CREDENTIAL_KEYS 格式：id1:base64key1,id2:base64key2；CREDENTIAL_KEY_ID 未设置时使用第一个
+	config.Security.CredentialKeys = map[string]string{}
+	for _, item := range splitList(viper.GetString("CREDENTIAL_KEYS")) {
+		id, key, ok := strings.Cut(item, ":")
+		if !ok {
+			log.Printf("忽略格式错误的 CREDENTIAL_KEYS 项（应为 id:base64key）")
+			continue
+		}
+		id = strings.TrimSpace(id)
+		config.Security.CredentialKeys[id] = strings.TrimSpace(key)
+		if config.Security.CredentialKeyID == "" {
+			config.Security.CredentialKeyID = id
+		}
+	}
+	if id := strings.TrimSpace(viper.GetString("CREDENTIAL_KEY_ID")); id != "" {
+		config.Security.CredentialKeyID = id
+	}
+
+	config.CORS.Default = loadCORSPolicy("CORS_", CORSPolicy{})
+	config.CORS.Player = loadCORSPolicy("CORS_PLAYER_", config.CORS.Default)
+	config.CORS.Admin = loadCORSPolicy("CORS_ADMIN_", config.CORS.Default)
+
+	config.Metrics.Enabled = viper.GetBool("METRICS_ENABLED")
+	config.Metrics.Addr = viper.GetString("METRICS_ADDR")
+
+	config.Health.QueueMaxAge = viper.GetDuration("HEALTH_QUEUE_MAX_AGE")
+	config.Health.MinOCRKeys = viper.GetInt("HEALTH_MIN_OCR_KEYS")
+
+	config.Events.MaxSubscribers = viper.GetInt("EVENTS_MAX_SUBSCRIBERS")
+	config.Events.Heartbeat = viper.GetDuration("EVENTS_HEARTBEAT")
+
+	config.Webhook.Timeout = viper.GetDuration("WEBHOOK_TIMEOUT")
+	config.Webhook.MaxAttempts = viper.GetInt("WEBHOOK_MAX_ATTEMPTS")
+
+	config.Tracing.Enabled = viper.GetBool("TRACING_ENABLED")
+	config.Tracing.Endpoint = viper.GetString("TRACING_OTLP_ENDPOINT")
+	config.Tracing.Insecure = viper.GetBool("TRACING_OTLP_INSECURE")
+	config.Tracing.SampleRatio = viper.GetFloat64("TRACING_SAMPLE_RATIO")
+	config.Tracing.ServiceName = viper.GetString("TRACING_SERVICE_NAME")
 
 	return &config
 }
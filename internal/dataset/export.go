@@ -0,0 +1,116 @@
+package dataset
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportOptions 导出为 PaddleOCR 识别训练集（rec_gt 标签文件）
+type ExportOptions struct {
+	OutDir        string
+	UsePreprocess bool      // 使用预处理后的图片（默认原图，与线上预处理解耦）
+	ValRatio      float64   // 验证集比例，按样本ID哈希稳定划分
+	Since         time.Time // 仅导出该时间之后的样本，零值表示全部
+}
+
+// ExportResult 导出统计
+type ExportResult struct {
+	Train   int
+	Val     int
+	Wrong   int // 识别错误的样本：真实标签未知，写入 wrong.txt 供人工标注
+	Skipped int // 缺少图片文件等
+}
+
+// Export 将判定为正确的样本写入 rec_gt_train.txt / rec_gt_val.txt（每行 "images/<文件名>\t<标签>"），
+// 图片复制到 OutDir/images；判定为错误的样本写入 wrong.txt（附 OCR 给出的错误答案）
+func Export(dir string, opts ExportOptions) (*ExportResult, error) {
+	samples, err := ReadSamples(dir)
+	if err != nil {
+		return nil, err
+	}
+	imagesDir := filepath.Join(opts.OutDir, "images")
+	if err := os.MkdirAll(imagesDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	files := map[string]*os.File{}
+	for _, name := range []string{"rec_gt_train.txt", "rec_gt_val.txt", "wrong.txt"} {
+		f, err := os.Create(filepath.Join(opts.OutDir, name))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		files[name] = f
+	}
+
+	res := &ExportResult{}
+	for _, s := range samples {
+		if !opts.Since.IsZero() && s.CapturedAt.Before(opts.Since) {
+			continue
+		}
+		src := s.RawImage
+		if opts.UsePreprocess && s.PreImage != "" {
+			src = s.PreImage
+		}
+		name := strings.ReplaceAll(src, "/", "_")
+		if err := copyFile(filepath.Join(dir, filepath.FromSlash(src)), filepath.Join(imagesDir, name)); err != nil {
+			res.Skipped++
+			continue
+		}
+		rel := "images/" + name
+
+		var target string
+		switch s.Verdict {
+		case VerdictCorrect:
+			target = "rec_gt_train.txt"
+			if inValSplit(s.ID, opts.ValRatio) {
+				target = "rec_gt_val.txt"
+				res.Val++
+			} else {
+				res.Train++
+			}
+			_, err = fmt.Fprintf(files[target], "%s\t%s\n", rel, s.Answer)
+		case VerdictWrong:
+			res.Wrong++
+			_, err = fmt.Fprintf(files["wrong.txt"], "%s\t%s\t%s\n", rel, s.Answer, s.Provider)
+		default:
+			res.Skipped++
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// inValSplit 按ID哈希划分验证集，重复导出时划分结果稳定
+func inValSplit(id string, ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return float64(h.Sum32()%10000)/10000 < ratio
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
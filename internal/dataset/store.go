@@ -0,0 +1,261 @@
+// Package dataset 从线上兑换流量中采集验证码样本：游戏接口对提交的验证码给出判定（40103 为错误），
+// 以此自动标注 OCR 结果，用于重新训练验证码模型。
+package dataset
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 样本判定
+const (
+	VerdictCorrect = "correct" // 游戏接受了验证码（兑换成功或返回业务错误）
+	VerdictWrong   = "wrong"   // 游戏返回 40103 验证码错误
+)
+
+// samplesFile 每日目录下的样本索引（JSON Lines）
+const samplesFile = "samples.jsonl"
+
+// dayLayout 每日目录名
+const dayLayout = "20060102"
+
+// Sample 一条验证码样本；图片路径相对于采集根目录
+type Sample struct {
+	ID         string    `json:"id"`
+	RawImage   string    `json:"raw_image"`
+	PreImage   string    `json:"pre_image,omitempty"`
+	Answer     string    `json:"answer"`
+	Provider   string    `json:"provider,omitempty"`
+	KeyID      int       `json:"key_id,omitempty"`
+	Confidence float64   `json:"confidence"`
+	Verdict    string    `json:"verdict"`
+	ErrCode    int       `json:"err_code"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Options 采集目录与容量限制
+type Options struct {
+	Dir       string
+	MaxBytes  int64         // 目录总大小上限，超出时删除最早的日期目录；0 表示不限制
+	Retention time.Duration // 样本保留时长，0 表示不过期
+}
+
+// Store 采集存储（并发安全）。按日期分目录，超出容量或过期时整日删除
+type Store struct {
+	opts      Options
+	logger    *zap.Logger
+	mu        sync.Mutex
+	size      int64
+	lastPrune time.Time
+	full      bool // 仅剩当日目录仍超出上限时停止写入，次日恢复
+}
+
+// NewStore 创建采集目录并统计已有样本大小
+func NewStore(opts Options, logger *zap.Logger) (*Store, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("dataset: capture dir is required")
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	size, err := dirSize(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{opts: opts, logger: logger, size: size}
+	s.mu.Lock()
+	s.prune(time.Now())
+	s.mu.Unlock()
+	logger.Info("验证码样本采集已启用", zap.String("dir", opts.Dir), zap.Int64("size_bytes", s.size))
+	return s, nil
+}
+
+// Capture 保存原图、预处理后的图片（可为空）与标注信息
+func (s *Store) Capture(raw, pre []byte, sample Sample) error {
+	if len(raw) == 0 {
+		return errors.New("dataset: empty image")
+	}
+	now := time.Now()
+	if sample.CapturedAt.IsZero() {
+		sample.CapturedAt = now
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastPrune) > 10*time.Minute || (s.opts.MaxBytes > 0 && s.size > s.opts.MaxBytes) {
+		s.prune(now)
+	}
+	if s.full {
+		return nil
+	}
+
+	day := sample.CapturedAt.Format(dayLayout)
+	dayDir := filepath.Join(s.opts.Dir, day)
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		return err
+	}
+	if sample.ID == "" {
+		sample.ID = newSampleID(sample.CapturedAt)
+	}
+
+	written := int64(0)
+	name := sample.ID + ".raw" + imageExt(raw)
+	if err := os.WriteFile(filepath.Join(dayDir, name), raw, 0o644); err != nil {
+		return err
+	}
+	sample.RawImage = filepath.ToSlash(filepath.Join(day, name))
+	written += int64(len(raw))
+	if len(pre) > 0 {
+		name := sample.ID + ".pre" + imageExt(pre)
+		if err := os.WriteFile(filepath.Join(dayDir, name), pre, 0o644); err != nil {
+			return err
+		}
+		sample.PreImage = filepath.ToSlash(filepath.Join(day, name))
+		written += int64(len(pre))
+	}
+
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dayDir, samplesFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.size += written + int64(len(line)) + 1
+	return nil
+}
+
+// prune 删除过期日期目录；总大小超出上限时从最早的日期开始删除（调用方持有锁）
+func (s *Store) prune(now time.Time) {
+	s.lastPrune = now
+	days, err := listDays(s.opts.Dir)
+	if err != nil {
+		s.logger.Warn("读取验证码样本目录失败", zap.Error(err))
+		return
+	}
+	today := now.Format(dayLayout)
+	for _, day := range days {
+		t, _ := time.ParseInLocation(dayLayout, day, now.Location())
+		expired := s.opts.Retention > 0 && now.Sub(t) > s.opts.Retention+24*time.Hour
+		overSize := s.opts.MaxBytes > 0 && s.size > s.opts.MaxBytes
+		if !expired && (!overSize || day == today) {
+			continue
+		}
+		dir := filepath.Join(s.opts.Dir, day)
+		size, _ := dirSize(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			s.logger.Warn("删除验证码样本目录失败", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		s.size -= size
+		s.logger.Info("已清理验证码样本", zap.String("day", day), zap.Bool("expired", expired), zap.Int64("freed_bytes", size))
+	}
+	full := s.opts.MaxBytes > 0 && s.size > s.opts.MaxBytes
+	if full && !s.full {
+		s.logger.Warn("验证码样本目录已达容量上限，今日暂停采集", zap.Int64("size_bytes", s.size), zap.Int64("max_bytes", s.opts.MaxBytes))
+	}
+	s.full = full
+}
+
+// ReadSamples 读取采集目录下全部样本（按采集时间排序）
+func ReadSamples(dir string) ([]Sample, error) {
+	days, err := listDays(dir)
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, day := range days {
+		f, err := os.Open(filepath.Join(dir, day, samplesFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var sample Sample
+			if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+				continue // 写入中断导致的残行
+			}
+			samples = append(samples, sample)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].CapturedAt.Before(samples[j].CapturedAt) })
+	return samples, nil
+}
+
+// listDays 返回按日期升序排列的每日目录名
+func listDays(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var days []string
+	for _, e := range entries {
+		if _, err := time.Parse(dayLayout, e.Name()); e.IsDir() && err == nil {
+			days = append(days, e.Name())
+		}
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
+func newSampleID(t time.Time) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%s%03d_%s", t.Format("150405"), t.Nanosecond()/int(time.Millisecond), hex.EncodeToString(b[:]))
+}
+
+// imageExt 根据内容判断图片扩展名
+func imageExt(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/bmp":
+		return ".bmp"
+	default:
+		return ".png"
+	}
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// 兑换进度事件类型
+const (
+	TypeBatchStarted      = "batch_started"      // 任务开始处理
+	TypeAccountStarted    = "account_started"    // 账号开始兑换
+	TypeCaptchaAttempt    = "captcha_attempt"    // 一次验证码识别+兑换尝试
+	TypeCooldownScheduled = "cooldown_scheduled" // 账号进入冷却/退避
+	TypeAccountResult     = "account_result"     // 账号最终结果
+	TypeBatchCompleted    = "batch_completed"    // 任务处理完成
+	TypeJobDeadLettered   = "job_dead_lettered"  // 任务重试耗尽，不再处理
+)
+
+// Event 兑换进度事件
+type Event struct {
+	Type         string    `json:"type"`
+	JobID        int64     `json:"job_id,omitempty"`
+	JobType      string    `json:"job_type,omitempty"`
+	RedeemCodeID int       `json:"redeem_code_id,omitempty"`
+	GiftCode     string    `json:"gift_code"`
+	AccountID    int       `json:"account_id,omitempty"`
+	FID          string    `json:"fid,omitempty"`
+	Attempt      int       `json:"attempt,omitempty"`
+	Captcha      string    `json:"captcha,omitempty"`
+	ErrCode      int       `json:"err_code,omitempty"`
+	Success      bool      `json:"success,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	DelaySeconds int       `json:"delay_seconds,omitempty"`
+	Total        int       `json:"total,omitempty"`
+	SuccessCount int       `json:"success_count,omitempty"`
+	FailedCount  int       `json:"failed_count,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Subscription 订阅；GiftCode 为空时接收全部事件
+type Subscription struct {
+	C        <-chan Event
+	ch       chan Event
+	giftCode string
+	bus      *Bus
+}
+
+// Bus 进程内事件总线：发布不阻塞，订阅者消费过慢时丢弃事件
+type Bus struct {
+	mu             sync.RWMutex
+	subs           map[*Subscription]struct{}
+	maxSubscribers int
+}
+
+// NewBus 创建事件总线；maxSubscribers<=0 时不限制订阅数
+func NewBus(maxSubscribers int) *Bus {
+	return &Bus{
+		subs:           make(map[*Subscription]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// Subscribe 订阅事件（giftCode 为空表示全部），超过订阅上限时返回 nil
+func (b *Bus) Subscribe(giftCode string, buffer int) *Subscription {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxSubscribers > 0 && len(b.subs) >= b.maxSubscribers {
+		return nil
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, giftCode: giftCode, bus: b}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+// Publish 发布事件；b 为 nil 时忽略，便于未注入总线的调用方
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if sub.giftCode != "" && sub.giftCode != ev.GiftCode {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+}
+
+// SubscriberCount 当前订阅数
+func (b *Bus) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +29,7 @@ func NewAccountHandler(accountService *service.AccountService, logger *zap.Logge
 func (h *AccountHandler) GetAllAccounts(c *gin.Context) {
 	accounts, err := h.accountService.GetAllAccounts()
 	if err != nil {
-		h.logger.Error("获取账号列表错误", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取账号列表错误", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取账号列表失败")
 		return
 	}
@@ -52,11 +53,11 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("📝 收到添加账号请求", zap.String("fid", fidStr))
+	requestLogger(c, h.logger).Info("📝 收到添加账号请求", zap.String("fid", fidStr))
 
-	result, err := h.accountService.CreateAccount(fidStr)
+	result, err := h.accountService.CreateAccount(c.Request.Context(), fidStr)
 	if err != nil {
-		h.logger.Error("添加账号失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("添加账号失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "添加账号失败")
 		return
 	}
@@ -81,7 +82,7 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	// 成功响应
+	// 成功响应（添加账号不能证明调用方拥有该账号，玩家自助token需通过 /api/me/login 昵称验证获取）
 	SuccessResponseWithMessage(c, result.Message, result.Data)
 }
 
@@ -95,11 +96,11 @@ func (h *AccountHandler) VerifyAccount(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🔍 收到手动验证账号请求", zap.Int("id", id))
+	requestLogger(c, h.logger).Info("🔍 收到手动验证账号请求", zap.Int("id", id))
 
-	result, err := h.accountService.VerifyAccount(id)
+	result, err := h.accountService.VerifyAccount(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("验证账号失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("验证账号失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "验证账号失败")
 		return
 	}
@@ -122,11 +123,11 @@ func (h *AccountHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🗑️ 收到删除账号请求", zap.Int("id", id))
+	requestLogger(c, h.logger).Info("🗑️ 收到删除账号请求", zap.Int("id", id))
 
 	result, err := h.accountService.DeleteAccount(id)
 	if err != nil {
-		h.logger.Error("删除账号失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("删除账号失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "删除账号失败")
 		return
 	}
@@ -156,11 +157,11 @@ func (h *AccountHandler) BulkDeleteAccounts(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🗑️ 收到批量删除账号请求", zap.Int("count", len(request.IDs)))
+	requestLogger(c, h.logger).Info("🗑️ 收到批量删除账号请求", zap.Int("count", len(request.IDs)))
 
 	result, err := h.accountService.BulkDeleteAccounts(request.IDs)
 	if err != nil {
-		h.logger.Error("批量删除账号失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("批量删除账号失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "批量删除账号失败")
 		return
 	}
@@ -176,11 +177,11 @@ func (h *AccountHandler) BulkDeleteAccounts(c *gin.Context) {
 // FixAllStats 修复所有兑换码统计（与Node版本对齐）
 // POST /api/accounts/fix-stats
 func (h *AccountHandler) FixAllStats(c *gin.Context) {
-	h.logger.Info("🔧 收到修复统计请求")
+	requestLogger(c, h.logger).Info("🔧 收到修复统计请求")
 
 	result, err := h.accountService.FixAllStats()
 	if err != nil {
-		h.logger.Error("修复统计失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("修复统计失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "修复统计失败")
 		return
 	}
@@ -194,7 +195,7 @@ func (h *AccountHandler) FixAllStats(c *gin.Context) {
 }
 
 // RegisterAccountRoutes 注册账号相关路由（带签名验证）
-func (h *AccountHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, signMiddleware gin.HandlerFunc) {
+func (h *AccountHandler) RegisterRoutes(router *gin.RouterGroup, adminRouter *AdminRouter, signMiddleware gin.HandlerFunc) {
 	accounts := router.Group("/accounts")
 	{
 		// 获取所有账号（无需认证）
@@ -206,12 +207,12 @@ func (h *AccountHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware
 		// 手动验证账号（无需认证）
 		accounts.POST("/:id/verify", h.VerifyAccount)
 
-		// 删除账号（需要管理员权限）
-		accounts.DELETE("/:id", authMiddleware, h.DeleteAccount)
-		// 批量删除账号（需要管理员权限）
-		accounts.DELETE("", authMiddleware, h.BulkDeleteAccounts)
+		// 删除账号（需要 operator 权限）
+		adminRouter.DELETE(accounts, "/:id", model.AdminRoleOperator, h.DeleteAccount)
+		// 批量删除账号（需要 operator 权限）
+		adminRouter.DELETE(accounts, "", model.AdminRoleOperator, h.BulkDeleteAccounts)
 
-		// 修复统计（需要管理员权限）
-		accounts.POST("/fix-stats", authMiddleware, h.FixAllStats)
+		// 修复统计（需要 operator 权限）
+		adminRouter.POST(accounts, "/fix-stats", model.AdminRoleOperator, h.FixAllStats)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -35,11 +36,11 @@ func (h *AdminHandler) VerifyPassword(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🔐 收到管理员密码验证请求")
+	requestLogger(c, h.logger).Info("🔐 收到管理员密码验证请求")
 
-	result, err := h.adminService.VerifyPassword(request.Password)
+	result, err := h.adminService.VerifyPassword(request.Password, clientInfo(c))
 	if err != nil {
-		h.logger.Error("密码验证失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("密码验证失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "密码验证失败")
 		return
 	}
@@ -73,7 +74,7 @@ func (h *AdminHandler) VerifyToken(c *gin.Context) {
 
 	result, err := h.adminService.VerifyToken(token)
 	if err != nil {
-		h.logger.Error("Token验证失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("Token验证失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "Token验证失败")
 		return
 	}
@@ -89,11 +90,11 @@ func (h *AdminHandler) VerifyToken(c *gin.Context) {
 // GetAllPasswords 获取所有管理员密码信息（与Node版本对齐）
 // GET /api/admin/passwords
 func (h *AdminHandler) GetAllPasswords(c *gin.Context) {
-	h.logger.Info("📋 收到获取密码列表请求")
+	requestLogger(c, h.logger).Info("📋 收到获取密码列表请求")
 
 	result, err := h.adminService.GetAllPasswords()
 	if err != nil {
-		h.logger.Error("获取密码列表失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取密码列表失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取密码列表失败")
 		return
 	}
@@ -119,11 +120,11 @@ func (h *AdminHandler) CreatePassword(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🔑 收到创建密码请求", zap.String("description", request.Description))
+	requestLogger(c, h.logger).Info("🔑 收到创建密码请求", zap.String("description", request.Description))
 
 	result, err := h.adminService.CreatePassword(request.Password, request.Description)
 	if err != nil {
-		h.logger.Error("创建密码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("创建密码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "创建密码失败")
 		return
 	}
@@ -146,11 +147,11 @@ func (h *AdminHandler) DeletePassword(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🗑️ 收到删除密码请求", zap.Int("id", id))
+	requestLogger(c, h.logger).Info("🗑️ 收到删除密码请求", zap.Int("id", id))
 
 	result, err := h.adminService.DeletePassword(id)
 	if err != nil {
-		h.logger.Error("删除密码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("删除密码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
 		return
 	}
@@ -182,13 +183,13 @@ func (h *AdminHandler) UpdatePasswordStatus(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🔄 收到更新密码状态请求",
+	requestLogger(c, h.logger).Info("🔄 收到更新密码状态请求",
 		zap.Int("id", id),
 		zap.Bool("is_active", request.IsActive))
 
 	result, err := h.adminService.UpdatePasswordStatus(id, request.IsActive)
 	if err != nil {
-		h.logger.Error("更新密码状态失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("更新密码状态失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "更新密码状态失败")
 		return
 	}
@@ -226,11 +227,11 @@ func (h *AdminHandler) UpdateDefaultPassword(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🔑 收到更新默认密码请求")
+	requestLogger(c, h.logger).Info("🔑 收到更新默认密码请求")
 
 	result, err := h.adminService.UpdateDefaultPassword(request.Password, request.Description)
 	if err != nil {
-		h.logger.Error("更新默认密码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("更新默认密码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
 		return
 	}
@@ -243,18 +244,274 @@ func (h *AdminHandler) UpdateDefaultPassword(c *gin.Context) {
 	SuccessResponseWithMessage(c, result.Message, nil)
 }
 
+// Login 管理员账号登录
+// POST /api/admin/login
+func (h *AdminHandler) Login(c *gin.Context) {
+	var request struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "用户名和密码不能为空")
+		return
+	}
+
+	requestLogger(c, h.logger).Info("🔐 收到管理员登录请求", zap.String("username", request.Username))
+
+	result, err := h.adminService.Login(request.Username, request.Password, clientInfo(c))
+	if err != nil {
+		requestLogger(c, h.logger).Error("管理员登录失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "登录失败")
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusUnauthorized, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Me 获取当前登录的管理员身份
+// GET /api/admin/me
+func (h *AdminHandler) Me(c *gin.Context) {
+	principal, _ := c.Get("admin_principal")
+	SuccessResponse(c, principal)
+}
+
+// Logout 退出登录（撤销当前token）
+// POST /api/admin/logout
+func (h *AdminHandler) Logout(c *gin.Context) {
+	result, err := h.adminService.RevokeToken(c.GetString("token"))
+	if err != nil {
+		requestLogger(c, h.logger).Error("退出登录失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "退出登录失败")
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, "已退出登录", nil)
+}
+
+// GetSessions 获取当前管理员的有效会话
+// GET /api/admin/sessions
+func (h *AdminHandler) GetSessions(c *gin.Context) {
+	result, err := h.adminService.ListSessions(currentPrincipal(c))
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取会话列表失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "获取会话列表失败")
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+// RevokeSession 撤销指定会话
+// DELETE /api/admin/sessions/:id
+func (h *AdminHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的会话ID")
+		return
+	}
+
+	result, err := h.adminService.RevokeSession(currentPrincipal(c), id)
+	if err != nil {
+		requestLogger(c, h.logger).Error("撤销会话失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "撤销会话失败")
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusNotFound, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, nil)
+}
+
+// RevokeAllSessions 撤销当前管理员的全部会话
+// DELETE /api/admin/sessions
+func (h *AdminHandler) RevokeAllSessions(c *gin.Context) {
+	result, err := h.adminService.RevokeAllSessions(currentPrincipal(c))
+	if err != nil {
+		requestLogger(c, h.logger).Error("撤销全部会话失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "撤销会话失败")
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// GetAllUsers 获取管理员账号列表
+// GET /api/admin/users
+func (h *AdminHandler) GetAllUsers(c *gin.Context) {
+	result, err := h.adminService.ListUsers()
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取管理员列表失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "获取管理员列表失败")
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+// CreateUser 创建管理员账号
+// POST /api/admin/users
+func (h *AdminHandler) CreateUser(c *gin.Context) {
+	var request struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		Role     string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "用户名、密码和角色不能为空")
+		return
+	}
+
+	requestLogger(c, h.logger).Info("👤 收到创建管理员请求",
+		zap.String("username", request.Username),
+		zap.String("role", request.Role),
+		zap.String("operator", c.GetString("admin_username")))
+
+	result, err := h.adminService.CreateUser(request.Username, request.Password, request.Role)
+	if err != nil {
+		requestLogger(c, h.logger).Error("创建管理员失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "创建管理员失败")
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// UpdateUser 更新管理员角色/状态/密码
+// PUT /api/admin/users/:id
+func (h *AdminHandler) UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的管理员ID")
+		return
+	}
+
+	var request struct {
+		Role     string `json:"role" binding:"required"`
+		IsActive *bool  `json:"is_active"`
+		Password string `json:"password"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "请求参数错误")
+		return
+	}
+
+	isActive := true
+	if request.IsActive != nil {
+		isActive = *request.IsActive
+	}
+
+	requestLogger(c, h.logger).Info("🔄 收到更新管理员请求",
+		zap.Int("id", id),
+		zap.String("role", request.Role),
+		zap.Bool("is_active", isActive),
+		zap.String("operator", c.GetString("admin_username")))
+
+	result, err := h.adminService.UpdateUser(id, request.Role, isActive, request.Password)
+	if err != nil {
+		requestLogger(c, h.logger).Error("更新管理员失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "更新管理员失败")
+		return
+	}
+
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		if result.Error == "管理员不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, nil)
+}
+
+// DeleteUser 删除管理员账号
+// DELETE /api/admin/users/:id
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的管理员ID")
+		return
+	}
+
+	requestLogger(c, h.logger).Info("🗑️ 收到删除管理员请求", zap.Int("id", id), zap.String("operator", c.GetString("admin_username")))
+
+	result, err := h.adminService.DeleteUser(id)
+	if err != nil {
+		requestLogger(c, h.logger).Error("删除管理员失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "删除管理员失败")
+		return
+	}
+
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		if result.Error == "管理员不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, nil)
+}
+
 // RegisterAdminRoutes 注册管理员相关路由（与Node版本对齐）
-func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware, loginGuardMiddleware gin.HandlerFunc) {
 	admin := router.Group("/admin")
 	{
-		// 验证密码（无需认证）
-		admin.POST("/verify", h.VerifyPassword)
+		// 验证密码（无需认证，旧版共享密码登录）
+		admin.POST("/verify", loginGuardMiddleware, h.VerifyPassword)
+
+		// 管理员账号登录（无需认证）
+		admin.POST("/login", loginGuardMiddleware, h.Login)
 
 		// 验证Token（无需认证，但需要在头部提供token）
 		admin.GET("/verify-token", h.VerifyToken)
 
-		// 以下所有接口都需要管理员权限
-		passwords := admin.Group("/passwords", authMiddleware)
+		// 当前登录身份（任意角色）
+		admin.GET("/me", authMiddleware, h.Me)
+
+		// 退出登录与会话管理（任意角色，仅能管理自己的会话）
+		admin.POST("/logout", authMiddleware, h.Logout)
+		sessions := admin.Group("/sessions", authMiddleware)
+		{
+			sessions.GET("", h.GetSessions)
+			sessions.DELETE("", h.RevokeAllSessions)
+			sessions.DELETE("/:id", h.RevokeSession)
+		}
+
+		// 管理员账号管理（需要 owner 权限）
+		users := admin.Group("/users", authMiddleware, RequireRole(model.AdminRoleOwner))
+		{
+			users.GET("", h.GetAllUsers)
+			users.POST("", h.CreateUser)
+			users.PUT("/:id", h.UpdateUser)
+			users.DELETE("/:id", h.DeleteUser)
+		}
+
+		// 共享密码管理（需要 owner 权限）
+		passwords := admin.Group("/passwords", authMiddleware, RequireRole(model.AdminRoleOwner))
 		{
 			// 获取所有密码信息
 			passwords.GET("", h.GetAllPasswords)
@@ -272,8 +529,8 @@ func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gi
 			passwords.PUT("/:id", h.UpdateDefaultPassword)
 		}
 
-		// 统计修复接口（需要管理员权限）
-		stats := admin.Group("/stats", authMiddleware)
+		// 统计修复接口（需要 operator 权限）
+		stats := admin.Group("/stats", authMiddleware, RequireRole(model.AdminRoleOperator))
 		{
 			// 修复所有兑换码统计
 			stats.POST("/fix", func(c *gin.Context) {
@@ -290,22 +547,22 @@ func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gi
 			})
 		}
 
-		// 刷新所有活跃账号（需要管理员权限）
-		admin.POST("/accounts/refresh", authMiddleware, func(c *gin.Context) {
+		// 刷新所有活跃账号（需要 operator 权限）
+		admin.POST("/accounts/refresh", authMiddleware, RequireRole(model.AdminRoleOperator), func(c *gin.Context) {
 			// 立即触发刷新（批次：每批5个，间隔3s）
 			go h.adminService.CronService.RefreshAllAccounts()
 			SuccessResponseWithMessage(c, "已触发刷新任务", nil)
 		})
 
-		// 手动触发RSS抓取（需要管理员权限）
-		admin.POST("/rss/fetch", authMiddleware, func(c *gin.Context) {
+		// 手动触发RSS抓取（需要 operator 权限）
+		admin.POST("/rss/fetch", authMiddleware, RequireRole(model.AdminRoleOperator), func(c *gin.Context) {
 			// 异步：先触发更新，等待10秒，再抓取；接口立即返回
 			go h.adminService.CronService.FetchAndProcessRSSManual()
 			SuccessResponseWithMessage(c, "已触发RSS抓取任务（将先更新源，等待约10秒后开始抓取）", nil)
 		})
 
 		// 获取最近已处理文章（只读面板数据，默认50条）
-		admin.GET("/rss/processed", authMiddleware, func(c *gin.Context) {
+		admin.GET("/rss/processed", authMiddleware, RequireRole(model.AdminRoleViewer), func(c *gin.Context) {
 			// 若未提供limit或<=0，则返回全部
 			limit := 0
 			if v := c.Query("limit"); v != "" {
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"wjdr-backend-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRouter 注册 /api/admin 之外需要管理员角色的路由：统一挂载鉴权与角色校验，
+// 同时登记路由，CORSMiddleware 据此对这些路由（含预检请求）套用管理接口CORS策略。
+// 路由须在服务启动前注册完毕，之后只读
+type AdminRouter struct {
+	auth   gin.HandlerFunc
+	cors   config.CORSPolicy
+	routes []string // "方法 路由模板"
+}
+
+func NewAdminRouter(authMiddleware gin.HandlerFunc, corsPolicy config.CORSPolicy) *AdminRouter {
+	return &AdminRouter{auth: authMiddleware, cors: corsPolicy}
+}
+
+// Handle 在 group 下注册需要 role 及以上角色的路由
+func (r *AdminRouter) Handle(group *gin.RouterGroup, method, relativePath, role string, handler gin.HandlerFunc) {
+	r.routes = append(r.routes, method+" "+path.Join(group.BasePath(), relativePath))
+	group.Handle(method, relativePath, r.auth, RequireRole(role), handler)
+}
+
+func (r *AdminRouter) GET(group *gin.RouterGroup, relativePath, role string, handler gin.HandlerFunc) {
+	r.Handle(group, http.MethodGet, relativePath, role, handler)
+}
+
+func (r *AdminRouter) POST(group *gin.RouterGroup, relativePath, role string, handler gin.HandlerFunc) {
+	r.Handle(group, http.MethodPost, relativePath, role, handler)
+}
+
+func (r *AdminRouter) DELETE(group *gin.RouterGroup, relativePath, role string, handler gin.HandlerFunc) {
+	r.Handle(group, http.MethodDelete, relativePath, role, handler)
+}
+
+// corsPolicy 请求命中已登记的路由时返回管理接口CORS策略
+func (r *AdminRouter) corsPolicy(method, requestPath string) (config.CORSPolicy, bool) {
+	for _, key := range r.routes {
+		route := strings.TrimPrefix(key, method+" ")
+		if route != key && matchRouteTemplate(route, requestPath) {
+			return r.cors, true
+		}
+	}
+	return config.CORSPolicy{}, false
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wjdr-backend-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddlewareUsesAdminPolicyForAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defaultPolicy := config.CORSPolicy{AllowOrigins: []string{"https://www.example.com"}, AllowMethods: []string{"GET", "DELETE"}}
+	adminPolicy := config.CORSPolicy{AllowOrigins: []string{"https://admin.example.com"}, AllowMethods: []string{"GET", "DELETE"}}
+	noop := func(c *gin.Context) { c.Next() }
+	adminRouter := NewAdminRouter(noop, adminPolicy)
+
+	router := gin.New()
+	router.Use(CORSMiddleware(defaultPolicy, nil, adminRouter))
+	(&AccountHandler{}).RegisterRoutes(router.Group("/api"), adminRouter, noop)
+
+	preflight := func(method, path, origin string) string {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := preflight("DELETE", "/api/accounts/7", "https://admin.example.com"); got != "https://admin.example.com" {
+		t.Errorf("admin route, admin origin: Allow-Origin = %q", got)
+	}
+	if got := preflight("DELETE", "/api/accounts/7", "https://www.example.com"); got != "" {
+		t.Errorf("admin route, public origin: Allow-Origin = %q, want none", got)
+	}
+	if got := preflight("GET", "/api/accounts", "https://www.example.com"); got != "https://www.example.com" {
+		t.Errorf("public route, public origin: Allow-Origin = %q", got)
+	}
+	if got := preflight("GET", "/api/accounts", "https://admin.example.com"); got != "" {
+		t.Errorf("public route, admin origin: Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler 自动化客户端API Key管理
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	logger        *zap.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// apiKeyRequest 创建/更新API Key的请求体
+type apiKeyRequest struct {
+	Name       string     `json:"name" binding:"required"`
+	Scopes     []string   `json:"scopes" binding:"required"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"` // RFC3339，为空表示永不过期
+	IsActive   *bool      `json:"is_active"`
+}
+
+func (r *apiKeyRequest) input() service.APIKeyInput {
+	isActive := true
+	if r.IsActive != nil {
+		isActive = *r.IsActive
+	}
+	return service.APIKeyInput{
+		Name:       r.Name,
+		Scopes:     r.Scopes,
+		AllowedIPs: r.AllowedIPs,
+		ExpiresAt:  r.ExpiresAt,
+		IsActive:   isActive,
+	}
+}
+
+// List 获取API Key列表
+// GET /api/admin/api-keys
+func (h *APIKeyHandler) List(c *gin.Context) {
+	result, err := h.apiKeyService.ListKeys()
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取API Key列表失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+// Scopes 获取可分配的权限范围
+// GET /api/admin/api-keys/scopes
+func (h *APIKeyHandler) Scopes(c *gin.Context) {
+	SuccessResponse(c, model.APIKeyScopes)
+}
+
+// Create 创建API Key
+// POST /api/admin/api-keys
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var request apiKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "名称和权限范围不能为空")
+		return
+	}
+
+	result, err := h.apiKeyService.CreateKey(request.input(), currentPrincipal(c).UserID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("创建API Key失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Update 更新API Key（整体替换名称、权限、白名单、过期时间与启用状态）
+// PUT /api/admin/api-keys/:id
+func (h *APIKeyHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的API Key ID")
+		return
+	}
+
+	var request apiKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "名称和权限范围不能为空")
+		return
+	}
+
+	result, err := h.apiKeyService.UpdateKey(id, request.input())
+	if err != nil {
+		requestLogger(c, h.logger).Error("更新API Key失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		if result.Error == "API Key不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Delete 删除API Key
+// DELETE /api/admin/api-keys/:id
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的API Key ID")
+		return
+	}
+
+	result, err := h.apiKeyService.DeleteKey(id)
+	if err != nil {
+		requestLogger(c, h.logger).Error("删除API Key失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusNotFound, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, nil)
+}
+
+func (h *APIKeyHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// API Key 管理需要 owner 权限
+	group := router.Group("/admin/api-keys", authMiddleware, RequireRole(model.AdminRoleOwner))
+	{
+		group.GET("", h.List)
+		group.GET("/scopes", h.Scopes)
+		group.POST("", h.Create)
+		group.PUT("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+	}
+}
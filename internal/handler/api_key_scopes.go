@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"wjdr-backend-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyRouteScopes API Key 可访问的路由及所需权限范围，未列出的路由一律拒绝
+var apiKeyRouteScopes = map[string]string{
+	"POST /api/redeem":                 model.ScopeRedeemSubmit,
+	"POST /api/redeem/retry":           model.ScopeRedeemManage,
+	"POST /api/redeem/:id/retry":       model.ScopeRedeemManage,
+	"DELETE /api/redeem/:id":           model.ScopeRedeemManage,
+	"DELETE /api/redeem":               model.ScopeRedeemManage,
+	"GET /api/redeem/events":           model.ScopeRedeemRead,
+	"GET /api/redeem/:id/events":       model.ScopeRedeemRead,
+	"DELETE /api/accounts/:id":         model.ScopeAccountsManage,
+	"DELETE /api/accounts":             model.ScopeAccountsManage,
+	"POST /api/admin/accounts/refresh": model.ScopeAccountsManage,
+	"POST /api/accounts/fix-stats":     model.ScopeStatsFix,
+	"POST /api/admin/stats/fix":        model.ScopeStatsFix,
+	"GET /api/admin/rss/processed":     model.ScopeRSSRead,
+	"POST /api/admin/rss/fetch":        model.ScopeRSSFetch,
+}
+
+// apiKeyRouteScope 当前请求路由所需的权限范围，ok 为 false 表示 API Key 不可访问该路由
+func apiKeyRouteScope(c *gin.Context) (scope string, ok bool) {
+	scope, ok = apiKeyRouteScopes[c.Request.Method+" "+c.FullPath()]
+	return scope, ok
+}
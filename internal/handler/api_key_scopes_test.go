@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyRouteScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// 以真实路由表注册，鉴权中间件只回写解析出的权限范围
+	scopeProbe := func(c *gin.Context) {
+		scope, ok := apiKeyRouteScope(c)
+		if !ok {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Header("X-Scope", scope)
+		c.AbortWithStatus(http.StatusOK)
+	}
+	noop := func(c *gin.Context) { c.Next() }
+	api := router.Group("/api")
+	adminRouter := NewAdminRouter(scopeProbe, config.CORSPolicy{})
+	(&AccountHandler{}).RegisterRoutes(api, adminRouter, noop)
+	(&AdminHandler{}).RegisterRoutes(api, scopeProbe, noop)
+	(&RedeemHandler{}).RegisterRoutes(api, adminRouter)
+	(&EventsHandler{}).RegisterRoutes(api, adminRouter)
+
+	cases := []struct {
+		method, path, route, scope string
+	}{
+		{"POST", "/api/redeem", "POST /api/redeem", model.ScopeRedeemSubmit},
+		{"POST", "/api/redeem/retry", "POST /api/redeem/retry", model.ScopeRedeemManage},
+		{"POST", "/api/redeem/7/retry", "POST /api/redeem/:id/retry", model.ScopeRedeemManage},
+		{"DELETE", "/api/redeem/7", "DELETE /api/redeem/:id", model.ScopeRedeemManage},
+		{"DELETE", "/api/redeem", "DELETE /api/redeem", model.ScopeRedeemManage},
+		{"GET", "/api/redeem/events", "GET /api/redeem/events", model.ScopeRedeemRead},
+		{"GET", "/api/redeem/7/events", "GET /api/redeem/:id/events", model.ScopeRedeemRead},
+		{"DELETE", "/api/accounts/7", "DELETE /api/accounts/:id", model.ScopeAccountsManage},
+		{"DELETE", "/api/accounts", "DELETE /api/accounts", model.ScopeAccountsManage},
+		{"POST", "/api/admin/accounts/refresh", "POST /api/admin/accounts/refresh", model.ScopeAccountsManage},
+		{"POST", "/api/accounts/fix-stats", "POST /api/accounts/fix-stats", model.ScopeStatsFix},
+		{"POST", "/api/admin/stats/fix", "POST /api/admin/stats/fix", model.ScopeStatsFix},
+		{"GET", "/api/admin/rss/processed", "GET /api/admin/rss/processed", model.ScopeRSSRead},
+		{"POST", "/api/admin/rss/fetch", "POST /api/admin/rss/fetch", model.ScopeRSSFetch},
+	}
+
+	covered := make(map[string]bool)
+	usedScopes := make(map[string]bool)
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s: status = %d, want 200 (route not registered or not mapped)", tc.method, tc.path, w.Code)
+			continue
+		}
+		if got := w.Header().Get("X-Scope"); got != tc.scope {
+			t.Errorf("%s %s: scope = %q, want %q", tc.method, tc.path, got, tc.scope)
+		}
+		covered[tc.route] = true
+		usedScopes[tc.scope] = true
+	}
+
+	for route := range apiKeyRouteScopes {
+		if !covered[route] {
+			t.Errorf("route %q has a scope but no test case", route)
+		}
+	}
+	for _, scope := range model.APIKeyScopes {
+		if !usedScopes[scope] {
+			t.Errorf("scope %q grants no route", scope)
+		}
+	}
+}
+
+func TestAPIKeyRouteScopesRejectUnlistedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/admin/users", func(c *gin.Context) {
+		if _, ok := apiKeyRouteScope(c); ok {
+			t.Errorf("unlisted route resolved to a scope")
+		}
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/admin/users", nil))
+}
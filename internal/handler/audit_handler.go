@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditHandler 审计记录查询
+type AuditHandler struct {
+	auditService *service.AuditService
+	logger       *zap.Logger
+}
+
+func NewAuditHandler(auditService *service.AuditService, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ListEvents 查询审计记录
+// GET /api/admin/audit-events?actor=&action=&result=&target=&since=&until=&limit=
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	filter := model.AuditEventFilter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Result: c.Query("result"),
+		Target: c.Query("target"),
+	}
+
+	for key, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			ErrorResponse(c, http.StatusBadRequest, false, "时间格式错误，请使用RFC3339格式: "+key)
+			return
+		}
+		*dst = &t
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			ErrorResponse(c, http.StatusBadRequest, false, "无效的limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	result, err := h.auditService.ListEvents(filter)
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取审计记录失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+func (h *AuditHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// 审计记录仅 owner 可查看
+	router.GET("/admin/audit-events", authMiddleware, RequireRole(model.AdminRoleOwner), h.ListEvents)
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	auditMaxBodyRead   = 64 << 10 // 审计读取请求体上限
+	auditMaxSummaryLen = 1000     // 请求体摘要最大长度
+	auditMaxTargetLen  = 1024     // target_ids 列长度
+)
+
+// auditActions 路由到审计动作名的映射，未列出的路由以 "METHOD 路径" 记录
+var auditActions = map[string]string{
+	"POST /api/accounts/:id/verify":       "account.verify",
+	"DELETE /api/accounts/:id":            "account.delete",
+	"DELETE /api/accounts":                "account.bulk_delete",
+	"POST /api/accounts/fix-stats":        "stats.fix",
+	"POST /api/redeem":                    "redeem_code.submit",
+	"DELETE /api/redeem/:id":              "redeem_code.delete",
+	"DELETE /api/redeem":                  "redeem_code.bulk_delete",
+	"POST /api/redeem/retry":              "redeem_code.retry",
+	"POST /api/redeem/:id/retry":          "redeem_code.retry",
+	"POST /api/admin/ocr-keys":            "ocr_key.create",
+	"PUT /api/admin/ocr-keys/:id":         "ocr_key.update",
+	"DELETE /api/admin/ocr-keys/:id":      "ocr_key.delete",
+	"POST /api/admin/rss/fetch":           "rss.fetch",
+	"POST /api/admin/accounts/refresh":    "account.refresh_all",
+	"POST /api/admin/stats/fix":           "stats.fix",
+	"POST /api/admin/users":               "admin_user.create",
+	"PUT /api/admin/users/:id":            "admin_user.update",
+	"POST /api/admin/api-keys":            "api_key.create",
+	"PUT /api/admin/api-keys/:id":         "api_key.update",
+	"DELETE /api/admin/api-keys/:id":      "api_key.delete",
+	"POST /api/admin/webhooks":            "webhook.create",
+	"PUT /api/admin/webhooks/:id":         "webhook.update",
+	"DELETE /api/admin/webhooks/:id":      "webhook.delete",
+	"POST /api/admin/webhooks/:id/test":   "webhook.test",
+	"POST /api/admin/logout":              "session.logout",
+	"DELETE /api/admin/sessions":          "session.revoke_all",
+	"DELETE /api/admin/sessions/:id":      "session.revoke",
+	"DELETE /api/admin/users/:id":         "admin_user.delete",
+	"POST /api/admin/passwords":           "admin_password.create",
+	"DELETE /api/admin/passwords/:id":     "admin_password.delete",
+	"PUT /api/admin/passwords/:id":        "admin_password.update",
+	"PUT /api/admin/passwords/:id/status": "admin_password.update_status",
+}
+
+// auditSensitiveKeys 请求体摘要中需要脱敏的字段
+var auditSensitiveKeys = map[string]bool{
+	"password":   true,
+	"secret":     true,
+	"secretkey":  true,
+	"secret_key": true,
+	"apikey":     true,
+	"api_key":    true,
+	"token":      true,
+}
+
+// auditResponseWriter 记录失败响应的错误信息
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.body.Len() < 2048 {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// AuditMiddleware 审计中间件：记录管理员身份发起的写操作（需注册在路由组上，AuthMiddleware 解析身份后生效）
+func AuditMiddleware(auditService *service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		// 预读请求体前 auditMaxBodyRead 字节，并与未读部分拼接后交还给后续handler（不截断完整请求体）
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, auditMaxBodyRead))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		value, exists := c.Get("admin_principal")
+		principal, ok := value.(*model.AdminPrincipal)
+		if !exists || !ok {
+			return // 非管理员请求或未通过认证
+		}
+
+		path := c.FullPath()
+		action, known := auditActions[method+" "+path]
+		if !known {
+			action = method + " " + path
+		}
+
+		event := &model.AuditEvent{
+			ActorUserID:    principal.UserID,
+			ActorName:      principal.Username,
+			ActorRole:      principal.Role,
+			Action:         action,
+			Method:         method,
+			Path:           c.Request.URL.Path,
+			TargetIDs:      auditTargetIDs(c, body),
+			RequestSummary: auditSummarizeBody(body),
+			StatusCode:     writer.Status(),
+			Result:         "success",
+			IP:             c.ClientIP(),
+			UserAgent:      truncateString(c.Request.UserAgent(), 512),
+		}
+		if principal.TokenID > 0 {
+			tokenID := principal.TokenID
+			event.ActorTokenID = &tokenID
+		}
+		if writer.Status() >= http.StatusBadRequest {
+			event.Result = "failed"
+			var resp struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(writer.body.Bytes(), &resp) == nil && resp.Error != "" {
+				event.ErrorMessage = &resp.Error
+			}
+		}
+
+		auditService.Record(event)
+	}
+}
+
+// auditTargetIDs 提取操作目标：路径参数 id 与请求体中的 ids 数组
+func auditTargetIDs(c *gin.Context, body []byte) string {
+	var ids []string
+	if id := c.Param("id"); id != "" {
+		ids = append(ids, id)
+	}
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &req) == nil {
+		for _, id := range req.IDs {
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+	joined := strings.Join(ids, ",")
+	if len(joined) > auditMaxTargetLen {
+		// 超出列长度时保留完整的前若干个ID
+		joined = joined[:auditMaxTargetLen-4]
+		joined = joined[:strings.LastIndex(joined, ",")+1] + "..."
+	}
+	return joined
+}
+
+// auditSummarizeBody 生成脱敏并截断的请求体摘要
+func auditSummarizeBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "[" + strconv.Itoa(len(body)) + " bytes]"
+	}
+	for k := range payload {
+		if auditSensitiveKeys[strings.ToLower(k)] {
+			payload[k] = "***"
+		}
+	}
+
+	summary, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	if len(summary) > auditMaxSummaryLen {
+		return truncateString(string(summary), auditMaxSummaryLen) + "..."
+	}
+	return string(summary)
+}
+
+// truncateString 按字节截断并丢弃被截断的半个UTF-8字符
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"wjdr-backend-go/internal/events"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EventsHandler 兑换进度实时推送（Server-Sent Events）
+type EventsHandler struct {
+	bus           *events.Bus
+	redeemService *service.RedeemService
+	heartbeat     time.Duration
+	logger        *zap.Logger
+	// done 服务关闭时关闭，结束所有进行中的事件流（否则 http.Server.Shutdown 会一直等待长连接）
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func NewEventsHandler(bus *events.Bus, redeemService *service.RedeemService, heartbeat time.Duration, logger *zap.Logger) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &EventsHandler{
+		bus:           bus,
+		redeemService: redeemService,
+		heartbeat:     heartbeat,
+		logger:        logger,
+		done:          make(chan struct{}),
+	}
+}
+
+// Shutdown 结束所有事件流（注册到 http.Server.RegisterOnShutdown）
+func (h *EventsHandler) Shutdown() {
+	h.doneOnce.Do(func() { close(h.done) })
+}
+
+// StreamAll 订阅全部兑换进度
+// GET /api/redeem/events
+func (h *EventsHandler) StreamAll(c *gin.Context) {
+	h.stream(c, "")
+}
+
+// StreamRedeemCode 订阅单个兑换码的进度
+// GET /api/redeem/:id/events
+func (h *EventsHandler) StreamRedeemCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的兑换码ID")
+		return
+	}
+
+	result, err := h.redeemService.GetRedeemCodeDetails(id)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, false, "获取兑换码失败")
+		return
+	}
+	if !result.Success {
+		ErrorResponse(c, http.StatusNotFound, false, result.Error)
+		return
+	}
+
+	h.stream(c, result.Data.(*model.RedeemCode).Code)
+}
+
+// stream 持续推送事件直到客户端断开；事件名为事件类型，数据为 JSON，空闲时发送心跳
+func (h *EventsHandler) stream(c *gin.Context, giftCode string) {
+	sub := h.bus.Subscribe(giftCode, 64)
+	if sub == nil {
+		ErrorResponse(c, http.StatusServiceUnavailable, false, "实时订阅数已达上限，请稍后重试")
+		return
+	}
+	defer sub.Close()
+
+	// 长连接不受服务器写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestLogger(c, h.logger).Debug("取消SSE写超时失败", zap.Error(err))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-h.done:
+			return false
+		case ev, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(ev.Type, ev)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// RegisterRoutes 注册事件流路由（包含 FID 与验证码尝试，且占用有限的订阅名额，需要 viewer 权限）
+func (h *EventsHandler) RegisterRoutes(router *gin.RouterGroup, adminRouter *AdminRouter) {
+	adminRouter.GET(router, "/redeem/events", model.AdminRoleViewer, h.StreamAll)
+	adminRouter.GET(router, "/redeem/:id/events", model.AdminRoleViewer, h.StreamRedeemCode)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"wjdr-backend-go/internal/buildinfo"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HealthHandler 存活、就绪与依赖健康检查
+type HealthHandler struct {
+	healthService *service.HealthService
+	logger        *zap.Logger
+}
+
+func NewHealthHandler(healthService *service.HealthService, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		logger:        logger,
+	}
+}
+
+// Liveness 存活检查：进程可响应即返回 ok，不检查依赖
+// GET /health, GET /api/health
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	info := buildinfo.Get()
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ok",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"service":   "wjdr-backend-go",
+		"version":   info.Version,
+		"commit":    info.Commit,
+	})
+}
+
+// Readiness 就绪检查：关键依赖（数据库、Worker）不可用时返回503，仅输出各项状态
+// GET /readyz
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+
+	checks := make(map[string]string, len(report.Checks))
+	for name, check := range report.Checks {
+		checks[name] = check.Status
+	}
+
+	status := http.StatusOK
+	if report.Status == model.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"status": report.Status,
+		"checks": checks,
+	})
+}
+
+// Details 依赖健康详情（含版本、积压时长、可用Key数、最近游戏接口调用等）
+// GET /api/admin/health
+func (h *HealthHandler) Details(c *gin.Context) {
+	SuccessResponse(c, h.healthService.Check(c.Request.Context()))
+}
+
+// RegisterRoutes 注册 /api 下的健康检查路由（根路由的 /health、/readyz 在 main 中注册）
+func (h *HealthHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/health", h.Liveness)
+	router.GET("/admin/health", authMiddleware, RequireRole(model.AdminRoleViewer), h.Details)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LoginGuardOptions 登录防爆破参数
+type LoginGuardOptions struct {
+	MaxAttempts     int           // 单IP连续失败多少次后开始锁定
+	BaseLockout     time.Duration // 首次锁定时长，之后每次失败翻倍
+	MaxLockout      time.Duration // 锁定时长上限，超过该时长未再失败则清零计数
+	GlobalMaxPerMin int           // 全局每分钟失败次数上限，超过后本分钟内失败过的IP暂停登录至本分钟结束（未失败的IP不受影响）；<=0 不限制
+}
+
+// LoginGuard 按IP与全局统计登录失败次数，连续失败后指数退避锁定；全局失败过多时仅暂停近期失败过的IP
+type LoginGuard struct {
+	mu        sync.Mutex
+	opts      LoginGuardOptions
+	attempts  map[string]*loginAttempts
+	lastSweep time.Time
+
+	globalWindow   time.Time
+	globalFailures int
+}
+
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginGuard 创建登录防护；MaxAttempts<=0 时不做单IP锁定
+func NewLoginGuard(opts LoginGuardOptions) *LoginGuard {
+	if opts.BaseLockout <= 0 {
+		opts.BaseLockout = time.Minute
+	}
+	if opts.MaxLockout < opts.BaseLockout {
+		opts.MaxLockout = opts.BaseLockout
+	}
+	now := time.Now()
+	return &LoginGuard{
+		opts:         opts,
+		attempts:     make(map[string]*loginAttempts),
+		lastSweep:    now,
+		globalWindow: now,
+	}
+}
+
+// RetryAfter 返回该IP还需等待的时间，0 表示可以尝试登录
+func (g *LoginGuard) RetryAfter(ip string) time.Duration {
+	if g == nil {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	if a, ok := g.attempts[ip]; ok && now.Before(a.lockedUntil) {
+		wait = a.lockedUntil.Sub(now)
+	}
+	// 全局失败过多时只暂停本分钟内失败过的IP，避免攻击者持续提交错误密码把所有管理员挡在外面
+	if a, ok := g.attempts[ip]; ok && g.opts.GlobalMaxPerMin > 0 && g.globalFailures >= g.opts.GlobalMaxPerMin &&
+		!a.lastFailure.Before(g.globalWindow) {
+		if end := g.globalWindow.Add(time.Minute); now.Before(end) && end.Sub(now) > wait {
+			wait = end.Sub(now)
+		}
+	}
+	return wait
+}
+
+// Fail 记录一次登录失败
+func (g *LoginGuard) Fail(ip string) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.sweep(now)
+
+	if now.Sub(g.globalWindow) >= time.Minute {
+		g.globalWindow = now
+		g.globalFailures = 0
+	}
+	g.globalFailures++
+
+	a, ok := g.attempts[ip]
+	if !ok || now.Sub(a.lastFailure) > g.opts.MaxLockout {
+		a = &loginAttempts{}
+		g.attempts[ip] = a
+	}
+	a.failures++
+	a.lastFailure = now
+
+	if g.opts.MaxAttempts <= 0 {
+		return
+	}
+	if over := a.failures - g.opts.MaxAttempts; over >= 0 {
+		lockout := g.opts.MaxLockout
+		if over < 32 {
+			if d := g.opts.BaseLockout << over; d > 0 && d < lockout {
+				lockout = d
+			}
+		}
+		a.lockedUntil = now.Add(lockout)
+	}
+}
+
+// Succeed 登录成功后清零该IP的失败计数
+func (g *LoginGuard) Succeed(ip string) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.attempts, ip)
+}
+
+// sweep 清理早已解锁且长时间未失败的IP，避免map无限增长
+func (g *LoginGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < 10*time.Minute {
+		return
+	}
+	for ip, a := range g.attempts {
+		if now.After(a.lockedUntil) && now.Sub(a.lastFailure) > g.opts.MaxLockout {
+			delete(g.attempts, ip)
+		}
+	}
+	g.lastSweep = now
+}
+
+// LoginGuardMiddleware 登录接口防爆破：锁定期间直接返回429，按响应状态记录成功/失败
+func LoginGuardMiddleware(guard *LoginGuard, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 仅在请求来自 TRUSTED_PROXIES 时采信 X-Forwarded-For，否则取连接地址，伪造转发头无法换IP绕过锁定
+		ip := c.ClientIP()
+
+		if wait := guard.RetryAfter(ip); wait > 0 {
+			seconds := int(wait.Seconds()) + 1
+			requestLogger(c, logger).Warn("🚫 登录尝试过于频繁，已拒绝", zap.String("ip", ip), zap.Int("retry_after", seconds))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   "登录失败次数过多，请 " + strconv.Itoa(seconds) + " 秒后重试",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized:
+			guard.Fail(ip)
+		case http.StatusOK:
+			guard.Succeed(ip)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestLoginGuardGlobalLimitSparesCleanIPs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	guard := NewLoginGuard(LoginGuardOptions{MaxAttempts: 100, BaseLockout: time.Minute, GlobalMaxPerMin: 3})
+	router := gin.New()
+	router.POST("/login", LoginGuardMiddleware(guard, zap.NewNop()), func(c *gin.Context) {
+		if c.Query("password") == "ok" {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Status(http.StatusUnauthorized)
+	})
+	login := func(ip, password string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login?password="+password, nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 攻击者从多个地址触发全局上限
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		if code := login(ip, "bad"); code != http.StatusUnauthorized {
+			t.Fatalf("%s: status = %d, want 401", ip, code)
+		}
+	}
+	if code := login("203.0.113.1", "bad"); code != http.StatusTooManyRequests {
+		t.Fatalf("failing IP after global limit: status = %d, want 429", code)
+	}
+	if code := login("198.51.100.7", "ok"); code != http.StatusOK {
+		t.Fatalf("clean IP during global limit: status = %d, want 200", code)
+	}
+}
+
+func TestLoginGuardLocksIPAfterMaxAttempts(t *testing.T) {
+	guard := NewLoginGuard(LoginGuardOptions{MaxAttempts: 2, BaseLockout: time.Minute})
+	guard.Fail("203.0.113.1")
+	if wait := guard.RetryAfter("203.0.113.1"); wait != 0 {
+		t.Fatalf("RetryAfter after 1 failure = %v, want 0", wait)
+	}
+	guard.Fail("203.0.113.1")
+	if wait := guard.RetryAfter("203.0.113.1"); wait <= 0 {
+		t.Fatalf("RetryAfter after 2 failures = %v, want lockout", wait)
+	}
+	if wait := guard.RetryAfter("198.51.100.7"); wait != 0 {
+		t.Fatalf("RetryAfter for other IP = %v, want 0", wait)
+	}
+	guard.Succeed("203.0.113.1")
+	if wait := guard.RetryAfter("203.0.113.1"); wait != 0 {
+		t.Fatalf("RetryAfter after success = %v, want 0", wait)
+	}
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"wjdr-backend-go/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 记录HTTP请求数与耗时（按路由模板聚合，避免路径参数导致标签爆炸）
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
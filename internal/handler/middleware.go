@@ -2,21 +2,41 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
+	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-// CORSMiddleware CORS中间件（与Node版本对齐）
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware CORS中间件：命中 adminRoutes 登记的路由时使用管理接口策略，否则按 prefixes 的路径前缀（如 "/api/admin"，最长前缀优先）选择策略；未命中时使用默认策略。
+// 需注册在 router 上而非路由组上，否则预检请求（OPTIONS）无法匹配到策略；预检按 Access-Control-Request-Method 匹配路由
+func CORSMiddleware(defaultPolicy config.CORSPolicy, prefixes map[string]config.CORSPolicy, adminRoutes *AdminRouter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		path := c.Request.URL.Path
+		method := c.Request.Method
+		if method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			method = strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+		}
+
+		policy, found := adminRoutes.corsPolicy(method, path)
+		if !found {
+			policy = defaultPolicy
+			matched := ""
+			for prefix, p := range prefixes {
+				if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+					policy, matched = p, prefix
+				}
+			}
+		}
 
-		if c.Request.Method == "OPTIONS" {
+		applyCORSHeaders(c, policy)
+
+		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -25,8 +45,77 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware Token验证中间件（与Node版本对齐）
-func AuthMiddleware(adminService *service.AdminService) gin.HandlerFunc {
+// matchRouteTemplate 路径是否匹配 gin 路由模板（":参数" 匹配任意一段）
+func matchRouteTemplate(route, path string) bool {
+	routeParts := strings.Split(strings.Trim(route, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(routeParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range routeParts {
+		if !strings.HasPrefix(part, ":") && part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCORSHeaders 写入CORS响应头，来源不在白名单时不写入（由浏览器拦截）
+func applyCORSHeaders(c *gin.Context, policy config.CORSPolicy) {
+	origin := c.GetHeader("Origin")
+	allowAny, allowed := corsOriginAllowed(policy.AllowOrigins, origin)
+	if !allowAny && !allowed {
+		return
+	}
+
+	// 允许携带凭据时不能返回 *，改为回显请求来源
+	if allowAny && !policy.AllowCredentials {
+		c.Header("Access-Control-Allow-Origin", "*")
+	} else if origin != "" {
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Writer.Header().Add("Vary", "Origin")
+	}
+	if policy.AllowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+	if len(policy.ExposeHeaders) > 0 {
+		c.Header("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
+	}
+
+	if c.Request.Method == http.MethodOptions {
+		c.Header("Access-Control-Allow-Methods", strings.Join(policy.AllowMethods, ", "))
+		c.Header("Access-Control-Allow-Headers", strings.Join(policy.AllowHeaders, ", "))
+		if policy.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+		}
+	}
+}
+
+// corsOriginAllowed 判断请求来源是否允许，返回 (是否允许任意来源, 是否命中白名单)
+func corsOriginAllowed(allowOrigins []string, origin string) (bool, bool) {
+	for _, allowed := range allowOrigins {
+		if allowed == "*" {
+			return true, false
+		}
+		if origin == "" {
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return false, true
+		}
+		// 通配子域名：https://*.example.com
+		if i := strings.Index(allowed, "*."); i >= 0 {
+			scheme, suffix := allowed[:i], allowed[i+1:]
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, suffix) && len(origin) > len(scheme)+len(suffix) {
+				return false, true
+			}
+		}
+	}
+	return false, false
+}
+
+// AuthMiddleware Token验证中间件（与Node版本对齐），同时接受带权限范围的API Key
+func AuthMiddleware(adminService *service.AdminService, apiKeyService *service.APIKeyService, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -51,10 +140,17 @@ func AuthMiddleware(adminService *service.AdminService) gin.HandlerFunc {
 
 		token := tokenParts[1]
 
-		// 验证token
-		result, err := adminService.VerifyToken(token)
+		// API Key 仅能访问权限范围内的接口
+		if service.IsAPIKey(token) {
+			apiKeyAuth(c, apiKeyService, token, logger)
+			return
+		}
+
+		// 验证token并解析管理员身份（数据库不可用时返回503，而非当作token无效）
+		principal, err := adminService.Authenticate(token)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			requestLogger(c, logger).Error("管理员token验证失败", zap.Error(err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
 				"success": false,
 				"error":   "Token验证失败",
 			})
@@ -62,7 +158,7 @@ func AuthMiddleware(adminService *service.AdminService) gin.HandlerFunc {
 			return
 		}
 
-		if !result.Success {
+		if principal == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Token无效或已过期",
@@ -71,12 +167,88 @@ func AuthMiddleware(adminService *service.AdminService) gin.HandlerFunc {
 			return
 		}
 
-		// 将token存储到上下文中，供后续使用
+		// 将token与身份存储到上下文中，供后续使用
 		c.Set("token", token)
+		c.Set("admin_principal", principal)
+		c.Set("admin_role", principal.Role)
+		c.Set("admin_username", principal.Username)
+		c.Next()
+	}
+}
+
+// apiKeyAuth 校验API Key及其对当前路由的权限范围
+func apiKeyAuth(c *gin.Context, apiKeyService *service.APIKeyService, key string, logger *zap.Logger) {
+	principal, err := apiKeyService.Authenticate(key, c.ClientIP())
+	if err != nil {
+		requestLogger(c, logger).Error("API Key验证失败", zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "API Key验证失败",
+		})
+		c.Abort()
+		return
+	}
+
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "API Key无效、已停用或已过期",
+		})
+		c.Abort()
+		return
+	}
+
+	scope, ok := apiKeyRouteScope(c)
+	if !ok || !principal.HasScope(scope) {
+		message := "API Key无权访问该接口"
+		if ok {
+			message = "API Key缺少权限范围: " + scope
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("admin_principal", principal)
+	c.Set("admin_role", principal.Role)
+	c.Set("admin_username", principal.Username)
+	c.Next()
+}
+
+// RequireRole 角色校验中间件，需置于 AuthMiddleware 之后
+// API Key 已在 AuthMiddleware 中按权限范围校验，此处直接放行
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := c.GetString("admin_role")
+		if current != model.AdminRoleAPIKey && !model.AdminRoleAtLeast(current, role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "权限不足，需要 " + role + " 及以上角色",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// currentPrincipal 获取 AuthMiddleware 解析出的管理员身份
+func currentPrincipal(c *gin.Context) *model.AdminPrincipal {
+	principal, _ := c.MustGet("admin_principal").(*model.AdminPrincipal)
+	return principal
+}
+
+// clientInfo 提取请求方的IP与User-Agent
+func clientInfo(c *gin.Context) model.ClientInfo {
+	return model.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: truncateString(c.Request.UserAgent(), 512),
+	}
+}
+
 // ErrorResponse 统一错误响应格式（与Node版本对齐）
 func ErrorResponse(c *gin.Context, statusCode int, success bool, error string) {
 	c.JSON(statusCode, gin.H{
@@ -111,4 +283,3 @@ func SuccessResponseWithMessage(c *gin.Context, message string, data interface{}
 
 	c.JSON(http.StatusOK, response)
 }
-
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NonceStore 已使用签名的记录；UseNonce 在签名首次出现时返回 true
+type NonceStore interface {
+	UseNonce(nonce string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryNonceStore 进程内签名缓存（单实例部署默认使用）
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces:    make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// UseNonce 登记签名，过期记录按分钟惰性清理
+func (s *MemoryNonceStore) UseNonce(nonce string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, exp := range s.nonces {
+			if !exp.After(now) {
+				delete(s.nonces, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if exp, exists := s.nonces[nonce]; exists && exp.After(now) {
+		return false, nil
+	}
+	s.nonces[nonce] = expiresAt
+	return true, nil
+}
+
+// seen 签名是否已在本实例登记且未过期（不登记）
+func (s *MemoryNonceStore) seen(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, exists := s.nonces[nonce]
+	return exists && exp.After(time.Now())
+}
+
+// NonceBackend 持久化的签名记录（见 repository.SignNonceRepository）
+type NonceBackend interface {
+	NonceStore
+	CleanExpired() (int, error)
+}
+
+// LayeredNonceStore 内存缓存 + 数据库持久化（多实例共享防重放状态）
+type LayeredNonceStore struct {
+	memory    *MemoryNonceStore
+	backend   NonceBackend
+	logger    *zap.Logger
+	mu        sync.Mutex
+	lastClean time.Time
+}
+
+func NewLayeredNonceStore(backend NonceBackend, logger *zap.Logger) *LayeredNonceStore {
+	return &LayeredNonceStore{
+		memory:    NewMemoryNonceStore(),
+		backend:   backend,
+		logger:    logger,
+		lastClean: time.Now(),
+	}
+}
+
+// UseNonce 先查内存（快速拒绝本实例内的重放），再由数据库唯一键兜底；
+// 仅在数据库登记成功后写入内存，避免数据库出错时该签名在本实例被永久拒绝
+func (s *LayeredNonceStore) UseNonce(nonce string, expiresAt time.Time) (bool, error) {
+	if s.memory.seen(nonce) {
+		return false, nil
+	}
+
+	// 每小时清理一次数据库中的过期签名
+	s.mu.Lock()
+	if time.Since(s.lastClean) > time.Hour {
+		s.lastClean = time.Now()
+		go func() {
+			if cleaned, err := s.backend.CleanExpired(); err != nil {
+				s.logger.Warn("清理过期签名nonce失败", zap.Error(err))
+			} else if cleaned > 0 {
+				s.logger.Debug("清理过期签名nonce", zap.Int("count", cleaned))
+			}
+		}()
+	}
+	s.mu.Unlock()
+
+	fresh, err := s.backend.UseNonce(nonce, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	s.memory.UseNonce(nonce, expiresAt)
+	return fresh, nil
+}
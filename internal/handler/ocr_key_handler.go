@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"wjdr-backend-go/internal/client"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/service"
+	"wjdr-backend-go/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -30,58 +34,154 @@ func (h *OCRKeyHandler) List(c *gin.Context) {
 		return
 	}
 	type item struct {
-		ID             int    `json:"id"`
-		Provider       string `json:"provider"`
-		Name           string `json:"name"`
-		APIKeyEnd      string `json:"apiKeyEnd"`
-		IsActive       bool   `json:"isActive"`
-		HasQuota       bool   `json:"hasQuota"`
-		MonthlyQuota   int    `json:"monthlyQuota"`
-		RemainingQuota int    `json:"remainingQuota"`
-		Weight         int    `json:"weight"`
-		Success        int    `json:"successCount"`
-		Fail           int    `json:"failCount"`
+		ID        int    `json:"id"`
+		Provider  string `json:"provider"`
+		Name      string `json:"name"`
+		APIKeyEnd string `json:"apiKeyEnd"`
+		// APIKey/SecretKey 脱敏后的凭据（"****" + 末尾 4 位，secretKey 仅表示是否已设置）；EncryptionKeyID 为加密所用主密钥ID，空表示明文存储
+		APIKey          string `json:"apiKey"`
+		SecretKey       string `json:"secretKey"`
+		EncryptionKeyID string `json:"encryptionKeyId"`
+		IsActive        bool   `json:"isActive"`
+		HasQuota        bool   `json:"hasQuota"`
+		// DisabledReason has_quota=false 的原因：quota（额度用尽，重置时恢复）、auth（鉴权失败）、manual（手动停用）
+		DisabledReason string `json:"disabledReason,omitempty"`
+		// MonthlyQuota/DailyQuota 每月/每日额度，null 表示不限，0 表示无额度
+		MonthlyQuota        *int `json:"monthlyQuota"`
+		RemainingQuota      int  `json:"remainingQuota"`
+		DailyQuota          *int `json:"dailyQuota"`
+		RemainingDailyQuota int  `json:"remainingDailyQuota"`
+		Weight              int  `json:"weight"`
+		Success             int  `json:"successCount"`
+		Fail                int  `json:"failCount"`
+		// Options provider 自定义配置（http 的请求模板，不含鉴权值）
+		Options json.RawMessage `json:"options,omitempty"`
+		// VerifiedCorrect/VerifiedWrong 游戏判定的累计次数；Stats 为滚动窗口内的准确率与耗时（尚未使用时为空）
+		VerifiedCorrect int                 `json:"verifiedCorrect"`
+		VerifiedWrong   int                 `json:"verifiedWrong"`
+		Stats           *client.OCRKeyStats `json:"stats,omitempty"`
+		// QPSLimit 每秒请求上限（0 表示使用 provider 默认值）；Breaker 为熔断与实际限流状态（未参与调度的 Key 为空）
+		QPSLimit float64               `json:"qpsLimit"`
+		Breaker  *client.BreakerStatus `json:"breaker,omitempty"`
 	}
+	stats := h.svc.KeyStats()
+	breakers := h.svc.BreakerStates()
 	resp := make([]item, 0, len(keys))
 	for _, k := range keys {
-		end := ""
-		if n := len(k.APIKey); n >= 4 {
-			end = k.APIKey[n-4:]
+		end := k.APIKeyHint
+		if end == "" && utils.CredentialKeyID(k.APIKey) == "" {
+			end = utils.CredentialHint(k.APIKey) // 尚未重新加密的旧数据
+		}
+		var options json.RawMessage
+		if k.Options != nil && json.Valid([]byte(*k.Options)) {
+			options = json.RawMessage(*k.Options)
+		}
+		var st *client.OCRKeyStats
+		if v, ok := stats[k.ID]; ok {
+			st = &v
+		}
+		var breaker *client.BreakerStatus
+		if v, ok := breakers[k.ID]; ok {
+			breaker = &v
 		}
 		resp = append(resp, item{
-			ID:             k.ID,
-			Provider:       k.Provider,
-			Name:           k.Name,
-			APIKeyEnd:      end,
-			IsActive:       k.IsActive,
-			HasQuota:       k.HasQuota,
-			MonthlyQuota:   k.MonthlyQuota,
-			RemainingQuota: k.RemainingQuota,
-			Weight:         k.Weight,
-			Success:        k.SuccessCount,
-			Fail:           k.FailCount,
+			ID:                  k.ID,
+			Provider:            k.Provider,
+			Name:                k.Name,
+			APIKeyEnd:           end,
+			APIKey:              utils.MaskCredential(k.APIKey != "", end),
+			SecretKey:           utils.MaskCredential(k.SecretKey != "", ""),
+			EncryptionKeyID:     utils.CredentialKeyID(k.APIKey),
+			IsActive:            k.IsActive,
+			HasQuota:            k.HasQuota,
+			DisabledReason:      k.DisabledReason,
+			MonthlyQuota:        k.MonthlyQuota,
+			RemainingQuota:      k.RemainingQuota,
+			DailyQuota:          k.DailyQuota,
+			RemainingDailyQuota: k.RemainingDailyQuota,
+			Weight:              k.Weight,
+			Success:             k.SuccessCount,
+			Fail:                k.FailCount,
+			Options:             options,
+			VerifiedCorrect:     k.VerifiedCorrect,
+			VerifiedWrong:       k.VerifiedWrong,
+			Stats:               st,
+			QPSLimit:            k.QPSLimit,
+			Breaker:             breaker,
 		})
 	}
 	SuccessResponse(c, resp)
 }
 
+// Stats 按 provider 汇总的真实准确率与耗时（滚动窗口）
+func (h *OCRKeyHandler) Stats(c *gin.Context) {
+	SuccessResponse(c, h.svc.ProviderStats())
+}
+
+// previewMaxBody 预览请求体上限（样本验证码通常只有几 KB）
+const previewMaxBody = 1 << 20
+
+// PreviewPreprocess 用预处理流水线处理一张样本验证码，返回每一步的中间图片（data URL），用于调参
+func (h *OCRKeyHandler) PreviewPreprocess(c *gin.Context) {
+	var req struct {
+		Image    string `json:"image" binding:"required"` // base64 或 data URL
+		Pipeline string `json:"pipeline"`                 // 为空时使用 provider 当前配置的流水线
+		Provider string `json:"provider"`
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, previewMaxBody)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "参数错误")
+		return
+	}
+	pipeline, stages, err := h.svc.PreviewPreprocess(req.Image, req.Pipeline, req.Provider)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "预处理失败："+err.Error())
+		return
+	}
+	for i := range stages {
+		stages[i].Image = "data:image/png;base64," + stages[i].Image
+	}
+	SuccessResponse(c, gin.H{"pipeline": pipeline, "stages": stages})
+}
+
 // Create 新增 Key
 func (h *OCRKeyHandler) Create(c *gin.Context) {
 	var req struct {
-		Provider       string `json:"provider"`
-		Name           string `json:"name" binding:"required"`
-		APIKey         string `json:"apiKey" binding:"required"`
-		SecretKey      string `json:"secretKey" binding:"required"`
-		IsActive       *bool  `json:"isActive"`
-		HasQuota       *bool  `json:"hasQuota"`
-		Weight         *int   `json:"weight"`
-		MonthlyQuota   *int   `json:"monthlyQuota"`
-		RemainingQuota *int   `json:"remainingQuota"`
+		Provider  string `json:"provider"`
+		Name      string `json:"name" binding:"required"`
+		APIKey    string `json:"apiKey" binding:"required"` // provider=http 时为识别服务地址
+		SecretKey string `json:"secretKey"`                 // provider=http 时为鉴权头的值，可留空
+		IsActive  *bool  `json:"isActive"`
+		HasQuota  *bool  `json:"hasQuota"`
+		Weight    *int   `json:"weight"`
+		// MonthlyQuota 每月额度，null 表示不限，不填为 0；DailyQuota 每日额度，null 或不填表示不限；remaining* 不填时等于对应额度
+		MonthlyQuota        optionalInt `json:"monthlyQuota"`
+		RemainingQuota      *int        `json:"remainingQuota"`
+		DailyQuota          optionalInt `json:"dailyQuota"`
+		RemainingDailyQuota *int        `json:"remainingDailyQuota"`
+		// QPSLimit 每秒请求上限，0 或不填表示使用 provider 默认值
+		QPSLimit *float64 `json:"qpsLimit"`
+		// Options provider 自定义配置（JSON 对象），http 见 client.HTTPOCROptions
+		Options json.RawMessage `json:"options"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ErrorResponse(c, http.StatusBadRequest, false, "参数错误")
 		return
 	}
+	provider := strings.ToLower(strings.TrimSpace(req.Provider))
+	if req.SecretKey == "" && (provider == "" || provider == "baidu") {
+		ErrorResponse(c, http.StatusBadRequest, false, "参数错误：secretKey不能为空")
+		return
+	}
+	options, ok := optionsString(req.Options)
+	if !ok {
+		ErrorResponse(c, http.StatusBadRequest, false, "参数错误：options必须为JSON对象")
+		return
+	}
+	if err := h.svc.ValidateProviderConfig(provider, req.APIKey, options); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "参数错误："+err.Error())
+		return
+	}
 	k := model.OCRKey{
 		Provider:  req.Provider,
 		Name:      req.Name,
@@ -91,23 +191,38 @@ func (h *OCRKeyHandler) Create(c *gin.Context) {
 		HasQuota:  true,
 		Weight:    1,
 	}
-	if req.MonthlyQuota != nil {
-		k.MonthlyQuota = *req.MonthlyQuota
+	if options != "" {
+		k.Options = &options
+	}
+	k.MonthlyQuota = new(int)
+	if req.MonthlyQuota.Set && (req.MonthlyQuota.Value == nil || *req.MonthlyQuota.Value >= 0) {
+		k.MonthlyQuota = req.MonthlyQuota.Value
 	}
 	if req.RemainingQuota != nil {
 		k.RemainingQuota = *req.RemainingQuota
-	} else {
-		k.RemainingQuota = k.MonthlyQuota
+	} else if k.MonthlyQuota != nil {
+		k.RemainingQuota = *k.MonthlyQuota
+	}
+	if req.DailyQuota.Value != nil && *req.DailyQuota.Value >= 0 {
+		k.DailyQuota = req.DailyQuota.Value
+	}
+	if req.RemainingDailyQuota != nil && *req.RemainingDailyQuota >= 0 {
+		k.RemainingDailyQuota = *req.RemainingDailyQuota
+	} else if k.DailyQuota != nil {
+		k.RemainingDailyQuota = *k.DailyQuota
 	}
 	if req.IsActive != nil {
 		k.IsActive = *req.IsActive
 	}
-	if req.HasQuota != nil {
-		k.HasQuota = *req.HasQuota
+	if req.HasQuota != nil && !*req.HasQuota {
+		k.HasQuota, k.DisabledReason = false, model.OCRKeyDisabledManual
 	}
 	if req.Weight != nil && *req.Weight > 0 {
 		k.Weight = *req.Weight
 	}
+	if req.QPSLimit != nil && *req.QPSLimit >= 0 {
+		k.QPSLimit = *req.QPSLimit
+	}
 
 	id, err := h.svc.Create(&k)
 	if err != nil {
@@ -128,18 +243,37 @@ func (h *OCRKeyHandler) Update(c *gin.Context) {
 		return
 	}
 	var req struct {
-		Name           *string `json:"name"`
-		IsActive       *bool   `json:"isActive"`
-		HasQuota       *bool   `json:"hasQuota"`
-		Weight         *int    `json:"weight"`
-		MonthlyQuota   *int    `json:"monthlyQuota"`
-		RemainingQuota *int    `json:"remainingQuota"`
+		Name     *string `json:"name"`
+		IsActive *bool   `json:"isActive"`
+		HasQuota *bool   `json:"hasQuota"`
+		Weight   *int    `json:"weight"`
+		// MonthlyQuota/DailyQuota 每月/每日额度，null 表示不限，0 表示无额度
+		MonthlyQuota        optionalInt `json:"monthlyQuota"`
+		RemainingQuota      *int        `json:"remainingQuota"`
+		DailyQuota          optionalInt `json:"dailyQuota"`
+		RemainingDailyQuota *int        `json:"remainingDailyQuota"`
+		// QPSLimit 每秒请求上限，0 表示使用 provider 默认值
+		QPSLimit *float64 `json:"qpsLimit"`
+		// Options 整体替换 provider 自定义配置
+		Options json.RawMessage `json:"options"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ErrorResponse(c, http.StatusBadRequest, false, "参数错误")
 		return
 	}
 	patch := map[string]interface{}{}
+	if len(req.Options) > 0 {
+		options, ok := optionsString(req.Options)
+		if !ok {
+			ErrorResponse(c, http.StatusBadRequest, false, "参数错误：options必须为JSON对象")
+			return
+		}
+		if err := h.svc.ValidateOptionsUpdate(id, options); err != nil {
+			ErrorResponse(c, http.StatusBadRequest, false, "参数错误："+err.Error())
+			return
+		}
+		patch["options"] = options
+	}
 	if req.Name != nil {
 		patch["name"] = *req.Name
 	}
@@ -147,17 +281,31 @@ func (h *OCRKeyHandler) Update(c *gin.Context) {
 		patch["is_active"] = *req.IsActive
 	}
 	if req.HasQuota != nil {
+		// 手动停用的 Key 不会在额度重置时被自动恢复
 		patch["has_quota"] = *req.HasQuota
+		patch["disabled_reason"] = ""
+		if !*req.HasQuota {
+			patch["disabled_reason"] = model.OCRKeyDisabledManual
+		}
 	}
 	if req.Weight != nil && *req.Weight > 0 {
 		patch["weight"] = *req.Weight
 	}
-	if req.MonthlyQuota != nil && *req.MonthlyQuota >= 0 {
-		patch["monthly_quota"] = *req.MonthlyQuota
+	if v, ok := req.MonthlyQuota.patchValue(); ok {
+		patch["monthly_quota"] = v
 	}
 	if req.RemainingQuota != nil && *req.RemainingQuota >= 0 {
 		patch["remaining_quota"] = *req.RemainingQuota
 	}
+	if v, ok := req.DailyQuota.patchValue(); ok {
+		patch["daily_quota"] = v
+	}
+	if req.RemainingDailyQuota != nil && *req.RemainingDailyQuota >= 0 {
+		patch["remaining_daily_quota"] = *req.RemainingDailyQuota
+	}
+	if req.QPSLimit != nil && *req.QPSLimit >= 0 {
+		patch["qps_limit"] = *req.QPSLimit
+	}
 	if len(patch) == 0 {
 		ErrorResponse(c, http.StatusBadRequest, false, "无有效更新字段")
 		return
@@ -189,12 +337,61 @@ func (h *OCRKeyHandler) Delete(c *gin.Context) {
 	SuccessResponseWithMessage(c, "删除成功", nil)
 }
 
+// optionalInt 区分 JSON 中未填写的字段与显式的 null
+type optionalInt struct {
+	Set   bool
+	Value *int
+}
+
+func (o *optionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// patchValue 更新用的列值：null 写入 NULL，负数视为未填写
+func (o optionalInt) patchValue() (interface{}, bool) {
+	if !o.Set {
+		return nil, false
+	}
+	if o.Value == nil {
+		return nil, true
+	}
+	if *o.Value < 0 {
+		return nil, false
+	}
+	return *o.Value, true
+}
+
+// optionsString 将请求中的 options 规范为 JSON 字符串；未提供或为 null 时返回空串
+func optionsString(raw json.RawMessage) (string, bool) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" {
+		return "", true
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", false
+	}
+	compact, err := json.Marshal(obj)
+	if err != nil {
+		return "", false
+	}
+	return string(compact), true
+}
+
 func (h *OCRKeyHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	group := router.Group("/admin/ocr-keys", authMiddleware)
 	{
-		group.GET("", h.List)
-		group.POST("", h.Create)
-		group.PUT(":id", h.Update)
-		group.DELETE(":id", h.Delete)
+		// 查看 Key 列表需要 operator，增删改凭据需要 owner
+		group.GET("", RequireRole(model.AdminRoleOperator), h.List)
+		group.GET("/stats", RequireRole(model.AdminRoleOperator), h.Stats)
+		group.POST("/preprocess", RequireRole(model.AdminRoleOperator), h.PreviewPreprocess)
+		group.POST("", RequireRole(model.AdminRoleOwner), h.Create)
+		group.PUT(":id", RequireRole(model.AdminRoleOwner), h.Update)
+		group.DELETE(":id", RequireRole(model.AdminRoleOwner), h.Delete)
 	}
 }
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"net/http"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PlayerHandler 玩家自助接口（/api/me），仅能访问token绑定的账号；token 需通过游戏昵称验证码证明账号归属后签发
+type PlayerHandler struct {
+	playerService *service.PlayerService
+	logger        *zap.Logger
+}
+
+func NewPlayerHandler(playerService *service.PlayerService, logger *zap.Logger) *PlayerHandler {
+	return &PlayerHandler{
+		playerService: playerService,
+		logger:        logger,
+	}
+}
+
+// Challenge 获取登录验证码（签名请求），玩家需把游戏昵称改为包含该验证码
+// POST /api/me/challenge
+func (h *PlayerHandler) Challenge(c *gin.Context) {
+	fid := c.GetString("verified_fid")
+	if fid == "" {
+		ErrorResponse(c, http.StatusBadRequest, false, "FID验证失败")
+		return
+	}
+
+	result, err := h.playerService.Challenge(fid)
+	if err != nil {
+		requestLogger(c, h.logger).Error("生成登录验证码失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "生成验证码失败")
+		return
+	}
+	if !result.Success {
+		ErrorResponse(c, http.StatusNotFound, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Login 玩家签名登录：确认游戏昵称包含验证码后签发访问令牌
+// POST /api/me/login
+func (h *PlayerHandler) Login(c *gin.Context) {
+	fid := c.GetString("verified_fid")
+	if fid == "" {
+		ErrorResponse(c, http.StatusBadRequest, false, "FID验证失败")
+		return
+	}
+
+	result, err := h.playerService.Login(c.Request.Context(), fid)
+	if err != nil {
+		requestLogger(c, h.logger).Error("玩家登录失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, "登录失败")
+		return
+	}
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		switch result.Error {
+		case "账号不存在，请先添加账号":
+			statusCode = http.StatusNotFound
+		case "游戏昵称中未找到有效验证码，请先获取验证码并修改昵称":
+			statusCode = http.StatusForbidden
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// GetProfile 获取自己的账号资料与状态
+// GET /api/me
+func (h *PlayerHandler) GetProfile(c *gin.Context) {
+	h.respond(c, "获取账号信息失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.GetProfile(accountID)
+	})
+}
+
+// GetLogs 获取自己的兑换历史
+// GET /api/me/logs
+func (h *PlayerHandler) GetLogs(c *gin.Context) {
+	h.respond(c, "获取兑换记录失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.GetLogs(accountID)
+	})
+}
+
+// GetRewards 获取自己成功兑换的礼包
+// GET /api/me/rewards
+func (h *PlayerHandler) GetRewards(c *gin.Context) {
+	h.respond(c, "获取兑换记录失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.GetRewards(accountID)
+	})
+}
+
+// Pause 暂停自动兑换
+// POST /api/me/pause
+func (h *PlayerHandler) Pause(c *gin.Context) {
+	h.respond(c, "更新账号状态失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.SetPaused(accountID, true)
+	})
+}
+
+// Resume 恢复自动兑换
+// POST /api/me/resume
+func (h *PlayerHandler) Resume(c *gin.Context) {
+	h.respond(c, "更新账号状态失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.SetPaused(accountID, false)
+	})
+}
+
+// RemoveAccount 移除自己的账号
+// DELETE /api/me
+func (h *PlayerHandler) RemoveAccount(c *gin.Context) {
+	requestLogger(c, h.logger).Info("🗑️ 玩家移除账号", zap.String("fid", c.GetString("player_fid")))
+	h.respond(c, "删除账号失败", func(accountID int) (*model.APIResponse, error) {
+		return h.playerService.RemoveAccount(accountID)
+	})
+}
+
+// Logout 作废当前token
+// POST /api/me/logout
+func (h *PlayerHandler) Logout(c *gin.Context) {
+	token := c.GetString("player_token")
+	h.respond(c, "退出登录失败", func(int) (*model.APIResponse, error) {
+		return h.playerService.Logout(token)
+	})
+}
+
+// respond 统一处理：取出token绑定的账号ID并输出服务结果
+func (h *PlayerHandler) respond(c *gin.Context, failMsg string, fn func(accountID int) (*model.APIResponse, error)) {
+	accountID := c.GetInt("player_account_id")
+	if accountID <= 0 {
+		ErrorResponse(c, http.StatusUnauthorized, false, "未登录")
+		return
+	}
+
+	result, err := fn(accountID)
+	if err != nil {
+		requestLogger(c, h.logger).Error(failMsg, zap.Error(err), zap.Int("account_id", accountID))
+		ErrorResponse(c, http.StatusInternalServerError, false, failMsg)
+		return
+	}
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		if result.Error == "账号不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	if result.Message != "" {
+		SuccessResponseWithMessage(c, result.Message, result.Data)
+		return
+	}
+	SuccessResponse(c, result.Data)
+}
+
+// RegisterRoutes 注册玩家自助路由
+func (h *PlayerHandler) RegisterRoutes(router *gin.RouterGroup, playerAuthMiddleware gin.HandlerFunc, signMiddleware gin.HandlerFunc) {
+	me := router.Group("/me")
+	{
+		// 签名获取验证码与登录（与添加账号使用同一签名规则）
+		me.POST("/challenge", signMiddleware, h.Challenge)
+		me.POST("/login", signMiddleware, h.Login)
+
+		// 以下接口需要玩家token，仅作用于token绑定的账号
+		me.GET("", playerAuthMiddleware, h.GetProfile)
+		me.GET("/logs", playerAuthMiddleware, h.GetLogs)
+		me.GET("/rewards", playerAuthMiddleware, h.GetRewards)
+		me.POST("/pause", playerAuthMiddleware, h.Pause)
+		me.POST("/resume", playerAuthMiddleware, h.Resume)
+		me.POST("/logout", playerAuthMiddleware, h.Logout)
+		me.DELETE("", playerAuthMiddleware, h.RemoveAccount)
+	}
+}
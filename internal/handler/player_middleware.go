@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PlayerAuthMiddleware 玩家token验证中间件（仅用于 /api/me 路由）
+func PlayerAuthMiddleware(playerService *service.PlayerService, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" || tokenParts[1] == "" {
+			ErrorResponse(c, http.StatusUnauthorized, false, "需要提供有效的 Authorization 头")
+			c.Abort()
+			return
+		}
+
+		record, err := playerService.Authenticate(tokenParts[1])
+		if err != nil {
+			requestLogger(c, logger).Error("玩家token验证失败", zap.Error(err))
+			ErrorResponse(c, http.StatusInternalServerError, false, "Token验证失败")
+			c.Abort()
+			return
+		}
+		if record == nil {
+			ErrorResponse(c, http.StatusUnauthorized, false, "Token无效或已过期")
+			c.Abort()
+			return
+		}
+
+		// 后续handler只能操作该token绑定的账号
+		c.Set("player_token", tokenParts[1])
+		c.Set("player_account_id", record.GameAccountID)
+		c.Set("player_fid", record.FID)
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// IPRateLimiter 按客户端IP的令牌桶限流器（IP 取 c.ClientIP()，仅在请求来自 TRUSTED_PROXIES 时采信转发头，防止伪造 X-Forwarded-For 绕过）
+type IPRateLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*ipLimiterEntry
+	limit     rate.Limit
+	burst     int
+	lastSweep time.Time
+}
+
+type ipLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewIPRateLimiter 创建限流器；perMinute<=0 时不限流
+func NewIPRateLimiter(perMinute, burst int) *IPRateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	limit := rate.Inf
+	if perMinute > 0 {
+		limit = rate.Limit(float64(perMinute) / 60.0)
+	}
+	return &IPRateLimiter{
+		limiters:  make(map[string]*ipLimiterEntry),
+		limit:     limit,
+		burst:     burst,
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 判断该IP本次请求是否放行
+func (l *IPRateLimiter) Allow(ip string) bool {
+	if l == nil || l.limit == rate.Inf {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// 清理10分钟未访问的IP，避免map无限增长
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, e := range l.limiters {
+			if now.Sub(e.lastSeen) > 10*time.Minute {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.limiters[ip]
+	if !ok {
+		entry = &ipLimiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[ip] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.Allow()
+}
@@ -37,13 +37,13 @@ func (h *RedeemHandler) SubmitRedeemCode(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("📝 收到提交兑换码请求",
+	requestLogger(c, h.logger).Info("📝 收到提交兑换码请求",
 		zap.String("code", request.Code),
 		zap.Bool("is_long", request.IsLong))
 
-	result, err := h.redeemService.SubmitRedeemCode(request.Code, request.IsLong)
+	result, err := h.redeemService.SubmitRedeemCode(c.Request.Context(), request.Code, request.IsLong)
 	if err != nil {
-		h.logger.Error("提交兑换码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("提交兑换码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "提交兑换码失败")
 		return
 	}
@@ -76,7 +76,7 @@ func (h *RedeemHandler) SubmitRedeemCode(c *gin.Context) {
 func (h *RedeemHandler) GetAllRedeemCodes(c *gin.Context) {
 	result, err := h.redeemService.GetAllRedeemCodes()
 	if err != nil {
-		h.logger.Error("获取兑换码列表失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取兑换码列表失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取兑换码列表失败")
 		return
 	}
@@ -101,7 +101,7 @@ func (h *RedeemHandler) GetRedeemCodeDetails(c *gin.Context) {
 
 	result, err := h.redeemService.GetRedeemCodeDetails(id)
 	if err != nil {
-		h.logger.Error("获取兑换码详情失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取兑换码详情失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取兑换码详情失败")
 		return
 	}
@@ -130,7 +130,7 @@ func (h *RedeemHandler) GetRedeemCodeLogs(c *gin.Context) {
 
 	result, err := h.redeemService.GetRedeemCodeLogs(id)
 	if err != nil {
-		h.logger.Error("获取兑换日志失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取兑换日志失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取兑换日志失败")
 		return
 	}
@@ -165,7 +165,7 @@ func (h *RedeemHandler) GetAllLogs(c *gin.Context) {
 	}
 
 	if err != nil {
-		h.logger.Error("获取兑换日志失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取兑换日志失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取兑换日志失败")
 		return
 	}
@@ -179,7 +179,12 @@ func (h *RedeemHandler) GetAllLogs(c *gin.Context) {
 	// 全局统计（不受过滤影响）
 	total, successCnt, failedCnt, statErr := h.redeemService.GetGlobalLogStats()
 	if statErr != nil {
-		h.logger.Error("获取全局兑换统计失败", zap.Error(statErr))
+		requestLogger(c, h.logger).Error("获取全局兑换统计失败", zap.Error(statErr))
+	}
+	// 按结果分类的全局统计（区分已兑换过/次数上限等非技术性失败）
+	categoryStats, catErr := h.redeemService.GetGlobalCategoryStats()
+	if catErr != nil {
+		requestLogger(c, h.logger).Error("获取全局兑换分类统计失败", zap.Error(catErr))
 	}
 	if resultFilter == "" {
 		// 未过滤：按全量结果统计
@@ -200,6 +205,7 @@ func (h *RedeemHandler) GetAllLogs(c *gin.Context) {
 				"success": successCount,
 				"failed":  failedCount,
 			},
+			"category_stats": categoryStats,
 		})
 		return
 	}
@@ -212,6 +218,7 @@ func (h *RedeemHandler) GetAllLogs(c *gin.Context) {
 			"success": successCnt,
 			"failed":  failedCnt,
 		},
+		"category_stats": categoryStats,
 	})
 }
 
@@ -227,7 +234,7 @@ func (h *RedeemHandler) GetAccountsForRedeemCode(c *gin.Context) {
 
 	result, err := h.redeemService.GetAccountsForRedeemCode(id)
 	if err != nil {
-		h.logger.Error("获取账号处理状态失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("获取账号处理状态失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "获取账号处理状态失败")
 		return
 	}
@@ -254,11 +261,11 @@ func (h *RedeemHandler) DeleteRedeemCode(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🗑️ 收到删除兑换码请求", zap.Int("id", id))
+	requestLogger(c, h.logger).Info("🗑️ 收到删除兑换码请求", zap.Int("id", id))
 
 	result, err := h.redeemService.DeleteRedeemCode(id)
 	if err != nil {
-		h.logger.Error("删除兑换码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("删除兑换码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "删除兑换码失败")
 		return
 	}
@@ -292,11 +299,11 @@ func (h *RedeemHandler) BulkDeleteRedeemCodes(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("🗑️ 收到批量删除兑换码请求", zap.Int("count", len(request.IDs)))
+	requestLogger(c, h.logger).Info("🗑️ 收到批量删除兑换码请求", zap.Int("count", len(request.IDs)))
 
 	result, err := h.redeemService.BulkDeleteRedeemCodes(request.IDs)
 	if err != nil {
-		h.logger.Error("批量删除兑换码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("批量删除兑换码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "批量删除兑换码失败")
 		return
 	}
@@ -324,10 +331,10 @@ func (h *RedeemHandler) RetryRedeemCode(c *gin.Context) {
 			return
 		}
 		// 将单个id也按批量接口走，统一风格
-		h.logger.Info("🔄 收到重试兑换码请求(单个)", zap.Int("id", id))
-		result, err := h.redeemService.RetryRedeemCodes([]int{id})
+		requestLogger(c, h.logger).Info("🔄 收到重试兑换码请求(单个)", zap.Int("id", id))
+		result, err := h.redeemService.RetryRedeemCodes(c.Request.Context(), []int{id})
 		if err != nil {
-			h.logger.Error("重试兑换码失败", zap.Error(err))
+			requestLogger(c, h.logger).Error("重试兑换码失败", zap.Error(err))
 			ErrorResponse(c, http.StatusInternalServerError, false, "重试兑换码失败")
 			return
 		}
@@ -351,10 +358,10 @@ func (h *RedeemHandler) RetryRedeemCode(c *gin.Context) {
 		ErrorResponse(c, http.StatusBadRequest, false, "请提供要补充兑换的兑换码ID数组")
 		return
 	}
-	h.logger.Info("🔄 收到批量重试兑换码请求", zap.Int("count", len(req.IDs)))
-	result, err := h.redeemService.RetryRedeemCodes(req.IDs)
+	requestLogger(c, h.logger).Info("🔄 收到批量重试兑换码请求", zap.Int("count", len(req.IDs)))
+	result, err := h.redeemService.RetryRedeemCodes(c.Request.Context(), req.IDs)
 	if err != nil {
-		h.logger.Error("批量重试兑换码失败", zap.Error(err))
+		requestLogger(c, h.logger).Error("批量重试兑换码失败", zap.Error(err))
 		ErrorResponse(c, http.StatusInternalServerError, false, "批量重试兑换码失败")
 		return
 	}
@@ -366,11 +373,11 @@ func (h *RedeemHandler) RetryRedeemCode(c *gin.Context) {
 }
 
 // RegisterRedeemRoutes 注册兑换相关路由（与Node版本对齐）
-func (h *RedeemHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+func (h *RedeemHandler) RegisterRoutes(router *gin.RouterGroup, adminRouter *AdminRouter) {
 	redeem := router.Group("/redeem")
 	{
-		// 提交新的兑换码（需要管理员权限）
-		redeem.POST("", authMiddleware, h.SubmitRedeemCode)
+		// 提交新的兑换码（需要 operator 权限）
+		adminRouter.POST(redeem, "", model.AdminRoleOperator, h.SubmitRedeemCode)
 
 		// 获取兑换码列表（无需认证）
 		redeem.GET("", h.GetAllRedeemCodes)
@@ -387,15 +394,15 @@ func (h *RedeemHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware g
 		// 获取所有兑换日志（无需认证）
 		redeem.GET("/logs", h.GetAllLogs)
 
-		// 删除单个兑换码（需要管理员权限）
-		redeem.DELETE("/:id", authMiddleware, h.DeleteRedeemCode)
+		// 删除单个兑换码（需要 operator 权限）
+		adminRouter.DELETE(redeem, "/:id", model.AdminRoleOperator, h.DeleteRedeemCode)
 
-		// 批量删除兑换码（需要管理员权限）
-		redeem.DELETE("", authMiddleware, h.BulkDeleteRedeemCodes)
+		// 批量删除兑换码（需要 operator 权限）
+		adminRouter.DELETE(redeem, "", model.AdminRoleOperator, h.BulkDeleteRedeemCodes)
 
-		// 重试兑换码（需要管理员权限）
+		// 重试兑换码（需要 operator 权限）
 		// 新风格统一入口：POST /api/redeem/retry，Body: {"ids": [1,2,...]}
-		redeem.POST("/retry", authMiddleware, h.RetryRedeemCode)
-		redeem.POST("/:id/retry", authMiddleware, h.RetryRedeemCode)
+		adminRouter.POST(redeem, "/retry", model.AdminRoleOperator, h.RetryRedeemCode)
+		adminRouter.POST(redeem, "/:id/retry", model.AdminRoleOperator, h.RetryRedeemCode)
 	}
 }
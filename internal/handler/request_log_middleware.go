@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDHeader 请求ID头，客户端或反向代理传入时沿用，否则生成
+const RequestIDHeader = "X-Request-ID"
+
+// 仅接受常见字符，避免日志注入
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// 探活与指标抓取请求较多，访问日志降为调试级别
+var quietPaths = map[string]bool{
+	"/health":     true,
+	"/api/health": true,
+	"/metrics":    true,
+}
+
+// RequestIDMiddleware 为每个请求分配请求ID，写入响应头，并把带 request_id 的 logger 放入请求上下文
+func RequestIDMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID, _ = utils.GenerateToken(8)
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		ctx = logging.WithLogger(ctx, logger.With(zap.String("request_id", requestID)))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware 基于 zap 的访问日志（替代 gin 默认的文本日志），需置于 RequestIDMiddleware 之后
+func AccessLogMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		case quietPaths[path]:
+			level = zapcore.DebugLevel
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("bytes", c.Writer.Size()),
+		}
+		if username := c.GetString("admin_username"); username != "" {
+			fields = append(fields, zap.String("admin", username))
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+		requestLogger(c, logger).Log(level, "HTTP请求", fields...)
+	}
+}
+
+// RecoveryMiddleware 捕获 panic 并以 zap 记录（带 request_id），返回500
+func RecoveryMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		requestLogger(c, logger).Error("💥 请求处理发生panic",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Any("panic", err),
+			zap.Stack("stack"))
+		ErrorResponse(c, http.StatusInternalServerError, false, "服务器内部错误")
+		c.Abort()
+	})
+}
+
+// requestLogger 返回带 request_id 的 logger（未经过 RequestIDMiddleware 时返回 base）
+func requestLogger(c *gin.Context, base *zap.Logger) *zap.Logger {
+	return logging.FromContext(c.Request.Context(), base)
+}
@@ -2,15 +2,38 @@ package handler
 
 import (
 	"net/http"
+	"time"
 	"wjdr-backend-go/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// SignVerifyOptions 签名验证参数
+type SignVerifyOptions struct {
+	Salt        string
+	MaxSkew     time.Duration  // 时间戳允许的最大偏差，<=0 表示不校验时间戳
+	NonceStore  NonceStore     // 已用签名记录，为 nil 时不做重放检查
+	RateLimiter *IPRateLimiter // 按IP限流，为 nil 时不限流
+}
+
+// nonceRetention 未配置时间窗口时，已用签名的保留时长
+const nonceRetention = 24 * time.Hour
+
 // SignVerificationMiddleware 签名验证中间件（专用于添加账号接口）
-func SignVerificationMiddleware(salt string, logger *zap.Logger) gin.HandlerFunc {
+func SignVerificationMiddleware(opts SignVerifyOptions, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 按IP限流（先于解析请求体，降低暴力枚举成本）
+		if !opts.RateLimiter.Allow(c.ClientIP()) {
+			requestLogger(c, logger).Warn("签名验证：请求过于频繁", zap.String("ip", c.ClientIP()))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   "请求过于频繁，请稍后再试",
+			})
+			c.Abort()
+			return
+		}
+
 		// 解析请求体
 		var request struct {
 			FID       string `json:"fid"`
@@ -20,7 +43,7 @@ func SignVerificationMiddleware(salt string, logger *zap.Logger) gin.HandlerFunc
 
 		// 绑定JSON请求体
 		if err := c.ShouldBindJSON(&request); err != nil {
-			logger.Warn("签名验证：请求格式错误", zap.Error(err))
+			requestLogger(c, logger).Warn("签名验证：请求格式错误", zap.Error(err))
 			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
 				"error":   "请求格式错误，缺少必要的签名参数",
@@ -31,7 +54,7 @@ func SignVerificationMiddleware(salt string, logger *zap.Logger) gin.HandlerFunc
 
 		// 检查必要参数
 		if request.FID == "" || request.Timestamp == "" || request.Sign == "" {
-			logger.Warn("签名验证：缺少必要参数",
+			requestLogger(c, logger).Warn("签名验证：缺少必要参数",
 				zap.String("fid", request.FID),
 				zap.String("timestamp", request.Timestamp),
 				zap.String("sign", request.Sign))
@@ -43,9 +66,40 @@ func SignVerificationMiddleware(salt string, logger *zap.Logger) gin.HandlerFunc
 			return
 		}
 
+		// 校验时间戳新鲜度（未启用时不解析时间戳，兼容旧客户端的任意时间戳格式）
+		var signedAt time.Time
+		if opts.MaxSkew > 0 {
+			var err error
+			if signedAt, err = utils.ParseSignTimestamp(request.Timestamp); err != nil {
+				requestLogger(c, logger).Warn("签名验证：时间戳格式错误", zap.String("timestamp", request.Timestamp))
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error":   "时间戳格式错误",
+				})
+				c.Abort()
+				return
+			}
+			skew := time.Since(signedAt)
+			if skew < 0 {
+				skew = -skew
+			}
+			if skew > opts.MaxSkew {
+				requestLogger(c, logger).Warn("签名验证：时间戳超出允许范围",
+					zap.String("fid", request.FID),
+					zap.String("timestamp", request.Timestamp),
+					zap.Duration("skew", skew))
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "签名已过期，请校准设备时间后重试",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// 验证签名
-		if !utils.VerifyAccountSign(request.FID, request.Timestamp, request.Sign, salt) {
-			logger.Warn("签名验证失败",
+		if !utils.VerifyAccountSign(request.FID, request.Timestamp, request.Sign, opts.Salt) {
+			requestLogger(c, logger).Warn("签名验证失败",
 				zap.String("fid", request.FID),
 				zap.String("timestamp", request.Timestamp),
 				zap.String("provided_sign", request.Sign))
@@ -57,7 +111,37 @@ func SignVerificationMiddleware(salt string, logger *zap.Logger) gin.HandlerFunc
 			return
 		}
 
-		logger.Info("签名验证成功",
+		// 拒绝重放：签名在时间窗口内只能使用一次（签名通过后再登记，避免伪造签名占用）
+		if opts.NonceStore != nil {
+			expiresAt := signedAt.Add(opts.MaxSkew)
+			if opts.MaxSkew <= 0 {
+				expiresAt = time.Now().Add(nonceRetention)
+			}
+			fresh, err := opts.NonceStore.UseNonce(request.Sign, expiresAt)
+			if err != nil {
+				requestLogger(c, logger).Error("签名验证：登记nonce失败", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error":   "签名验证失败",
+				})
+				c.Abort()
+				return
+			}
+			if !fresh {
+				requestLogger(c, logger).Warn("签名验证：检测到重放请求",
+					zap.String("fid", request.FID),
+					zap.String("timestamp", request.Timestamp),
+					zap.String("ip", c.ClientIP()))
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "签名已被使用，请重新发起请求",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		requestLogger(c, logger).Info("签名验证成功",
 			zap.String("fid", request.FID),
 			zap.String("timestamp", request.Timestamp))
 
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebhookHandler 出站Webhook管理
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *zap.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// webhookRequest 创建/更新Webhook的请求体
+type webhookRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	URL      string   `json:"url" binding:"required"`
+	Secret   string   `json:"secret"` // 仅创建时生效，为空自动生成
+	Events   []string `json:"events"` // 为空表示订阅全部事件
+	IsActive *bool    `json:"is_active"`
+}
+
+func (r *webhookRequest) input() service.WebhookInput {
+	isActive := true
+	if r.IsActive != nil {
+		isActive = *r.IsActive
+	}
+	return service.WebhookInput{
+		Name:     r.Name,
+		URL:      r.URL,
+		Secret:   r.Secret,
+		Events:   r.Events,
+		IsActive: isActive,
+	}
+}
+
+// List 获取Webhook列表
+// GET /api/admin/webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	result, err := h.webhookService.ListWebhooks()
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取Webhook列表失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+// Events 获取可订阅的事件
+// GET /api/admin/webhooks/events
+func (h *WebhookHandler) Events(c *gin.Context) {
+	SuccessResponse(c, model.WebhookEvents)
+}
+
+// Create 创建Webhook
+// POST /api/admin/webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var request webhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "名称和URL不能为空")
+		return
+	}
+
+	result, err := h.webhookService.CreateWebhook(request.input(), currentPrincipal(c).UserID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("创建Webhook失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusBadRequest, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Update 更新Webhook（整体替换名称、URL、事件过滤与启用状态）
+// PUT /api/admin/webhooks/:id
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的Webhook ID")
+		return
+	}
+
+	var request webhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "名称和URL不能为空")
+		return
+	}
+
+	result, err := h.webhookService.UpdateWebhook(id, request.input())
+	if err != nil {
+		requestLogger(c, h.logger).Error("更新Webhook失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		statusCode := http.StatusBadRequest
+		if result.Error == "Webhook不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+// Delete 删除Webhook
+// DELETE /api/admin/webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的Webhook ID")
+		return
+	}
+
+	result, err := h.webhookService.DeleteWebhook(id)
+	if err != nil {
+		requestLogger(c, h.logger).Error("删除Webhook失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		ErrorResponse(c, http.StatusNotFound, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, nil)
+}
+
+// Deliveries 获取最近的投递记录
+// GET /api/admin/webhooks/:id/deliveries?limit=
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的Webhook ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result, err := h.webhookService.ListDeliveries(id, limit)
+	if err != nil {
+		requestLogger(c, h.logger).Error("获取Webhook投递记录失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	SuccessResponse(c, result.Data)
+}
+
+// Test 发送一次 ping 事件
+// POST /api/admin/webhooks/:id/test
+func (h *WebhookHandler) Test(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, false, "无效的Webhook ID")
+		return
+	}
+
+	result, err := h.webhookService.TestWebhook(c.Request.Context(), id)
+	if err != nil {
+		requestLogger(c, h.logger).Error("测试Webhook失败", zap.Error(err))
+		ErrorResponse(c, http.StatusInternalServerError, false, result.Error)
+		return
+	}
+
+	if !result.Success {
+		statusCode := http.StatusBadGateway
+		if result.Error == "Webhook不存在" {
+			statusCode = http.StatusNotFound
+		}
+		ErrorResponse(c, statusCode, false, result.Error)
+		return
+	}
+
+	SuccessResponseWithMessage(c, result.Message, result.Data)
+}
+
+func (h *WebhookHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// Webhook 管理需要 owner 权限（密钥与外部地址）
+	group := router.Group("/admin/webhooks", authMiddleware, RequireRole(model.AdminRoleOwner))
+	{
+		group.GET("", h.List)
+		group.GET("/events", h.Events)
+		group.POST("", h.Create)
+		group.PUT("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+		group.GET("/:id/deliveries", h.Deliveries)
+		group.POST("/:id/test", h.Test)
+	}
+}
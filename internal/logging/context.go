@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger 将带关联字段的 logger 放入上下文
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext 取出上下文中的 logger，没有时返回 fallback
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return fallback
+}
+
+// With 在上下文 logger 上追加字段（如 job_id、fid），返回新的上下文与 logger
+func With(ctx context.Context, fallback *zap.Logger, fields ...zap.Field) (context.Context, *zap.Logger) {
+	logger := FromContext(ctx, fallback).With(fields...)
+	return WithLogger(ctx, logger), logger
+}
+
+// WithRequestID 记录请求ID（提交异步任务时写入载荷）
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 返回上下文中的请求ID
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
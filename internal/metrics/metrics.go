@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus 指标（注册到默认Registry，由 /metrics 暴露）
+var (
+	// HTTP 接口
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_http_requests_total",
+		Help: "HTTP请求数（按方法、路由、状态码）",
+	}, []string{"method", "route", "status"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "wjdr_http_request_duration_seconds",
+		Help:    "HTTP请求耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// 游戏接口
+	GameAPIDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "wjdr_game_api_duration_seconds",
+		Help:    "游戏接口单次请求耗时（result: ok/error）",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"endpoint", "result"})
+	GameAPIRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_game_api_retries_total",
+		Help: "游戏接口网络重试次数",
+	}, []string{"endpoint"})
+	GameAPIErrCodes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_game_api_err_code_total",
+		Help: "游戏接口返回的 err_code 分布",
+	}, []string{"endpoint", "err_code"})
+
+	// 兑换结果
+	RedeemResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_redeem_results_total",
+		Help: "账号兑换最终结果（按结果分类）",
+	}, []string{"category"})
+
+	// OCR
+	OCRRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_ocr_requests_total",
+		Help: "OCR识别次数（按provider、key、结果）",
+	}, []string{"provider", "key_id", "result"})
+	OCRDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "wjdr_ocr_duration_seconds",
+		Help:    "OCR识别耗时",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10},
+	}, []string{"provider", "key_id"})
+	OCRConsensus = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_ocr_consensus_total",
+		Help: "多Provider投票结果（agreed/confident/fallback/rejected）",
+	}, []string{"outcome"})
+	OCRVerdicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_ocr_verdicts_total",
+		Help: "游戏对已提交验证码的判定（按provider、key，verdict: correct/wrong）",
+	}, []string{"provider", "key_id", "verdict"})
+	OCRBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wjdr_ocr_breaker_state",
+		Help: "OCR Key 熔断状态（0 正常 / 1 半开 / 2 熔断）",
+	}, []string{"provider", "key_id"})
+	OCRThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_ocr_throttled_total",
+		Help: "因达到 QPS 上限被跳过的 OCR Key 调度次数",
+	}, []string{"provider", "key_id"})
+
+	// 任务队列与Worker
+	JobsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "wjdr_jobs_in_flight",
+		Help: "正在处理的任务数",
+	})
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "wjdr_job_duration_seconds",
+		Help:    "任务处理耗时（result: success/failed）",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"type", "result"})
+	JobRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_job_retries_total",
+		Help: "任务重试次数（final 表示达到最大重试次数后失败）",
+	}, []string{"type", "final"})
+
+	// 出站Webhook
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wjdr_webhook_deliveries_total",
+		Help: "Webhook投递尝试次数（按事件、结果）",
+	}, []string{"event", "result"})
+)
+
+// queueDepth 任务队列深度指标的数据来源；指标只注册一次，新建的队列替换来源
+var queueDepth struct {
+	once             sync.Once
+	mu               sync.RWMutex
+	length, capacity func() int
+}
+
+// RegisterQueueDepth 设置任务队列深度指标（内存队列中待处理的任务数与容量）的数据来源，可重复调用，以最后一次为准
+func RegisterQueueDepth(length, capacity func() int) {
+	queueDepth.mu.Lock()
+	queueDepth.length, queueDepth.capacity = length, capacity
+	queueDepth.mu.Unlock()
+	queueDepth.once.Do(func() {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wjdr_job_queue_depth",
+			Help: "内存任务队列中待处理的任务数",
+		}, func() float64 {
+			queueDepth.mu.RLock()
+			defer queueDepth.mu.RUnlock()
+			return float64(queueDepth.length())
+		})
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wjdr_job_queue_capacity",
+			Help: "内存任务队列容量",
+		}, func() float64 {
+			queueDepth.mu.RLock()
+			defer queueDepth.mu.RUnlock()
+			return float64(queueDepth.capacity())
+		})
+	})
+}
+
+// ObserveOCR 记录一次OCR识别
+func ObserveOCR(provider string, keyID int, success bool, duration time.Duration) {
+	id := strconv.Itoa(keyID)
+	result := "failed"
+	if success {
+		result = "success"
+	}
+	OCRRequests.WithLabelValues(provider, id, result).Inc()
+	OCRDuration.WithLabelValues(provider, id).Observe(duration.Seconds())
+}
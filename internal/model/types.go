@@ -28,6 +28,9 @@ type RedeemCode struct {
 	SuccessCount  int       `json:"success_count" db:"success_count"`
 	FailedCount   int       `json:"failed_count" db:"failed_count"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+
+	// CategoryStats 按结果分类的计数（success/already_redeemed/limit_reached/...）
+	CategoryStats map[string]int `json:"category_stats" db:"category_stats"`
 }
 
 // RedeemLog 兑换日志模型
@@ -39,6 +42,7 @@ type RedeemLog struct {
 	Nickname          *string   `json:"nickname,omitempty" db:"nickname"`
 	Code              string    `json:"code" db:"code"`
 	Result            string    `json:"result" db:"result"`
+	ResultCategory    string    `json:"result_category" db:"result_category"`
 	ErrorMessage      *string   `json:"error_message" db:"error_message"`
 	SuccessMessage    *string   `json:"success_message" db:"success_message"`
 	CaptchaRecognized *string   `json:"captcha_recognized" db:"captcha_recognized"`
@@ -47,6 +51,17 @@ type RedeemLog struct {
 	RedeemedAt        time.Time `json:"redeemed_at" db:"redeemed_at"`
 }
 
+// 兑换结果分类：result 仅区分 success/failed，分类用于区分“已兑换过”“次数上限”等非技术性失败
+// 分类由游戏 err_code 推导（见 client.ClassifyRedeemResult）
+const (
+	ResultCategorySuccess         = "success"          // 兑换成功
+	ResultCategoryAlreadyRedeemed = "already_redeemed" // 已兑换过（40008/40011）
+	ResultCategoryLimitReached    = "limit_reached"    // 超出领取次数（40005）
+	ResultCategoryExpired         = "expired"          // 兑换码过期或不存在（40007/40014）
+	ResultCategoryIneligible      = "ineligible"       // 不满足领取条件（40006）
+	ResultCategoryTechnical       = "technical"        // 验证码/网络/服务器繁忙等技术性失败
+)
+
 // AdminPassword 管理员密码模型
 type AdminPassword struct {
 	ID           int       `json:"id" db:"id"`
@@ -59,10 +74,242 @@ type AdminPassword struct {
 
 // AdminToken 管理员Token模型
 type AdminToken struct {
+	ID         int        `json:"id" db:"id"`
+	TokenHash  string     `json:"-" db:"token_hash"`              // 仅保存token的SHA256摘要
+	UserID     *int       `json:"user_id,omitempty" db:"user_id"` // 旧版共享密码登录时为空
+	Role       string     `json:"role" db:"role"`
+	CreatedIP  string     `json:"created_ip" db:"created_ip"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Current    bool       `json:"current" db:"-"` // 是否为当前请求使用的会话
+}
+
+// ClientInfo 发起登录的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AdminUser 具名管理员账号
+type AdminUser struct {
+	ID           int        `json:"id" db:"id"`
+	Username     string     `json:"username" db:"username"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	Role         string     `json:"role" db:"role"`
+	IsActive     bool       `json:"is_active" db:"is_active"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AdminPrincipal 当前请求的管理员身份（由token解析）
+type AdminPrincipal struct {
+	TokenID  int      `json:"-"`
+	UserID   *int     `json:"user_id,omitempty"`
+	Username string   `json:"username"`
+	Role     string   `json:"role"`
+	APIKeyID *int     `json:"api_key_id,omitempty"` // 通过API Key认证时非空
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// AdminRoleAPIKey API Key 认证的身份角色（权限由 scopes 决定，不参与角色等级比较）
+const AdminRoleAPIKey = "api_key"
+
+// HasScope 判断API Key身份是否具备指定权限
+func (p *AdminPrincipal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey 自动化客户端使用的长期访问密钥
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	KeyPrefix  string     `json:"key_prefix" db:"key_prefix"` // 明文前缀，便于识别
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips" db:"allowed_ips"` // 为空表示不限制，支持CIDR
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" db:"last_used_ip"`
+	CreatedBy  *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// API Key 权限范围
+const (
+	ScopeRedeemSubmit   = "redeem:submit"   // 提交兑换码
+	ScopeRedeemManage   = "redeem:manage"   // 重试/删除兑换码
+	ScopeRedeemRead     = "redeem:read"     // 查看兑换进度（事件流）
+	ScopeAccountsManage = "accounts:manage" // 删除/刷新账号
+	ScopeStatsFix       = "stats:fix"       // 修复统计
+	ScopeRSSRead        = "rss:read"        // 查看已处理文章
+	ScopeRSSFetch       = "rss:fetch"       // 触发RSS抓取
+)
+
+// APIKeyScopes 全部可分配的权限范围
+var APIKeyScopes = []string{
+	ScopeRedeemSubmit,
+	ScopeRedeemManage,
+	ScopeRedeemRead,
+	ScopeAccountsManage,
+	ScopeStatsFix,
+	ScopeRSSRead,
+	ScopeRSSFetch,
+}
+
+// IsValidAPIKeyScope 判断权限范围是否合法
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook 出站通知订阅
+type Webhook struct {
 	ID        int       `json:"id" db:"id"`
-	Token     string    `json:"token" db:"token"`
-	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Name      string    `json:"name" db:"name"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`      // HMAC签名密钥，仅创建时返回
+	Events    []string  `json:"events" db:"events"` // 为空表示订阅全部事件
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedBy *int      `json:"created_by,omitempty" db:"created_by"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery Webhook 投递记录
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	WebhookID      int        `json:"webhook_id" db:"webhook_id"`
+	Event          string     `json:"event" db:"event"`
+	Payload        string     `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"` // pending, success, failed
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
+	ErrorMessage   *string    `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
+// Webhook 事件
+const (
+	WebhookEventRedeemCodeCreated = "redeem_code.created" // RSS 抓取到新兑换码
+	WebhookEventBatchCompleted    = "batch.completed"     // 兑换任务完成（含成功/失败数）
+	WebhookEventJobDeadLettered   = "job.dead_lettered"   // 任务重试耗尽
+	WebhookEventOCRKeyExhausted   = "ocr_key.exhausted"   // OCR Key 额度用尽被自动禁用
+	WebhookEventOCRQuotaLow       = "ocr_key.quota_low"   // OCR Key 剩余额度低于预警阈值
+	WebhookEventAccountDisabled   = "account.disabled"    // 账号验证失败被自动停用
+	WebhookEventPing              = "ping"                // 手动测试
+)
+
+// WebhookEvents 全部可订阅的事件
+var WebhookEvents = []string{
+	WebhookEventRedeemCodeCreated,
+	WebhookEventBatchCompleted,
+	WebhookEventJobDeadLettered,
+	WebhookEventOCRKeyExhausted,
+	WebhookEventOCRQuotaLow,
+	WebhookEventAccountDisabled,
+}
+
+// IsValidWebhookEvent 判断事件名是否可订阅
+func IsValidWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// 管理员角色：viewer 只读，operator 可执行兑换/账号维护，owner 可管理管理员与凭据
+const (
+	AdminRoleViewer   = "viewer"
+	AdminRoleOperator = "operator"
+	AdminRoleOwner    = "owner"
+)
+
+// adminRoleLevels 角色权限等级，数值越大权限越高
+var adminRoleLevels = map[string]int{
+	AdminRoleViewer:   1,
+	AdminRoleOperator: 2,
+	AdminRoleOwner:    3,
+}
+
+// IsValidAdminRole 判断角色名是否合法
+func IsValidAdminRole(role string) bool {
+	_, ok := adminRoleLevels[role]
+	return ok
+}
+
+// AdminRoleAtLeast 判断 role 是否具备 required 及以上权限
+func AdminRoleAtLeast(role, required string) bool {
+	level, ok := adminRoleLevels[role]
+	if !ok {
+		return false
+	}
+	return level >= adminRoleLevels[required]
+}
+
+// PlayerToken 玩家自助访问令牌（按FID签发，仅存储哈希）
+type PlayerToken struct {
+	ID            int        `json:"id" db:"id"`
+	GameAccountID int        `json:"game_account_id" db:"game_account_id"`
+	FID           string     `json:"fid" db:"fid"`
+	TokenHash     string     `json:"-" db:"token_hash"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AuditEvent 管理操作审计记录
+type AuditEvent struct {
+	ID             int64     `json:"id" db:"id"`
+	ActorUserID    *int      `json:"actor_user_id,omitempty" db:"actor_user_id"`
+	ActorName      string    `json:"actor_name" db:"actor_name"`
+	ActorRole      string    `json:"actor_role" db:"actor_role"`
+	ActorTokenID   *int      `json:"actor_token_id,omitempty" db:"actor_token_id"`
+	Action         string    `json:"action" db:"action"`
+	Method         string    `json:"method" db:"method"`
+	Path           string    `json:"path" db:"path"`
+	TargetIDs      string    `json:"target_ids" db:"target_ids"`
+	RequestSummary string    `json:"request_summary" db:"request_summary"`
+	Result         string    `json:"result" db:"result"` // success/failed
+	StatusCode     int       `json:"status_code" db:"status_code"`
+	ErrorMessage   *string   `json:"error_message,omitempty" db:"error_message"`
+	IP             string    `json:"ip" db:"ip"`
+	UserAgent      string    `json:"user_agent" db:"user_agent"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// AuditEventFilter 审计记录查询条件（零值表示不过滤）
+type AuditEventFilter struct {
+	Actor  string
+	Action string
+	Result string
+	Target string
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
 }
 
 // Job 异步任务模型
@@ -94,28 +341,49 @@ type JobPayload struct {
 	AccountIDs    []int `json:"account_ids,omitempty"`
 	IsRetry       bool  `json:"is_retry,omitempty"`
 	SkipAccountID *int  `json:"skip_account_id,omitempty"`
+	// TraceContext 提交任务时的链路上下文（W3C traceparent），Worker 据此关联到发起请求
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	// RequestID 提交任务的请求ID，Worker 日志据此关联到发起请求
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // OCRKey OCR Key 管理模型
 type OCRKey struct {
-	ID             int        `json:"id" db:"id"`
-	Provider       string     `json:"provider" db:"provider"`
-	Name           string     `json:"name" db:"name"`
-	APIKey         string     `json:"api_key" db:"api_key"`
-	SecretKey      string     `json:"secret_key" db:"secret_key"`
-	IsActive       bool       `json:"is_active" db:"is_active"`
-	HasQuota       bool       `json:"has_quota" db:"has_quota"`
-	MonthlyQuota   int        `json:"monthly_quota" db:"monthly_quota"`
-	RemainingQuota int        `json:"remaining_quota" db:"remaining_quota"`
-	Weight         int        `json:"weight" db:"weight"`
-	SuccessCount   int        `json:"success_count" db:"success_count"`
-	FailCount      int        `json:"fail_count" db:"fail_count"`
-	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
-	LastUsedAt     *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
-	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	ID             int     `json:"id" db:"id"`
+	Provider       string  `json:"provider" db:"provider"`
+	Name           string  `json:"name" db:"name"`
+	APIKey         string  `json:"-" db:"api_key"`                 // 加密存储（见 utils.CredentialCipher，旧数据可能为明文），仅在 OCRKeyManager.Reload 中解密
+	SecretKey      string  `json:"-" db:"secret_key"`              // 同 APIKey
+	APIKeyHint     string  `json:"api_key_hint" db:"api_key_hint"` // API Key 明文末尾 4 位，用于脱敏展示
+	Options        *string `json:"options,omitempty" db:"options"` // provider 自定义配置（JSON），如 http 的请求模板
+	IsActive       bool    `json:"is_active" db:"is_active"`
+	HasQuota       bool    `json:"has_quota" db:"has_quota"`
+	DisabledReason string  `json:"disabled_reason" db:"disabled_reason"` // has_quota=false 的原因（见 OCRKeyDisabled*），可用时为空
+	MonthlyQuota   *int    `json:"monthly_quota" db:"monthly_quota"`     // NULL 表示不限，0 表示无额度
+	RemainingQuota int     `json:"remaining_quota" db:"remaining_quota"`
+	// DailyQuota/RemainingDailyQuota 每日额度（NULL 表示不限），每天0点重置
+	DailyQuota          *int    `json:"daily_quota" db:"daily_quota"`
+	RemainingDailyQuota int     `json:"remaining_daily_quota" db:"remaining_daily_quota"`
+	Weight              int     `json:"weight" db:"weight"`
+	QPSLimit            float64 `json:"qps_limit" db:"qps_limit"` // 每秒请求上限，0 表示使用 provider 默认值（baidu 为 2）
+	SuccessCount        int     `json:"success_count" db:"success_count"`
+	FailCount           int     `json:"fail_count" db:"fail_count"`
+	// VerifiedCorrect/VerifiedWrong 游戏判定的累计正确/错误次数（40103 为错误）
+	VerifiedCorrect int        `json:"verified_correct" db:"verified_correct"`
+	VerifiedWrong   int        `json:"verified_wrong" db:"verified_wrong"`
+	LastError       *string    `json:"last_error,omitempty" db:"last_error"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// OCR Key 停用原因：额度重置只恢复因额度停用的 Key
+const (
+	OCRKeyDisabledQuota  = "quota"  // 额度用尽（本地计数或服务端额度错误）
+	OCRKeyDisabledAuth   = "auth"   // 鉴权/权限错误，需人工更换凭据后启用
+	OCRKeyDisabledManual = "manual" // 管理员手动停用
+)
+
 // API响应结构
 type APIResponse struct {
 	Success bool        `json:"success"`
@@ -126,6 +394,32 @@ type APIResponse struct {
 
 // 以前的分页响应与分页结构已移除（不再分页）
 
+// 健康检查状态
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded" // 非关键依赖异常，服务仍可接收请求
+	HealthStatusDown     = "down"     // 关键依赖不可用，不应接收流量
+)
+
+// HealthCheck 单项依赖检查结果
+type HealthCheck struct {
+	Status   string                 `json:"status"`
+	Critical bool                   `json:"critical"`
+	Message  string                 `json:"message,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport 依赖健康检查汇总
+type HealthReport struct {
+	Status    string                 `json:"status"`
+	Version   string                 `json:"version"`
+	Commit    string                 `json:"commit"`
+	BuildTime string                 `json:"build_time,omitempty"`
+	Uptime    string                 `json:"uptime"`
+	Timestamp string                 `json:"timestamp"`
+	Checks    map[string]HealthCheck `json:"checks"`
+}
+
 // 账号验证响应
 type AccountVerifyResponse struct {
 	Success bool `json:"success"`
@@ -0,0 +1,125 @@
+// Package onnx 纯 Go 的 ONNX 推理（仅 CPU、float32），只实现小型识别模型（如 CRNN 验证码模型）所需的算子，
+// 使服务在无 Python/动态库的环境下也能离线识别。
+package onnx
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Model 已加载的模型，只读，可并发调用 Run
+type Model struct {
+	graph *graph
+	opset int64
+	// Inputs 需要调用方提供的输入（不含常量初始值）
+	Inputs []string
+	// Outputs 模型输出
+	Outputs []string
+}
+
+// Load 从文件加载模型（权重需内嵌在同一文件中）
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse 解析 ONNX 模型，并检查所有算子是否受支持
+func Parse(data []byte) (*Model, error) {
+	g, opset, err := parseModel(data)
+	if err != nil {
+		return nil, fmt.Errorf("onnx: parse model: %w", err)
+	}
+
+	unsupported := map[string]bool{}
+	for _, n := range g.Nodes {
+		if n.Domain != "" && n.Domain != "ai.onnx" {
+			unsupported[n.Domain+"."+n.OpType] = true
+			continue
+		}
+		if _, ok := operators[n.OpType]; !ok {
+			unsupported[n.OpType] = true
+			continue
+		}
+		if err := validateAttrs(n); err != nil {
+			return nil, fmt.Errorf("onnx: node %s: %w", n.Name, err)
+		}
+	}
+	if len(unsupported) > 0 {
+		ops := make([]string, 0, len(unsupported))
+		for op := range unsupported {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		return nil, fmt.Errorf("onnx: unsupported operators: %s", strings.Join(ops, ", "))
+	}
+
+	m := &Model{graph: g, opset: opset, Outputs: g.Outputs}
+	for _, name := range g.Inputs {
+		if _, isWeight := g.Initializers[name]; !isWeight {
+			m.Inputs = append(m.Inputs, name)
+		}
+	}
+	return m, nil
+}
+
+// Run 执行一次推理，返回全部模型输出；算子因模型与输入不匹配而越界时返回错误而不是使进程崩溃
+func (m *Model) Run(inputs map[string]*Tensor) (result map[string]*Tensor, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("onnx: inference panicked: %v", r)
+		}
+	}()
+	values := make(map[string]*Tensor, len(m.graph.Initializers)+len(m.graph.Nodes))
+	for name, t := range m.graph.Initializers {
+		values[name] = t
+	}
+	for _, name := range m.Inputs {
+		t, ok := inputs[name]
+		if !ok {
+			return nil, fmt.Errorf("onnx: missing input %s", name)
+		}
+		if len(t.Data) != t.Size() {
+			return nil, fmt.Errorf("onnx: input %s has %d values for shape %v", name, len(t.Data), t.Shape)
+		}
+		values[name] = t
+	}
+
+	ctx := &opContext{opset: m.opset}
+	for _, n := range m.graph.Nodes {
+		in := make([]*Tensor, len(n.Inputs))
+		for i, name := range n.Inputs {
+			if name == "" {
+				continue // 省略的可选输入
+			}
+			t, ok := values[name]
+			if !ok {
+				return nil, fmt.Errorf("onnx: node %s (%s) input %s is not available", n.Name, n.OpType, name)
+			}
+			in[i] = t
+		}
+		out, err := operators[n.OpType](ctx, n, in)
+		if err != nil {
+			return nil, fmt.Errorf("onnx: node %s: %w", n.Name, err)
+		}
+		for i, name := range n.Outputs {
+			if name != "" && i < len(out) {
+				values[name] = out[i]
+			}
+		}
+	}
+
+	result = make(map[string]*Tensor, len(m.Outputs))
+	for _, name := range m.Outputs {
+		t, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("onnx: output %s was not produced", name)
+		}
+		result[name] = t
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 以下构造函数按 onnx.proto 的字段号手工编码测试用的最小模型
+
+func pbBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func pbVarint(b []byte, num protowire.Number, v int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func pbTensor(name string, dims []int64, data []float32) []byte {
+	var b []byte
+	for _, d := range dims {
+		b = pbVarint(b, 1, d)
+	}
+	b = pbVarint(b, 2, dataTypeFloat)
+	b = pbBytes(b, 8, []byte(name))
+	raw := make([]byte, 4*len(data))
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(v))
+	}
+	return pbBytes(b, 9, raw)
+}
+
+func pbAttrInts(name string, values ...int64) []byte {
+	b := pbBytes(nil, 1, []byte(name))
+	for _, v := range values {
+		b = pbVarint(b, 8, v)
+	}
+	return b
+}
+
+func pbAttrInt(name string, v int64) []byte {
+	return pbVarint(pbBytes(nil, 1, []byte(name)), 3, v)
+}
+
+func pbAttrFloat(name string, v float32) []byte {
+	b := pbBytes(nil, 1, []byte(name))
+	b = protowire.AppendTag(b, 2, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(v))
+}
+
+func pbNode(op string, inputs, outputs []string, attrs ...[]byte) []byte {
+	var b []byte
+	for _, in := range inputs {
+		b = pbBytes(b, 1, []byte(in))
+	}
+	for _, out := range outputs {
+		b = pbBytes(b, 2, []byte(out))
+	}
+	b = pbBytes(b, 3, []byte(op+"_0"))
+	b = pbBytes(b, 4, []byte(op))
+	for _, a := range attrs {
+		b = pbBytes(b, 5, a)
+	}
+	return b
+}
+
+func pbModel(nodes, initializers [][]byte, inputs, outputs []string) []byte {
+	var g []byte
+	for _, n := range nodes {
+		g = pbBytes(g, 1, n)
+	}
+	for _, t := range initializers {
+		g = pbBytes(g, 5, t)
+	}
+	for _, name := range inputs {
+		g = pbBytes(g, 11, pbBytes(nil, 1, []byte(name)))
+	}
+	for _, name := range outputs {
+		g = pbBytes(g, 12, pbBytes(nil, 1, []byte(name)))
+	}
+	m := pbVarint(nil, 1, 8)
+	m = pbBytes(m, 8, pbVarint(nil, 2, 13))
+	return pbBytes(m, 7, g)
+}
+
+// convBNModel Conv(2x2, 2 个输出通道) -> BatchNormalization -> Relu
+func convBNModel(convAttrs [][]byte, bnScale []float32) []byte {
+	nodes := [][]byte{
+		pbNode("Conv", []string{"x", "w", "b"}, []string{"conv"}, convAttrs...),
+		pbNode("BatchNormalization", []string{"conv", "scale", "bias", "mean", "var"}, []string{"bn"}, pbAttrFloat("epsilon", 0)),
+		pbNode("Relu", []string{"bn"}, []string{"y"}),
+	}
+	inits := [][]byte{
+		pbTensor("w", []int64{2, 1, 2, 2}, []float32{1, 0, 0, 1, 0, 1, -1, 0}),
+		pbTensor("b", []int64{2}, []float32{1, 0}),
+		pbTensor("scale", []int64{int64(len(bnScale))}, bnScale),
+		pbTensor("bias", []int64{2}, []float32{0, 1}),
+		pbTensor("mean", []int64{2}, []float32{1, 0}),
+		pbTensor("var", []int64{2}, []float32{1, 1}),
+	}
+	return pbModel(nodes, inits, []string{"x", "w", "b", "scale", "bias", "mean", "var"}, []string{"y"})
+}
+
+func TestRunGoldenConvBatchNormRelu(t *testing.T) {
+	m, err := Parse(convBNModel(nil, []float32{1, 2}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(m.Inputs) != 1 || m.Inputs[0] != "x" {
+		t.Fatalf("Inputs = %v, want [x]", m.Inputs)
+	}
+
+	x := NewTensor([]int{1, 1, 3, 3}, []float32{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	out, err := m.Run(map[string]*Tensor{"x": x})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	y := out["y"]
+	wantShape := []int{1, 2, 2, 2}
+	// 通道 0：x[i][j]+x[i+1][j+1]+1，BN 后减 1；通道 1：x[i][j+1]-x[i+1][j] = -2，BN 后 2*(-2)+1，Relu 截为 0
+	want := []float32{6, 8, 12, 14, 0, 0, 0, 0}
+	if !equalShape(y.Shape, wantShape) {
+		t.Fatalf("shape = %v, want %v", y.Shape, wantShape)
+	}
+	for i := range want {
+		if math.Abs(float64(y.Data[i]-want[i])) > 1e-5 {
+			t.Fatalf("data = %v, want %v", y.Data, want)
+		}
+	}
+}
+
+func TestParseRejectsMalformedModels(t *testing.T) {
+	cases := map[string][]byte{
+		"negative dim": pbModel(nil, [][]byte{pbTensor("w", []int64{-1, 4}, nil)}, nil, nil),
+		"huge tensor":  pbModel(nil, [][]byte{pbTensor("w", []int64{1 << 20, 1 << 20}, nil)}, nil, nil),
+		"zero group":   convBNModel([][]byte{pbAttrInt("group", 0)}, []float32{1, 2}),
+		"zero stride":  convBNModel([][]byte{pbAttrInts("strides", 0, 1)}, []float32{1, 2}),
+		"negative pad": convBNModel([][]byte{pbAttrInts("pads", -1, 0, 0, 0)}, []float32{1, 2}),
+	}
+	for name, data := range cases {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("%s: Parse succeeded, want error", name)
+		}
+	}
+}
+
+func TestRunRejectsMismatchedBatchNormParams(t *testing.T) {
+	m, err := Parse(convBNModel(nil, []float32{1}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	_, err = m.Run(map[string]*Tensor{"x": NewTensor([]int{1, 1, 3, 3}, nil)})
+	if err == nil || !strings.Contains(err.Error(), "BatchNormalization") {
+		t.Fatalf("Run error = %v, want BatchNormalization parameter error", err)
+	}
+}
@@ -0,0 +1,1013 @@
+package onnx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// opFunc 算子实现：in 中未提供的可选输入为 nil
+type opFunc func(ctx *opContext, n *node, in []*Tensor) ([]*Tensor, error)
+
+// opContext 推理时的模型级信息
+type opContext struct {
+	opset int64
+}
+
+// operators 支持的算子（覆盖 PaddleOCR CRNN 经 paddle2onnx 导出后用到的算子）
+var operators = map[string]opFunc{
+	"Identity":           opIdentity,
+	"Dropout":            opIdentity,
+	"Cast":               opCast,
+	"Constant":           opConstant,
+	"ConstantOfShape":    opConstantOfShape,
+	"Add":                binaryOp(func(x, y float32) float32 { return x + y }),
+	"Sub":                binaryOp(func(x, y float32) float32 { return x - y }),
+	"Mul":                binaryOp(func(x, y float32) float32 { return x * y }),
+	"Div":                binaryOp(func(x, y float32) float32 { return x / y }),
+	"Pow":                binaryOp(func(x, y float32) float32 { return float32(math.Pow(float64(x), float64(y))) }),
+	"Relu":               unaryOp(func(x float32) float32 { return max(x, 0) }),
+	"Sigmoid":            unaryOp(sigmoid),
+	"Tanh":               unaryOp(func(x float32) float32 { return float32(math.Tanh(float64(x))) }),
+	"Exp":                unaryOp(func(x float32) float32 { return float32(math.Exp(float64(x))) }),
+	"Sqrt":               unaryOp(func(x float32) float32 { return float32(math.Sqrt(float64(x))) }),
+	"Neg":                unaryOp(func(x float32) float32 { return -x }),
+	"Abs":                unaryOp(func(x float32) float32 { return float32(math.Abs(float64(x))) }),
+	"Reciprocal":         unaryOp(func(x float32) float32 { return 1 / x }),
+	"HardSwish":          unaryOp(func(x float32) float32 { return x * min(max(x/6+0.5, 0), 1) }),
+	"HardSigmoid":        opHardSigmoid,
+	"LeakyRelu":          opLeakyRelu,
+	"Clip":               opClip,
+	"BatchNormalization": opBatchNorm,
+	"Conv":               opConv,
+	"MaxPool":            poolOp(false),
+	"AveragePool":        poolOp(true),
+	"GlobalAveragePool":  globalPoolOp(true),
+	"GlobalMaxPool":      globalPoolOp(false),
+	"Reshape":            opReshape,
+	"Flatten":            opFlatten,
+	"Transpose":          opTranspose,
+	"Squeeze":            opSqueeze,
+	"Unsqueeze":          opUnsqueeze,
+	"Concat":             opConcat,
+	"Shape":              opShape,
+	"Gather":             opGather,
+	"Slice":              opSlice,
+	"MatMul":             opMatMul,
+	"Gemm":               opGemm,
+	"Softmax":            opSoftmax,
+	"LSTM":               opLSTM,
+}
+
+func sigmoid(x float32) float32 {
+	return float32(1 / (1 + math.Exp(-float64(x))))
+}
+
+func (n *node) attrInt(name string, def int) int {
+	if a, ok := n.Attrs[name]; ok {
+		return int(a.I)
+	}
+	return def
+}
+
+func (n *node) attrFloat(name string, def float32) float32 {
+	if a, ok := n.Attrs[name]; ok {
+		return a.F
+	}
+	return def
+}
+
+func (n *node) attrInts(name string) []int {
+	a, ok := n.Attrs[name]
+	if !ok {
+		return nil
+	}
+	out := make([]int, len(a.Ints))
+	for i, v := range a.Ints {
+		out[i] = int(v)
+	}
+	return out
+}
+
+func (n *node) attrString(name, def string) string {
+	if a, ok := n.Attrs[name]; ok {
+		return a.S
+	}
+	return def
+}
+
+// validateAttrs 加载时校验会导致除零或越界的属性（步长、膨胀、核大小须为正数，填充非负，group 至少为 1）
+func validateAttrs(n *node) error {
+	for _, name := range []string{"strides", "dilations", "kernel_shape"} {
+		for _, v := range n.attrInts(name) {
+			if v <= 0 {
+				return fmt.Errorf("%s: invalid %s %v", n.OpType, name, n.attrInts(name))
+			}
+		}
+	}
+	for _, v := range n.attrInts("pads") {
+		if v < 0 || v > maxTensorElements {
+			return fmt.Errorf("%s: invalid pads %v", n.OpType, n.attrInts("pads"))
+		}
+	}
+	if n.OpType == "Conv" && n.attrInt("group", 1) < 1 {
+		return fmt.Errorf("Conv: invalid group %d", n.attrInt("group", 1))
+	}
+	return nil
+}
+
+func requireInputs(n *node, in []*Tensor, count int) error {
+	for i := 0; i < count; i++ {
+		if i >= len(in) || in[i] == nil {
+			return fmt.Errorf("%s: missing input %d", n.OpType, i)
+		}
+	}
+	return nil
+}
+
+func opIdentity(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	return []*Tensor{in[0]}, nil
+}
+
+// opCast 数值均以 float32 保存，转换为整数/布尔类型时按 ONNX 语义截断
+func opCast(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	switch n.attrInt("to", dataTypeFloat) {
+	case dataTypeBool:
+		return []*Tensor{unary(in[0], func(x float32) float32 {
+			if x != 0 {
+				return 1
+			}
+			return 0
+		})}, nil
+	case dataTypeUint8, dataTypeInt8, 4, dataTypeInt16, dataTypeInt32, dataTypeInt64, 12, 13:
+		return []*Tensor{unary(in[0], func(x float32) float32 { return float32(math.Trunc(float64(x))) })}, nil
+	}
+	return []*Tensor{in[0]}, nil
+}
+
+func opConstant(_ *opContext, n *node, _ []*Tensor) ([]*Tensor, error) {
+	if a, ok := n.Attrs["value"]; ok && a.T != nil {
+		return []*Tensor{a.T}, nil
+	}
+	if a, ok := n.Attrs["value_float"]; ok {
+		return []*Tensor{NewTensor(nil, []float32{a.F})}, nil
+	}
+	if a, ok := n.Attrs["value_int"]; ok {
+		return []*Tensor{NewTensor(nil, []float32{clampInt64(a.I)})}, nil
+	}
+	if a, ok := n.Attrs["value_floats"]; ok {
+		return []*Tensor{NewTensor([]int{len(a.Floats)}, append([]float32(nil), a.Floats...))}, nil
+	}
+	if a, ok := n.Attrs["value_ints"]; ok {
+		data := make([]float32, len(a.Ints))
+		for i, v := range a.Ints {
+			data[i] = clampInt64(v)
+		}
+		return []*Tensor{NewTensor([]int{len(data)}, data)}, nil
+	}
+	return nil, fmt.Errorf("Constant: unsupported value attribute")
+}
+
+func opConstantOfShape(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	out := NewTensor(in[0].ints(), nil)
+	if a, ok := n.Attrs["value"]; ok && a.T != nil && len(a.T.Data) > 0 {
+		for i := range out.Data {
+			out.Data[i] = a.T.Data[0]
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+func binaryOp(fn func(x, y float32) float32) opFunc {
+	return func(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+		if err := requireInputs(n, in, 2); err != nil {
+			return nil, err
+		}
+		out, err := elementwise(in[0], in[1], fn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.OpType, err)
+		}
+		return []*Tensor{out}, nil
+	}
+}
+
+func unaryOp(fn func(x float32) float32) opFunc {
+	return func(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+		if err := requireInputs(n, in, 1); err != nil {
+			return nil, err
+		}
+		return []*Tensor{unary(in[0], fn)}, nil
+	}
+}
+
+func opHardSigmoid(ctx *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	alpha, beta := n.attrFloat("alpha", 0.2), n.attrFloat("beta", 0.5)
+	return unaryOp(func(x float32) float32 { return min(max(alpha*x+beta, 0), 1) })(ctx, n, in)
+}
+
+func opLeakyRelu(ctx *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	alpha := n.attrFloat("alpha", 0.01)
+	return unaryOp(func(x float32) float32 {
+		if x < 0 {
+			return alpha * x
+		}
+		return x
+	})(ctx, n, in)
+}
+
+func opClip(ctx *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	// opset 11 起 min/max 为可选输入，之前为属性
+	lo, hi := n.attrFloat("min", -math.MaxFloat32), n.attrFloat("max", math.MaxFloat32)
+	if len(in) > 1 && in[1] != nil {
+		lo = in[1].Data[0]
+	}
+	if len(in) > 2 && in[2] != nil {
+		hi = in[2].Data[0]
+	}
+	return unaryOp(func(x float32) float32 { return min(max(x, lo), hi) })(ctx, n, in)
+}
+
+func opBatchNorm(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 5); err != nil {
+		return nil, err
+	}
+	x, scale, bias, mean, variance := in[0], in[1], in[2], in[3], in[4]
+	if len(x.Shape) < 2 {
+		return nil, fmt.Errorf("BatchNormalization: input rank %d", len(x.Shape))
+	}
+	eps := n.attrFloat("epsilon", 1e-5)
+	channels := x.Shape[1]
+	for _, t := range []*Tensor{scale, bias, mean, variance} {
+		if len(t.Data) < channels {
+			return nil, fmt.Errorf("BatchNormalization: parameter has %d values for %d channels", len(t.Data), channels)
+		}
+	}
+	inner := shapeSize(x.Shape[2:])
+	out := NewTensor(x.Shape, nil)
+	for b := 0; b < x.Shape[0]; b++ {
+		for c := 0; c < channels; c++ {
+			k := scale.Data[c] / float32(math.Sqrt(float64(variance.Data[c]+eps)))
+			shift := bias.Data[c] - mean.Data[c]*k
+			base := (b*channels + c) * inner
+			for i := 0; i < inner; i++ {
+				out.Data[base+i] = x.Data[base+i]*k + shift
+			}
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+// convPads 计算二维卷积/池化的填充（top, left, bottom, right）
+func convPads(n *node, inH, inW, kH, kW, sH, sW, dH, dW int) (int, int, int, int) {
+	pads := n.attrInts("pads")
+	autoPad := n.attrString("auto_pad", "NOTSET")
+	if autoPad == "SAME_UPPER" || autoPad == "SAME_LOWER" {
+		padFor := func(in, k, s, d int) (int, int) {
+			out := (in + s - 1) / s
+			total := max((out-1)*s+(k-1)*d+1-in, 0)
+			if autoPad == "SAME_UPPER" {
+				return total / 2, total - total/2
+			}
+			return total - total/2, total / 2
+		}
+		pt, pb := padFor(inH, kH, sH, dH)
+		pl, pr := padFor(inW, kW, sW, dW)
+		return pt, pl, pb, pr
+	}
+	if len(pads) == 4 {
+		return pads[0], pads[1], pads[2], pads[3]
+	}
+	return 0, 0, 0, 0
+}
+
+func pair(values []int, def int) (int, int) {
+	if len(values) >= 2 {
+		return values[0], values[1]
+	}
+	return def, def
+}
+
+func opConv(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 2); err != nil {
+		return nil, err
+	}
+	x, w := in[0], in[1]
+	if len(x.Shape) != 4 || len(w.Shape) != 4 {
+		return nil, fmt.Errorf("Conv: only 2D convolution is supported, got input %v weight %v", x.Shape, w.Shape)
+	}
+	var bias *Tensor
+	if len(in) > 2 {
+		bias = in[2]
+	}
+
+	batch, inC, inH, inW := x.Shape[0], x.Shape[1], x.Shape[2], x.Shape[3]
+	outC, cPerGroup, kH, kW := w.Shape[0], w.Shape[1], w.Shape[2], w.Shape[3]
+	group := n.attrInt("group", 1)
+	if cPerGroup*group != inC || outC%group != 0 {
+		return nil, fmt.Errorf("Conv: channels %d do not match weight %v with group %d", inC, w.Shape, group)
+	}
+	if bias != nil && len(bias.Data) < outC {
+		return nil, fmt.Errorf("Conv: bias has %d values for %d output channels", len(bias.Data), outC)
+	}
+	sH, sW := pair(n.attrInts("strides"), 1)
+	dH, dW := pair(n.attrInts("dilations"), 1)
+	pt, pl, pb, pr := convPads(n, inH, inW, kH, kW, sH, sW, dH, dW)
+	outH := (inH+pt+pb-((kH-1)*dH+1))/sH + 1
+	outW := (inW+pl+pr-((kW-1)*dW+1))/sW + 1
+	if outH <= 0 || outW <= 0 {
+		return nil, fmt.Errorf("Conv: empty output for input %v", x.Shape)
+	}
+
+	out := NewTensor([]int{batch, outC, outH, outW}, nil)
+	outPerGroup := outC / group
+	plane := outH * outW
+	for b := 0; b < batch; b++ {
+		for m := 0; m < outC; m++ {
+			dst := out.Data[(b*outC+m)*plane : (b*outC+m+1)*plane]
+			if bias != nil {
+				for i := range dst {
+					dst[i] = bias.Data[m]
+				}
+			}
+			g := m / outPerGroup
+			for c := 0; c < cPerGroup; c++ {
+				src := x.Data[(b*inC+g*cPerGroup+c)*inH*inW:]
+				kernel := w.Data[(m*cPerGroup+c)*kH*kW:]
+				for ky := 0; ky < kH; ky++ {
+					for kx := 0; kx < kW; kx++ {
+						wv := kernel[ky*kW+kx]
+						if wv == 0 {
+							continue
+						}
+						for oy := 0; oy < outH; oy++ {
+							iy := oy*sH - pt + ky*dH
+							if iy < 0 || iy >= inH {
+								continue
+							}
+							row := src[iy*inW : (iy+1)*inW]
+							drow := dst[oy*outW : (oy+1)*outW]
+							ix := kx*dW - pl
+							for ox := 0; ox < outW; ox, ix = ox+1, ix+sW {
+								if ix >= 0 && ix < inW {
+									drow[ox] += wv * row[ix]
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+func poolOp(average bool) opFunc {
+	return func(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+		if err := requireInputs(n, in, 1); err != nil {
+			return nil, err
+		}
+		x := in[0]
+		if len(x.Shape) != 4 {
+			return nil, fmt.Errorf("%s: only 2D pooling is supported, got %v", n.OpType, x.Shape)
+		}
+		batch, channels, inH, inW := x.Shape[0], x.Shape[1], x.Shape[2], x.Shape[3]
+		kH, kW := pair(n.attrInts("kernel_shape"), 1)
+		sH, sW := pair(n.attrInts("strides"), 1)
+		dH, dW := pair(n.attrInts("dilations"), 1)
+		pt, pl, pb, pr := convPads(n, inH, inW, kH, kW, sH, sW, dH, dW)
+		ceil := n.attrInt("ceil_mode", 0) == 1
+		includePad := n.attrInt("count_include_pad", 0) == 1
+
+		outSize := func(in, k, s, d, p0, p1 int) int {
+			span := in + p0 + p1 - ((k-1)*d + 1)
+			out := span/s + 1
+			if ceil && span%s != 0 {
+				out++
+				// 最后一个窗口必须从输入或左侧填充内开始
+				if (out-1)*s >= in+p0 {
+					out--
+				}
+			}
+			return out
+		}
+		outH := outSize(inH, kH, sH, dH, pt, pb)
+		outW := outSize(inW, kW, sW, dW, pl, pr)
+
+		out := NewTensor([]int{batch, channels, outH, outW}, nil)
+		for bc := 0; bc < batch*channels; bc++ {
+			src := x.Data[bc*inH*inW : (bc+1)*inH*inW]
+			dst := out.Data[bc*outH*outW : (bc+1)*outH*outW]
+			for oy := 0; oy < outH; oy++ {
+				for ox := 0; ox < outW; ox++ {
+					acc := float32(0)
+					if !average {
+						acc = -math.MaxFloat32
+					}
+					count := 0
+					for ky := 0; ky < kH; ky++ {
+						iy := oy*sH - pt + ky*dH
+						for kx := 0; kx < kW; kx++ {
+							ix := ox*sW - pl + kx*dW
+							if iy < 0 || iy >= inH || ix < 0 || ix >= inW {
+								// 仅统计落在 pads 内的位置（ceil_mode 多出的部分不计）
+								if includePad && iy < inH+pb && ix < inW+pr {
+									count++
+								}
+								continue
+							}
+							v := src[iy*inW+ix]
+							if average {
+								acc += v
+							} else if v > acc {
+								acc = v
+							}
+							count++
+						}
+					}
+					if average && count > 0 {
+						acc /= float32(count)
+					}
+					dst[oy*outW+ox] = acc
+				}
+			}
+		}
+		return []*Tensor{out}, nil
+	}
+}
+
+func globalPoolOp(average bool) opFunc {
+	return func(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+		if err := requireInputs(n, in, 1); err != nil {
+			return nil, err
+		}
+		x := in[0]
+		if len(x.Shape) < 3 {
+			return nil, fmt.Errorf("%s: input rank %d", n.OpType, len(x.Shape))
+		}
+		shape := append([]int(nil), x.Shape...)
+		for i := 2; i < len(shape); i++ {
+			shape[i] = 1
+		}
+		inner := shapeSize(x.Shape[2:])
+		out := NewTensor(shape, nil)
+		for bc := range out.Data {
+			src := x.Data[bc*inner : (bc+1)*inner]
+			acc := src[0]
+			if average {
+				acc = 0
+				for _, v := range src {
+					acc += v
+				}
+				acc /= float32(inner)
+			} else {
+				for _, v := range src {
+					acc = max(acc, v)
+				}
+			}
+			out.Data[bc] = acc
+		}
+		return []*Tensor{out}, nil
+	}
+}
+
+func opReshape(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	var target []int
+	if len(in) > 1 && in[1] != nil {
+		target = in[1].ints()
+	} else {
+		target = n.attrInts("shape")
+	}
+	shape := make([]int, len(target))
+	infer := -1
+	known := 1
+	for i, d := range target {
+		switch {
+		case d == 0 && n.attrInt("allowzero", 0) == 0:
+			shape[i] = x.Shape[i]
+		case d == -1:
+			infer = i
+			continue
+		default:
+			shape[i] = d
+		}
+		known *= shape[i]
+	}
+	if infer >= 0 {
+		if known == 0 {
+			return nil, fmt.Errorf("Reshape: cannot infer dimension for %v -> %v", x.Shape, target)
+		}
+		shape[infer] = x.Size() / known
+	}
+	if shapeSize(shape) != x.Size() {
+		return nil, fmt.Errorf("Reshape: %v -> %v size mismatch", x.Shape, target)
+	}
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+func opFlatten(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	axis := n.attrInt("axis", 1)
+	if axis < 0 {
+		axis += len(x.Shape)
+	}
+	outer := shapeSize(x.Shape[:axis])
+	return []*Tensor{{Shape: []int{outer, x.Size() / max(outer, 1)}, Data: x.Data}}, nil
+}
+
+func opTranspose(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	perm := n.attrInts("perm")
+	if perm == nil {
+		rank := len(in[0].Shape)
+		perm = make([]int, rank)
+		for i := range perm {
+			perm[i] = rank - 1 - i
+		}
+	}
+	out, err := transpose(in[0], perm)
+	if err != nil {
+		return nil, err
+	}
+	return []*Tensor{out}, nil
+}
+
+// axesOf opset 13 起 axes 为第二个输入，之前为属性
+func axesOf(n *node, in []*Tensor) []int {
+	if len(in) > 1 && in[1] != nil {
+		return in[1].ints()
+	}
+	return n.attrInts("axes")
+}
+
+func opSqueeze(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	drop := map[int]bool{}
+	axes := axesOf(n, in)
+	for _, a := range axes {
+		axis, err := normAxis(a, len(x.Shape))
+		if err != nil {
+			return nil, fmt.Errorf("Squeeze: %w", err)
+		}
+		drop[axis] = true
+	}
+	shape := []int{}
+	for i, d := range x.Shape {
+		if drop[i] || (len(axes) == 0 && d == 1) {
+			continue
+		}
+		shape = append(shape, d)
+	}
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+func opUnsqueeze(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	axes := axesOf(n, in)
+	rank := len(x.Shape) + len(axes)
+	insert := map[int]bool{}
+	for _, a := range axes {
+		axis, err := normAxis(a, rank)
+		if err != nil {
+			return nil, fmt.Errorf("Unsqueeze: %w", err)
+		}
+		insert[axis] = true
+	}
+	shape := make([]int, 0, rank)
+	j := 0
+	for i := 0; i < rank; i++ {
+		if insert[i] {
+			shape = append(shape, 1)
+		} else {
+			shape = append(shape, x.Shape[j])
+			j++
+		}
+	}
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+func opConcat(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	axis, err := normAxis(n.attrInt("axis", 0), len(in[0].Shape))
+	if err != nil {
+		return nil, fmt.Errorf("Concat: %w", err)
+	}
+	shape := append([]int(nil), in[0].Shape...)
+	shape[axis] = 0
+	for _, t := range in {
+		if len(t.Shape) != len(shape) {
+			return nil, fmt.Errorf("Concat: rank mismatch %v", t.Shape)
+		}
+		shape[axis] += t.Shape[axis]
+	}
+	out := NewTensor(shape, nil)
+	outer := shapeSize(shape[:axis])
+	inner := shapeSize(shape[axis+1:])
+	offset := 0
+	for o := 0; o < outer; o++ {
+		for _, t := range in {
+			chunk := t.Shape[axis] * inner
+			copy(out.Data[offset:], t.Data[o*chunk:(o+1)*chunk])
+			offset += chunk
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+func opShape(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	dims := in[0].Shape
+	rank := len(dims)
+	start, end := n.attrInt("start", 0), n.attrInt("end", rank)
+	if start < 0 {
+		start += rank
+	}
+	if end < 0 {
+		end += rank
+	}
+	start, end = min(max(start, 0), rank), min(max(end, 0), rank)
+	data := []float32{}
+	for _, d := range dims[start:max(start, end)] {
+		data = append(data, float32(d))
+	}
+	return []*Tensor{NewTensor([]int{len(data)}, data)}, nil
+}
+
+func opGather(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 2); err != nil {
+		return nil, err
+	}
+	data, indices := in[0], in[1]
+	axis, err := normAxis(n.attrInt("axis", 0), len(data.Shape))
+	if err != nil {
+		return nil, fmt.Errorf("Gather: %w", err)
+	}
+	shape := append(append(append([]int{}, data.Shape[:axis]...), indices.Shape...), data.Shape[axis+1:]...)
+	out := NewTensor(shape, nil)
+	outer := shapeSize(data.Shape[:axis])
+	inner := shapeSize(data.Shape[axis+1:])
+	dim := data.Shape[axis]
+	offset := 0
+	for o := 0; o < outer; o++ {
+		for _, idx := range indices.ints() {
+			if idx < 0 {
+				idx += dim
+			}
+			if idx < 0 || idx >= dim {
+				return nil, fmt.Errorf("Gather: index %d out of range %d", idx, dim)
+			}
+			copy(out.Data[offset:offset+inner], data.Data[(o*dim+idx)*inner:])
+			offset += inner
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+func opSlice(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	rank := len(x.Shape)
+	var starts, ends, axes, steps []int
+	if len(in) >= 3 && in[1] != nil && in[2] != nil {
+		starts, ends = in[1].ints(), in[2].ints()
+		if len(in) > 3 && in[3] != nil {
+			axes = in[3].ints()
+		}
+		if len(in) > 4 && in[4] != nil {
+			steps = in[4].ints()
+		}
+	} else {
+		starts, ends, axes = n.attrInts("starts"), n.attrInts("ends"), n.attrInts("axes")
+	}
+	if axes == nil {
+		axes = make([]int, len(starts))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+
+	begin := make([]int, rank)
+	step := make([]int, rank)
+	shape := append([]int(nil), x.Shape...)
+	for i := range step {
+		step[i] = 1
+	}
+	for i, a := range axes {
+		axis, err := normAxis(a, rank)
+		if err != nil {
+			return nil, fmt.Errorf("Slice: %w", err)
+		}
+		dim := x.Shape[axis]
+		s, e, st := starts[i], ends[i], 1
+		if steps != nil {
+			st = steps[i]
+		}
+		if st == 0 {
+			return nil, fmt.Errorf("Slice: step cannot be 0")
+		}
+		if s < 0 {
+			s += dim
+		}
+		if e < 0 {
+			e += dim
+		}
+		if st > 0 {
+			s, e = min(max(s, 0), dim), min(max(e, 0), dim)
+			shape[axis] = max((e-s+st-1)/st, 0)
+		} else {
+			s, e = min(max(s, 0), dim-1), min(max(e, -1), dim-1)
+			shape[axis] = max((s-e-st-1)/(-st), 0)
+		}
+		begin[axis], step[axis] = s, st
+	}
+
+	out := NewTensor(shape, nil)
+	src := strides(x.Shape)
+	idx := make([]int, rank)
+	for i := range out.Data {
+		offset := 0
+		for d := 0; d < rank; d++ {
+			offset += (begin[d] + idx[d]*step[d]) * src[d]
+		}
+		out.Data[i] = x.Data[offset]
+		for d := rank - 1; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < shape[d] {
+				break
+			}
+			idx[d] = 0
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+// matmul2D c[m,n] += a[m,k] * b[k,n]
+func matmul2D(a, b, c []float32, m, k, n int) {
+	for i := 0; i < m; i++ {
+		crow := c[i*n : (i+1)*n]
+		for p := 0; p < k; p++ {
+			av := a[i*k+p]
+			if av == 0 {
+				continue
+			}
+			brow := b[p*n : (p+1)*n]
+			for j := range crow {
+				crow[j] += av * brow[j]
+			}
+		}
+	}
+}
+
+func opMatMul(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 2); err != nil {
+		return nil, err
+	}
+	a, b := in[0], in[1]
+	aShape, bShape := a.Shape, b.Shape
+	if len(aShape) == 1 {
+		aShape = []int{1, aShape[0]}
+	}
+	if len(bShape) == 1 {
+		bShape = []int{bShape[0], 1}
+	}
+	m, k := aShape[len(aShape)-2], aShape[len(aShape)-1]
+	k2, cols := bShape[len(bShape)-2], bShape[len(bShape)-1]
+	if k != k2 {
+		return nil, fmt.Errorf("MatMul: %v x %v shape mismatch", a.Shape, b.Shape)
+	}
+	batchShape, err := broadcastShape(aShape[:len(aShape)-2], bShape[:len(bShape)-2])
+	if err != nil {
+		return nil, fmt.Errorf("MatMul: %w", err)
+	}
+	outShape := append(append([]int{}, batchShape...), m, cols)
+	out := NewTensor(outShape, nil)
+
+	batches := shapeSize(batchShape)
+	sa := broadcastStrides(aShape[:len(aShape)-2], batchShape)
+	sb := broadcastStrides(bShape[:len(bShape)-2], batchShape)
+	idx := make([]int, len(batchShape))
+	for bi := 0; bi < batches; bi++ {
+		offA, offB := 0, 0
+		for d := range idx {
+			offA += idx[d] * sa[d]
+			offB += idx[d] * sb[d]
+		}
+		matmul2D(a.Data[offA*m*k:], b.Data[offB*k*cols:], out.Data[bi*m*cols:(bi+1)*m*cols], m, k, cols)
+		for d := len(idx) - 1; d >= 0; d-- {
+			idx[d]++
+			if idx[d] < batchShape[d] {
+				break
+			}
+			idx[d] = 0
+		}
+	}
+
+	// 还原一维输入被提升的维度
+	if len(a.Shape) == 1 || len(b.Shape) == 1 {
+		shape := append([]int{}, batchShape...)
+		if len(a.Shape) != 1 {
+			shape = append(shape, m)
+		}
+		if len(b.Shape) != 1 {
+			shape = append(shape, cols)
+		}
+		out.Shape = shape
+	}
+	return []*Tensor{out}, nil
+}
+
+func opGemm(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 2); err != nil {
+		return nil, err
+	}
+	a, b := in[0], in[1]
+	var err error
+	if n.attrInt("transA", 0) == 1 {
+		if a, err = transpose(a, []int{1, 0}); err != nil {
+			return nil, err
+		}
+	}
+	if n.attrInt("transB", 0) == 1 {
+		if b, err = transpose(b, []int{1, 0}); err != nil {
+			return nil, err
+		}
+	}
+	m, k, cols := a.Shape[0], a.Shape[1], b.Shape[1]
+	if b.Shape[0] != k {
+		return nil, fmt.Errorf("Gemm: %v x %v shape mismatch", a.Shape, b.Shape)
+	}
+	alpha, beta := n.attrFloat("alpha", 1), n.attrFloat("beta", 1)
+	out := NewTensor([]int{m, cols}, nil)
+	matmul2D(a.Data, b.Data, out.Data, m, k, cols)
+	if alpha != 1 {
+		for i := range out.Data {
+			out.Data[i] *= alpha
+		}
+	}
+	if len(in) > 2 && in[2] != nil {
+		c := in[2]
+		if out, err = elementwise(out, c, func(x, y float32) float32 { return x + beta*y }); err != nil {
+			return nil, fmt.Errorf("Gemm: %w", err)
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+func opSoftmax(ctx *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 1); err != nil {
+		return nil, err
+	}
+	x := in[0]
+	rank := len(x.Shape)
+	defAxis := -1
+	if ctx.opset < 13 {
+		defAxis = 1
+	}
+	axis, err := normAxis(n.attrInt("axis", defAxis), rank)
+	if err != nil {
+		return nil, fmt.Errorf("Softmax: %w", err)
+	}
+
+	// opset 13 之前按 axis 将输入压成二维后在第二维上归一化
+	outer, dim, inner := shapeSize(x.Shape[:axis]), x.Shape[axis], shapeSize(x.Shape[axis+1:])
+	if ctx.opset < 13 {
+		dim, inner = dim*inner, 1
+	}
+	out := NewTensor(x.Shape, nil)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			base := o*dim*inner + i
+			maxV := float32(-math.MaxFloat32)
+			for d := 0; d < dim; d++ {
+				maxV = max(maxV, x.Data[base+d*inner])
+			}
+			sum := float64(0)
+			for d := 0; d < dim; d++ {
+				e := math.Exp(float64(x.Data[base+d*inner] - maxV))
+				out.Data[base+d*inner] = float32(e)
+				sum += e
+			}
+			for d := 0; d < dim; d++ {
+				out.Data[base+d*inner] = float32(float64(out.Data[base+d*inner]) / sum)
+			}
+		}
+	}
+	return []*Tensor{out}, nil
+}
+
+// opLSTM 标准 LSTM（门顺序 i,o,f,c，激活 sigmoid/tanh/tanh，layout=0）
+func opLSTM(_ *opContext, n *node, in []*Tensor) ([]*Tensor, error) {
+	if err := requireInputs(n, in, 3); err != nil {
+		return nil, err
+	}
+	if len(in) > 7 && in[7] != nil {
+		return nil, fmt.Errorf("LSTM: peephole weights are not supported")
+	}
+	if n.attrInt("layout", 0) != 0 || n.attrInt("input_forget", 0) != 0 {
+		return nil, fmt.Errorf("LSTM: only layout=0 without input_forget is supported")
+	}
+	if acts, ok := n.Attrs["activations"]; ok {
+		for i, act := range acts.Strings {
+			if want := []string{"Sigmoid", "Tanh", "Tanh"}[i%3]; act != want {
+				return nil, fmt.Errorf("LSTM: activation %s is not supported", act)
+			}
+		}
+	}
+	x, w, r := in[0], in[1], in[2]
+	seqLen, batch, inputSize := x.Shape[0], x.Shape[1], x.Shape[2]
+	numDir := w.Shape[0]
+	hidden := n.attrInt("hidden_size", r.Shape[2])
+	direction := strings.ToLower(n.attrString("direction", "forward"))
+	if (direction == "bidirectional") != (numDir == 2) {
+		return nil, fmt.Errorf("LSTM: direction %s does not match weights %v", direction, w.Shape)
+	}
+
+	y := NewTensor([]int{seqLen, numDir, batch, hidden}, nil)
+	yh := NewTensor([]int{numDir, batch, hidden}, nil)
+	yc := NewTensor([]int{numDir, batch, hidden}, nil)
+	gates := make([]float32, 4*hidden)
+
+	for dir := 0; dir < numDir; dir++ {
+		wd := w.Data[dir*4*hidden*inputSize:]
+		rd := r.Data[dir*4*hidden*hidden:]
+		var bias []float32
+		if len(in) > 3 && in[3] != nil {
+			bias = in[3].Data[dir*8*hidden:]
+		}
+		reverse := direction == "reverse" || dir == 1
+
+		for b := 0; b < batch; b++ {
+			h := make([]float32, hidden)
+			c := make([]float32, hidden)
+			if len(in) > 5 && in[5] != nil {
+				copy(h, in[5].Data[(dir*batch+b)*hidden:])
+			}
+			if len(in) > 6 && in[6] != nil {
+				copy(c, in[6].Data[(dir*batch+b)*hidden:])
+			}
+			for step := 0; step < seqLen; step++ {
+				t := step
+				if reverse {
+					t = seqLen - 1 - step
+				}
+				xt := x.Data[(t*batch+b)*inputSize : (t*batch+b+1)*inputSize]
+				for g := 0; g < 4*hidden; g++ {
+					sum := float32(0)
+					if bias != nil {
+						sum = bias[g] + bias[4*hidden+g]
+					}
+					wrow := wd[g*inputSize : (g+1)*inputSize]
+					for i, v := range xt {
+						sum += wrow[i] * v
+					}
+					rrow := rd[g*hidden : (g+1)*hidden]
+					for i, v := range h {
+						sum += rrow[i] * v
+					}
+					gates[g] = sum
+				}
+				for j := 0; j < hidden; j++ {
+					ig := sigmoid(gates[j])
+					og := sigmoid(gates[hidden+j])
+					fg := sigmoid(gates[2*hidden+j])
+					cg := float32(math.Tanh(float64(gates[3*hidden+j])))
+					c[j] = fg*c[j] + ig*cg
+					h[j] = og * float32(math.Tanh(float64(c[j])))
+				}
+				copy(y.Data[((t*numDir+dir)*batch+b)*hidden:], h)
+			}
+			copy(yh.Data[(dir*batch+b)*hidden:], h)
+			copy(yc.Data[(dir*batch+b)*hidden:], c)
+		}
+	}
+	return []*Tensor{y, yh, yc}, nil
+}
@@ -0,0 +1,385 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 仅解码推理所需的 ONNX protobuf 子集（字段号见 onnx/onnx.proto）
+
+// node 计算图中的一个算子
+type node struct {
+	Name    string
+	OpType  string
+	Domain  string
+	Inputs  []string
+	Outputs []string
+	Attrs   map[string]*attribute
+}
+
+// attribute 算子属性
+type attribute struct {
+	F       float32
+	I       int64
+	S       string
+	T       *Tensor
+	Floats  []float32
+	Ints    []int64
+	Strings []string
+}
+
+// graph 计算图：节点按拓扑序排列
+type graph struct {
+	Nodes        []*node
+	Initializers map[string]*Tensor
+	Inputs       []string
+	Outputs      []string
+}
+
+// maxTensorElements 单个常量张量的元素上限（64M 个 float32，即 256MB），防止损坏的模型文件耗尽内存
+const maxTensorElements = 1 << 26
+
+// TensorProto.DataType
+const (
+	dataTypeFloat  = 1
+	dataTypeUint8  = 2
+	dataTypeInt8   = 3
+	dataTypeInt16  = 5
+	dataTypeInt32  = 6
+	dataTypeInt64  = 7
+	dataTypeBool   = 9
+	dataTypeDouble = 11
+)
+
+// walkFields 遍历消息的每个字段；bytes 类型传 v，数值类型传 x
+func walkFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var (
+			v []byte
+			x uint64
+		)
+		switch typ {
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var x32 uint32
+			x32, n = protowire.ConsumeFixed32(b)
+			x = uint64(x32)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, typ, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendInts 兼容 packed 与非 packed 的 repeated int64/int32
+func appendInts(dst []int64, typ protowire.Type, v []byte, x uint64) ([]int64, error) {
+	if typ != protowire.BytesType {
+		return append(dst, int64(x)), nil
+	}
+	for len(v) > 0 {
+		val, n := protowire.ConsumeVarint(v)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		dst = append(dst, int64(val))
+		v = v[n:]
+	}
+	return dst, nil
+}
+
+// appendFloats 兼容 packed 与非 packed 的 repeated float
+func appendFloats(dst []float32, typ protowire.Type, v []byte, x uint64) []float32 {
+	if typ != protowire.BytesType {
+		return append(dst, math.Float32frombits(uint32(x)))
+	}
+	for i := 0; i+4 <= len(v); i += 4 {
+		dst = append(dst, math.Float32frombits(binary.LittleEndian.Uint32(v[i:])))
+	}
+	return dst
+}
+
+// appendDoubles 兼容 packed 与非 packed 的 repeated double
+func appendDoubles(dst []float32, typ protowire.Type, v []byte, x uint64) []float32 {
+	if typ != protowire.BytesType {
+		return append(dst, float32(math.Float64frombits(x)))
+	}
+	for i := 0; i+8 <= len(v); i += 8 {
+		dst = append(dst, float32(math.Float64frombits(binary.LittleEndian.Uint64(v[i:]))))
+	}
+	return dst
+}
+
+func parseModel(b []byte) (*graph, int64, error) {
+	var (
+		g     *graph
+		opset int64
+	)
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 7: // graph
+			parsed, err := parseGraph(v)
+			if err != nil {
+				return err
+			}
+			g = parsed
+		case 8: // opset_import
+			var domain string
+			var version int64
+			if err := walkFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				switch num {
+				case 1:
+					domain = string(v)
+				case 2:
+					version = int64(x)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if domain == "" || domain == "ai.onnx" {
+				opset = version
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if g == nil {
+		return nil, 0, errors.New("onnx: model has no graph")
+	}
+	return g, opset, nil
+}
+
+func parseGraph(b []byte) (*graph, error) {
+	g := &graph{Initializers: map[string]*Tensor{}}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1: // node
+			n, err := parseNode(v)
+			if err != nil {
+				return err
+			}
+			g.Nodes = append(g.Nodes, n)
+		case 5: // initializer
+			name, t, err := parseTensor(v)
+			if err != nil {
+				return fmt.Errorf("onnx: initializer %s: %w", name, err)
+			}
+			g.Initializers[name] = t
+		case 11, 12: // input / output (ValueInfoProto.name = 1)
+			var name string
+			if err := walkFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				if num == 1 {
+					name = string(v)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if num == 11 {
+				g.Inputs = append(g.Inputs, name)
+			} else {
+				g.Outputs = append(g.Outputs, name)
+			}
+		case 15: // sparse_initializer
+			return errors.New("onnx: sparse initializers are not supported")
+		}
+		return nil
+	})
+	return g, err
+}
+
+func parseNode(b []byte) (*node, error) {
+	n := &node{Attrs: map[string]*attribute{}}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			n.Inputs = append(n.Inputs, string(v))
+		case 2:
+			n.Outputs = append(n.Outputs, string(v))
+		case 3:
+			n.Name = string(v)
+		case 4:
+			n.OpType = string(v)
+		case 5:
+			name, attr, err := parseAttribute(v)
+			if err != nil {
+				return err
+			}
+			n.Attrs[name] = attr
+		case 7:
+			n.Domain = string(v)
+		}
+		return nil
+	})
+	return n, err
+}
+
+func parseAttribute(b []byte) (string, *attribute, error) {
+	var name string
+	attr := &attribute{}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(v)
+		case 2:
+			attr.F = math.Float32frombits(uint32(x))
+		case 3:
+			attr.I = int64(x)
+		case 4:
+			attr.S = string(v)
+		case 5:
+			_, attr.T, err = parseTensor(v)
+		case 7:
+			attr.Floats = appendFloats(attr.Floats, typ, v, x)
+		case 8:
+			attr.Ints, err = appendInts(attr.Ints, typ, v, x)
+		case 9:
+			attr.Strings = append(attr.Strings, string(v))
+		}
+		return err
+	})
+	return name, attr, err
+}
+
+// parseTensor 解码 TensorProto，数值统一转换为 float32（形状类 int64 在模型中均远小于 2^24）
+func parseTensor(b []byte) (string, *Tensor, error) {
+	var (
+		name     string
+		dims     []int64
+		dataType int64
+		raw      []byte
+		floats   []float32
+		ints     []int64
+		external bool
+	)
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		var err error
+		switch num {
+		case 1:
+			dims, err = appendInts(dims, typ, v, x)
+		case 2:
+			dataType = int64(x)
+		case 4:
+			floats = appendFloats(floats, typ, v, x)
+		case 5, 7, 11:
+			ints, err = appendInts(ints, typ, v, x)
+		case 8:
+			name = string(v)
+		case 9:
+			raw = v
+		case 10:
+			floats = appendDoubles(floats, typ, v, x)
+		case 13:
+			external = true
+		}
+		return err
+	})
+	if err != nil {
+		return name, nil, err
+	}
+	if external {
+		return name, nil, errors.New("external tensor data is not supported, export the model as a single file")
+	}
+
+	shape := make([]int, len(dims))
+	size := 1
+	for i, d := range dims {
+		if d < 0 || d > maxTensorElements {
+			return name, nil, fmt.Errorf("invalid tensor dim %d", d)
+		}
+		shape[i] = int(d)
+		if d > 0 && size > maxTensorElements/int(d) {
+			return name, nil, fmt.Errorf("tensor shape %v exceeds %d elements", dims, maxTensorElements)
+		}
+		size *= int(d)
+	}
+
+	data := make([]float32, 0, size)
+	switch {
+	case raw != nil:
+		switch dataType {
+		case dataTypeFloat:
+			for i := 0; i+4 <= len(raw); i += 4 {
+				data = append(data, math.Float32frombits(binary.LittleEndian.Uint32(raw[i:])))
+			}
+		case dataTypeDouble:
+			for i := 0; i+8 <= len(raw); i += 8 {
+				data = append(data, float32(math.Float64frombits(binary.LittleEndian.Uint64(raw[i:]))))
+			}
+		case dataTypeInt64:
+			for i := 0; i+8 <= len(raw); i += 8 {
+				data = append(data, clampInt64(int64(binary.LittleEndian.Uint64(raw[i:]))))
+			}
+		case dataTypeInt32:
+			for i := 0; i+4 <= len(raw); i += 4 {
+				data = append(data, float32(int32(binary.LittleEndian.Uint32(raw[i:]))))
+			}
+		case dataTypeInt16:
+			for i := 0; i+2 <= len(raw); i += 2 {
+				data = append(data, float32(int16(binary.LittleEndian.Uint16(raw[i:]))))
+			}
+		case dataTypeInt8:
+			for _, c := range raw {
+				data = append(data, float32(int8(c)))
+			}
+		case dataTypeUint8, dataTypeBool:
+			for _, c := range raw {
+				data = append(data, float32(c))
+			}
+		default:
+			return name, nil, fmt.Errorf("unsupported tensor data type %d", dataType)
+		}
+	case floats != nil:
+		data = floats
+	default:
+		for _, v := range ints {
+			data = append(data, clampInt64(v))
+		}
+	}
+
+	if len(data) != size {
+		return name, nil, fmt.Errorf("tensor size mismatch: shape %v, got %d values", shape, len(data))
+	}
+	return name, &Tensor{Shape: shape, Data: data}, nil
+}
+
+// clampInt64 将 int64 截断到 ±2^30 后转为 float32（Slice 常用 INT64_MAX 表示"到末尾"，截断后语义不变）
+func clampInt64(v int64) float32 {
+	const limit = 1 << 30
+	if v > limit {
+		return limit
+	}
+	if v < -limit {
+		return -limit
+	}
+	return float32(v)
+}
@@ -0,0 +1,206 @@
+package onnx
+
+import (
+	"fmt"
+)
+
+// Tensor 行主序的 float32 张量；形状类整数（Shape/Gather 的结果等）同样以 float32 保存
+type Tensor struct {
+	Shape []int
+	Data  []float32
+}
+
+// NewTensor 创建张量，data 为 nil 时按形状分配
+func NewTensor(shape []int, data []float32) *Tensor {
+	if data == nil {
+		data = make([]float32, shapeSize(shape))
+	}
+	return &Tensor{Shape: append([]int(nil), shape...), Data: data}
+}
+
+// Size 元素个数
+func (t *Tensor) Size() int {
+	return shapeSize(t.Shape)
+}
+
+// ints 将张量内容解释为整数列表（形状、轴、索引）
+func (t *Tensor) ints() []int {
+	out := make([]int, len(t.Data))
+	for i, v := range t.Data {
+		out[i] = int(v)
+	}
+	return out
+}
+
+func shapeSize(shape []int) int {
+	size := 1
+	for _, d := range shape {
+		size *= d
+	}
+	return size
+}
+
+// strides 行主序步长
+func strides(shape []int) []int {
+	st := make([]int, len(shape))
+	acc := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		st[i] = acc
+		acc *= shape[i]
+	}
+	return st
+}
+
+// normAxis 处理负数轴
+func normAxis(axis, rank int) (int, error) {
+	if axis < 0 {
+		axis += rank
+	}
+	if axis < 0 || axis >= rank {
+		return 0, fmt.Errorf("axis %d out of range for rank %d", axis, rank)
+	}
+	return axis, nil
+}
+
+// broadcastShape numpy 规则的广播形状
+func broadcastShape(a, b []int) ([]int, error) {
+	rank := len(a)
+	if len(b) > rank {
+		rank = len(b)
+	}
+	out := make([]int, rank)
+	for i := 0; i < rank; i++ {
+		da, db := 1, 1
+		if j := len(a) - rank + i; j >= 0 {
+			da = a[j]
+		}
+		if j := len(b) - rank + i; j >= 0 {
+			db = b[j]
+		}
+		switch {
+		case da == db, db == 1:
+			out[i] = da
+		case da == 1:
+			out[i] = db
+		default:
+			return nil, fmt.Errorf("cannot broadcast %v with %v", a, b)
+		}
+	}
+	return out, nil
+}
+
+// broadcastStrides 将 shape 对齐到 out 的步长，广播维度步长为0
+func broadcastStrides(shape, out []int) []int {
+	st := strides(shape)
+	res := make([]int, len(out))
+	offset := len(out) - len(shape)
+	for i := range shape {
+		if shape[i] != 1 {
+			res[offset+i] = st[i]
+		}
+	}
+	return res
+}
+
+// elementwise 逐元素二元运算（支持广播）
+func elementwise(a, b *Tensor, fn func(x, y float32) float32) (*Tensor, error) {
+	// 常见快路径：同形状或右侧为标量
+	if len(b.Data) == 1 {
+		out := NewTensor(a.Shape, nil)
+		y := b.Data[0]
+		for i, x := range a.Data {
+			out.Data[i] = fn(x, y)
+		}
+		if len(b.Shape) > len(a.Shape) {
+			out.Shape, _ = broadcastShape(a.Shape, b.Shape)
+		}
+		return out, nil
+	}
+	if equalShape(a.Shape, b.Shape) {
+		out := NewTensor(a.Shape, nil)
+		for i := range a.Data {
+			out.Data[i] = fn(a.Data[i], b.Data[i])
+		}
+		return out, nil
+	}
+
+	shape, err := broadcastShape(a.Shape, b.Shape)
+	if err != nil {
+		return nil, err
+	}
+	out := NewTensor(shape, nil)
+	sa := broadcastStrides(a.Shape, shape)
+	sb := broadcastStrides(b.Shape, shape)
+	idx := make([]int, len(shape))
+	ia, ib := 0, 0
+	for i := range out.Data {
+		out.Data[i] = fn(a.Data[ia], b.Data[ib])
+		// 多维计数器递增
+		for d := len(shape) - 1; d >= 0; d-- {
+			idx[d]++
+			ia += sa[d]
+			ib += sb[d]
+			if idx[d] < shape[d] {
+				break
+			}
+			ia -= sa[d] * shape[d]
+			ib -= sb[d] * shape[d]
+			idx[d] = 0
+		}
+	}
+	return out, nil
+}
+
+func equalShape(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// unary 逐元素一元运算
+func unary(a *Tensor, fn func(x float32) float32) *Tensor {
+	out := NewTensor(a.Shape, nil)
+	for i, x := range a.Data {
+		out.Data[i] = fn(x)
+	}
+	return out
+}
+
+// transpose 按 perm 重排维度
+func transpose(a *Tensor, perm []int) (*Tensor, error) {
+	rank := len(a.Shape)
+	if len(perm) != rank {
+		return nil, fmt.Errorf("transpose perm %v does not match rank %d", perm, rank)
+	}
+	shape := make([]int, rank)
+	for i, p := range perm {
+		shape[i] = a.Shape[p]
+	}
+	out := NewTensor(shape, nil)
+	src := strides(a.Shape)
+	st := make([]int, rank) // 输出维度 i 在输入中的步长
+	for i, p := range perm {
+		st[i] = src[p]
+	}
+	idx := make([]int, rank)
+	offset := 0
+	for i := range out.Data {
+		out.Data[i] = a.Data[offset]
+		for d := rank - 1; d >= 0; d-- {
+			idx[d]++
+			offset += st[d]
+			if idx[d] < shape[d] {
+				break
+			}
+			offset -= st[d] * shape[d]
+			idx[d] = 0
+		}
+	}
+	return out, nil
+}
@@ -196,6 +196,52 @@ func (r *AccountRepository) UpdateFromLoginData(id int, nickname string, avatarI
 	return nil
 }
 
+// FindByID 通过ID查找账号
+func (r *AccountRepository) FindByID(id int) (*model.Account, error) {
+	query := `SELECT id, fid, nickname, avatar_image, stove_lv, stove_lv_content, 
+			  is_active, is_verified, last_login_check, created_at 
+			  FROM game_accounts WHERE id = ?`
+
+	row := r.db.QueryRow(query, id)
+
+	var account model.Account
+	err := row.Scan(
+		&account.ID,
+		&account.FID,
+		&account.Nickname,
+		&account.AvatarImage,
+		&account.StoveLv,
+		&account.StoveLvContent,
+		&account.IsActive,
+		&account.IsVerified,
+		&account.LastLoginCheck,
+		&account.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 账号不存在
+		}
+		r.logger.Error("查询账号失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// UpdateActiveStatus 更新账号启用状态（停用后不再参与自动兑换）
+func (r *AccountRepository) UpdateActiveStatus(id int, isActive bool) error {
+	query := `UPDATE game_accounts SET is_active = ? WHERE id = ?`
+
+	_, err := r.db.Exec(query, isActive, id)
+	if err != nil {
+		r.logger.Error("更新账号启用状态失败", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return nil
+}
+
 // Delete 删除账号（与Node版本对齐）
 func (r *AccountRepository) Delete(id int) error {
 	const maxRetries = 3
@@ -305,6 +351,16 @@ func (r *AccountRepository) Delete(id int) error {
 				}
 				return err
 			}
+			// 分类统计无法简单扣减，按剩余日志重算
+			if err := r.refreshCategoryStats(tx, codeID); err != nil {
+				tx.Rollback()
+				if isDeadlock(err) && attempt < maxRetries {
+					r.logger.Warn("删除账号-重算分类统计发生死锁，重试", zap.Int("attempt", attempt), zap.Int("redeem_code_id", codeID))
+					time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+					continue
+				}
+				return err
+			}
 		}
 
 		if err := tx.Commit(); err != nil {
@@ -438,6 +494,16 @@ func (r *AccountRepository) BulkDelete(ids []int) (int, error) {
 				}
 				return 0, err
 			}
+			// 分类统计无法简单扣减，按剩余日志重算
+			if err := r.refreshCategoryStats(tx, codeID); err != nil {
+				tx.Rollback()
+				if isDeadlock(err) && attempt < maxRetries {
+					r.logger.Warn("批量删除-重算分类统计发生死锁，重试", zap.Int("attempt", attempt), zap.Int("redeem_code_id", codeID))
+					time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+					continue
+				}
+				return 0, err
+			}
 		}
 
 		if err := tx.Commit(); err != nil {
@@ -455,8 +521,18 @@ func (r *AccountRepository) BulkDelete(ids []int) (int, error) {
 	return 0, fmt.Errorf("批量删除在重试 %d 次后仍失败（死锁）", maxRetries)
 }
 
-// updateRedeemCodeStats 重新计算兑换码统计数据（与Node版本对齐）
-func (r *AccountRepository) updateRedeemCodeStats(tx *sql.Tx, redeemCodeID int) error {
+// refreshCategoryStats 按当前日志重算单个兑换码的分类统计
+func (r *AccountRepository) refreshCategoryStats(tx *sql.Tx, redeemCodeID int) error {
+	categoryStats, err := queryCategoryStats(tx, redeemCodeID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE redeem_codes SET category_stats = ? WHERE id = ?`, encodeCategoryStats(categoryStats), redeemCodeID)
+	return err
+}
+
+// updateRedeemCodeStats 重新计算兑换码统计数据（与Node版本对齐），返回分类统计
+func (r *AccountRepository) updateRedeemCodeStats(tx *sql.Tx, redeemCodeID int) (map[string]int, error) {
 	statsQuery := `
 		SELECT 
 			COUNT(*) as total_accounts,
@@ -471,7 +547,12 @@ func (r *AccountRepository) updateRedeemCodeStats(tx *sql.Tx, redeemCodeID int)
 	var totalAccounts, successCount, failedCount int
 	err := row.Scan(&totalAccounts, &successCount, &failedCount)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	categoryStats, err := queryCategoryStats(tx, redeemCodeID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 更新兑换码表的统计数据
@@ -480,13 +561,14 @@ func (r *AccountRepository) updateRedeemCodeStats(tx *sql.Tx, redeemCodeID int)
 		SET 
 			total_accounts = ?,
 			success_count = ?,
-			failed_count = ?
+			failed_count = ?,
+			category_stats = ?
 		WHERE id = ?
 	`
 
-	_, err = tx.Exec(updateQuery, totalAccounts, successCount, failedCount, redeemCodeID)
+	_, err = tx.Exec(updateQuery, totalAccounts, successCount, failedCount, encodeCategoryStats(categoryStats), redeemCodeID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 日志降噪：单个兑换码统计更新改为调试级别
@@ -494,17 +576,59 @@ func (r *AccountRepository) updateRedeemCodeStats(tx *sql.Tx, redeemCodeID int)
 		zap.Int("redeem_code_id", redeemCodeID),
 		zap.Int("total", totalAccounts),
 		zap.Int("success", successCount),
-		zap.Int("failed", failedCount))
+		zap.Int("failed", failedCount),
+		zap.Any("categories", categoryStats))
 
-	return nil
+	return categoryStats, nil
 }
 
-// FixAllRedeemCodeStats 修复所有兑换码的统计数据（与Node版本对齐）
-func (r *AccountRepository) FixAllRedeemCodeStats() (int, error) {
+// BackfillResultCategories 为未写入结果分类的旧日志回填分类，classify 与写入日志时使用同一规则；返回回填的日志数
+// 按 (result, err_code) 组合逐组更新，组合数很少
+func (r *AccountRepository) BackfillResultCategories(classify func(success bool, errCode int) string) (int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT result, err_code FROM redeem_logs WHERE result_category IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	type resultGroup struct {
+		result  string
+		errCode sql.NullInt64
+	}
+	var groups []resultGroup
+	for rows.Next() {
+		var g resultGroup
+		if err := rows.Scan(&g.result, &g.errCode); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	backfilled := 0
+	for _, g := range groups {
+		category := classify(g.result == "success", int(g.errCode.Int64))
+		result, err := r.db.Exec(`
+            UPDATE redeem_logs SET result_category = ?
+            WHERE result_category IS NULL AND result = ? AND err_code <=> ?`, category, g.result, g.errCode)
+		if err != nil {
+			return backfilled, err
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			backfilled += int(n)
+		}
+	}
+	return backfilled, nil
+}
+
+// FixAllRedeemCodeStats 修复所有兑换码的统计数据（与Node版本对齐），同时返回全部兑换码的分类汇总
+func (r *AccountRepository) FixAllRedeemCodeStats() (int, map[string]int, error) {
 	// 获取所有兑换码
 	rows, err := r.db.Query(`SELECT id FROM redeem_codes ORDER BY id ASC`)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 	defer rows.Close()
 
@@ -512,32 +636,37 @@ func (r *AccountRepository) FixAllRedeemCodeStats() (int, error) {
 	for rows.Next() {
 		var id int
 		if err := rows.Scan(&id); err != nil {
-			return 0, err
+			return 0, nil, err
 		}
 		redeemCodeIDs = append(redeemCodeIDs, id)
 	}
 
 	// 为每个兑换码修复统计
 	fixedCount := 0
+	totals := make(map[string]int)
 	for _, id := range redeemCodeIDs {
 		tx, err := r.db.Begin()
 		if err != nil {
-			return fixedCount, err
+			return fixedCount, totals, err
 		}
 
-		if err := r.updateRedeemCodeStats(tx, id); err != nil {
+		categoryStats, err := r.updateRedeemCodeStats(tx, id)
+		if err != nil {
 			tx.Rollback()
-			return fixedCount, err
+			return fixedCount, totals, err
 		}
 
 		if err := tx.Commit(); err != nil {
-			return fixedCount, err
+			return fixedCount, totals, err
 		}
 
 		fixedCount++
+		for category, cnt := range categoryStats {
+			totals[category] += cnt
+		}
 	}
 
 	// 日志降噪：仓储层仅输出调试级别，服务层输出摘要Info
 	r.logger.Debug("修复兑换码统计完成", zap.Int("fixed_count", fixedCount))
-	return fixedCount, nil
+	return fixedCount, totals, nil
 }
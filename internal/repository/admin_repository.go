@@ -2,10 +2,14 @@ package repository
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/utils"
 
 	"go.uber.org/zap"
 )
@@ -22,37 +26,63 @@ func NewAdminRepository(db *sql.DB, logger *zap.Logger) *AdminRepository {
 	}
 }
 
-// ValidatePassword 验证管理员密码（与Node版本对齐）
-func (r *AdminRepository) ValidatePassword(password string) (bool, error) {
-	// 使用SHA256加密输入的密码（与Node版本一致）
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := fmt.Sprintf("%x", hasher.Sum(nil))
+// ValidatePassword 验证管理员密码，返回匹配的密码ID（0表示不匹配）以及摘要是否需要升级
+// 兼容Node版本遗留的无盐SHA256摘要，新摘要统一使用 bcrypt
+func (r *AdminRepository) ValidatePassword(password string) (int, bool, error) {
+	query := `SELECT id, password_hash FROM admin_passwords WHERE is_active = TRUE ORDER BY id`
 
-	// 查询数据库中是否存在匹配的活跃密码
-	query := `SELECT id FROM admin_passwords WHERE password_hash = ? AND is_active = TRUE`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error("密码验证查询失败", zap.Error(err))
+		return 0, false, err
+	}
+	defer rows.Close()
 
-	row := r.db.QueryRow(query, hashedPassword)
-	var id int
-	err := row.Scan(&id)
+	legacyHash := legacySHA256(password)
+	for rows.Next() {
+		var (
+			id   int
+			hash string
+		)
+		if err := rows.Scan(&id, &hash); err != nil {
+			r.logger.Error("扫描管理员密码数据失败", zap.Error(err))
+			return 0, false, err
+		}
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil // 密码不匹配
+		if strings.HasPrefix(hash, "$2") {
+			if utils.CheckPassword(hash, password) {
+				return id, utils.PasswordNeedsRehash(hash), nil
+			}
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(legacyHash)) == 1 {
+			return id, true, nil
 		}
-		r.logger.Error("密码验证查询失败", zap.Error(err))
-		return false, err
 	}
 
-	return true, nil
+	return 0, false, rows.Err()
 }
 
-// CreatePassword 创建新的管理员密码（与Node版本对齐）
+// UpdatePasswordHash 以 bcrypt 重新保存密码摘要（登录成功后透明升级旧摘要）
+func (r *AdminRepository) UpdatePasswordHash(id int, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`UPDATE admin_passwords SET password_hash = ? WHERE id = ?`, hashedPassword, id)
+	if err != nil {
+		r.logger.Error("升级密码摘要失败", zap.Error(err), zap.Int("id", id))
+	}
+	return err
+}
+
+// CreatePassword 创建新的管理员密码（与Node版本对齐，摘要改用 bcrypt）
 func (r *AdminRepository) CreatePassword(password, description string) (int, error) {
-	// 使用SHA256加密密码
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := fmt.Sprintf("%x", hasher.Sum(nil))
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
 
 	query := `INSERT INTO admin_passwords (password_hash, description, is_active) VALUES (?, ?, TRUE)`
 
@@ -150,9 +180,10 @@ func (r *AdminRepository) UpdateDefaultPassword(id int, newPassword, description
 		return false, fmt.Errorf("只能更新默认密码")
 	}
 
-	hasher := sha256.New()
-	hasher.Write([]byte(newPassword))
-	hashedPassword := fmt.Sprintf("%x", hasher.Sum(nil))
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return false, err
+	}
 
 	query := `UPDATE admin_passwords SET password_hash = ?, description = ? WHERE id = ?`
 
@@ -170,13 +201,19 @@ func (r *AdminRepository) UpdateDefaultPassword(id int, newPassword, description
 	return rowsAffected > 0, nil
 }
 
+// legacySHA256 Node版本使用的无盐SHA256摘要，仅用于校验尚未升级的旧密码
+func legacySHA256(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
 // === Token 管理 ===
 
-// CreateToken 添加新token（与Node版本对齐）
-func (r *AdminRepository) CreateToken(token string, expiresAt time.Time) (int, error) {
-	query := `INSERT INTO admin_tokens (token, expires_at) VALUES (?, ?)`
+// CreateToken 保存token摘要及签发时的客户端信息，userID 为空表示旧版共享密码登录
+func (r *AdminRepository) CreateToken(tokenHash string, userID *int, role string, client model.ClientInfo, expiresAt time.Time) (int, error) {
+	query := `INSERT INTO admin_tokens (token_hash, user_id, role, created_ip, user_agent, expires_at) VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query, token, expiresAt)
+	result, err := r.db.Exec(query, tokenHash, userID, role, client.IP, client.UserAgent, expiresAt)
 	if err != nil {
 		r.logger.Error("创建token失败", zap.Error(err))
 		return 0, err
@@ -190,31 +227,162 @@ func (r *AdminRepository) CreateToken(token string, expiresAt time.Time) (int, e
 	return int(id), nil
 }
 
-// VerifyToken 验证token是否有效（与Node版本对齐）
-func (r *AdminRepository) VerifyToken(token string) (bool, error) {
-	// 使用优化的查询，利用索引
-	query := `SELECT id FROM admin_tokens WHERE token = ? AND expires_at > NOW() LIMIT 1`
+// FindPrincipal 解析token对应的管理员身份；token无效、过期或所属用户被禁用时返回 nil，查询失败时返回错误
+// 绑定用户的token以用户当前角色为准，便于降权即时生效
+func (r *AdminRepository) FindPrincipal(tokenHash string) (*model.AdminPrincipal, error) {
+	query := `
+        SELECT t.id, t.user_id, t.role, u.username, u.role, u.is_active
+        FROM admin_tokens t
+        LEFT JOIN admin_users u ON u.id = t.user_id
+        WHERE t.token_hash = ? AND t.expires_at > NOW()
+        LIMIT 1`
+
+	var (
+		tokenID   int
+		userID    sql.NullInt64
+		tokenRole string
+		username  sql.NullString
+		userRole  sql.NullString
+		isActive  sql.NullBool
+	)
+	err := r.db.QueryRow(query, tokenHash).Scan(&tokenID, &userID, &tokenRole, &username, &userRole, &isActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // token无效或已过期
+		}
+		return nil, fmt.Errorf("验证token失败: %w", err)
+	}
 
-	row := r.db.QueryRow(query, token)
-	var id int
-	err := row.Scan(&id)
+	principal := &model.AdminPrincipal{TokenID: tokenID, Role: tokenRole, Username: "legacy"}
+	if userID.Valid {
+		if !username.Valid || !isActive.Bool {
+			return nil, nil // 用户已删除或禁用
+		}
+		id := int(userID.Int64)
+		principal.UserID = &id
+		principal.Username = username.String
+		principal.Role = userRole.String
+	}
+	return principal, nil
+}
 
+// DeleteTokensByUser 删除用户的全部token（禁用/删除用户时调用）
+func (r *AdminRepository) DeleteTokensByUser(userID int) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM admin_tokens WHERE user_id = ?`, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil // token无效或已过期
+		r.logger.Error("删除用户token失败", zap.Error(err), zap.Int("user_id", userID))
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// TouchToken 更新token最近使用时间（一分钟内重复请求不再写库）
+func (r *AdminRepository) TouchToken(id int) error {
+	query := `UPDATE admin_tokens SET last_used_at = NOW()
+	          WHERE id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE)`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// ListSessions 获取管理员未过期的会话，userID 为空时返回共享密码登录签发的会话
+func (r *AdminRepository) ListSessions(userID *int) ([]model.AdminToken, error) {
+	query := `SELECT id, user_id, role, created_ip, user_agent, last_used_at, expires_at, created_at
+	          FROM admin_tokens WHERE expires_at > NOW() AND ` + sessionOwnerCond(userID) + `
+	          ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, sessionOwnerArgs(userID)...)
+	if err != nil {
+		r.logger.Error("查询会话列表失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.AdminToken
+	for rows.Next() {
+		var (
+			session model.AdminToken
+			owner   sql.NullInt64
+		)
+		err := rows.Scan(
+			&session.ID,
+			&owner,
+			&session.Role,
+			&session.CreatedIP,
+			&session.UserAgent,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("扫描会话数据失败", zap.Error(err))
+			return nil, err
 		}
-		r.logger.Error("验证token失败", zap.Error(err))
-		return false, nil // token验证失败时默认返回false，不阻断流程
+		if owner.Valid {
+			id := int(owner.Int64)
+			session.UserID = &id
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession 删除指定会话（仅限本人的会话）
+func (r *AdminRepository) DeleteSession(id int, userID *int) (bool, error) {
+	query := `DELETE FROM admin_tokens WHERE id = ? AND ` + sessionOwnerCond(userID)
+
+	result, err := r.db.Exec(query, append([]interface{}{id}, sessionOwnerArgs(userID)...)...)
+	if err != nil {
+		r.logger.Error("删除会话失败", zap.Error(err), zap.Int("id", id))
+		return false, err
 	}
 
-	return true, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteSessions 删除管理员的全部会话，userID 为空时删除全部共享密码登录会话
+func (r *AdminRepository) DeleteSessions(userID *int) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM admin_tokens WHERE `+sessionOwnerCond(userID), sessionOwnerArgs(userID)...)
+	if err != nil {
+		r.logger.Error("删除会话失败", zap.Error(err))
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+func sessionOwnerCond(userID *int) string {
+	if userID == nil {
+		return "user_id IS NULL"
+	}
+	return "user_id = ?"
+}
+
+func sessionOwnerArgs(userID *int) []interface{} {
+	if userID == nil {
+		return nil
+	}
+	return []interface{}{*userID}
 }
 
-// DeleteToken 删除token
-func (r *AdminRepository) DeleteToken(token string) (bool, error) {
-	query := `DELETE FROM admin_tokens WHERE token = ?`
+// DeleteToken 按摘要删除token（退出登录）
+func (r *AdminRepository) DeleteToken(tokenHash string) (bool, error) {
+	query := `DELETE FROM admin_tokens WHERE token_hash = ?`
 
-	result, err := r.db.Exec(query, token)
+	result, err := r.db.Exec(query, tokenHash)
 	if err != nil {
 		r.logger.Error("删除token失败", zap.Error(err))
 		return false, err
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// === 具名管理员账号 ===
+
+const adminUserColumns = `id, username, password_hash, role, is_active, last_login_at, created_at, updated_at`
+
+func scanAdminUser(scanner interface{ Scan(...interface{}) error }) (*model.AdminUser, error) {
+	var user model.AdminUser
+	err := scanner.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.IsActive,
+		&user.LastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser 创建管理员账号（passwordHash 为 bcrypt 摘要）
+func (r *AdminRepository) CreateUser(username, passwordHash, role string) (int, error) {
+	query := `INSERT INTO admin_users (username, password_hash, role, is_active) VALUES (?, ?, ?, TRUE)`
+
+	result, err := r.db.Exec(query, username, passwordHash, role)
+	if err != nil {
+		r.logger.Error("创建管理员账号失败", zap.Error(err), zap.String("username", username))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("管理员账号创建成功", zap.Int64("id", id), zap.String("username", username), zap.String("role", role))
+	return int(id), nil
+}
+
+// FindUserByUsername 通过用户名查找管理员，不存在时返回 nil
+func (r *AdminRepository) FindUserByUsername(username string) (*model.AdminUser, error) {
+	row := r.db.QueryRow(`SELECT `+adminUserColumns+` FROM admin_users WHERE username = ?`, username)
+	user, err := scanAdminUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询管理员账号失败", zap.Error(err), zap.String("username", username))
+		return nil, err
+	}
+	return user, nil
+}
+
+// FindUserByID 通过ID查找管理员，不存在时返回 nil
+func (r *AdminRepository) FindUserByID(id int) (*model.AdminUser, error) {
+	row := r.db.QueryRow(`SELECT `+adminUserColumns+` FROM admin_users WHERE id = ?`, id)
+	user, err := scanAdminUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询管理员账号失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetAllUsers 获取全部管理员账号
+func (r *AdminRepository) GetAllUsers() ([]model.AdminUser, error) {
+	rows, err := r.db.Query(`SELECT ` + adminUserColumns + ` FROM admin_users ORDER BY created_at ASC`)
+	if err != nil {
+		r.logger.Error("查询管理员账号列表失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			r.logger.Error("扫描管理员账号失败", zap.Error(err))
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+// UpdateUser 更新管理员角色与启用状态
+func (r *AdminRepository) UpdateUser(id int, role string, isActive bool) (bool, error) {
+	result, err := r.db.Exec(`UPDATE admin_users SET role = ?, is_active = ? WHERE id = ?`, role, isActive, id)
+	if err != nil {
+		r.logger.Error("更新管理员账号失败", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// UpdateUserPassword 更新管理员密码摘要
+func (r *AdminRepository) UpdateUserPassword(id int, passwordHash string) error {
+	_, err := r.db.Exec(`UPDATE admin_users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		r.logger.Error("更新管理员密码失败", zap.Error(err), zap.Int("id", id))
+	}
+	return err
+}
+
+// TouchUserLogin 记录最近登录时间
+func (r *AdminRepository) TouchUserLogin(id int) error {
+	_, err := r.db.Exec(`UPDATE admin_users SET last_login_at = NOW() WHERE id = ?`, id)
+	return err
+}
+
+// DeleteUser 删除管理员账号
+func (r *AdminRepository) DeleteUser(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM admin_users WHERE id = ?`, id)
+	if err != nil {
+		r.logger.Error("删除管理员账号失败", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// CountActiveOwners 统计启用中的 owner 数量（防止移除最后一个 owner）
+func (r *AdminRepository) CountActiveOwners() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM admin_users WHERE role = ? AND is_active = TRUE`, model.AdminRoleOwner).Scan(&count)
+	if err != nil {
+		r.logger.Error("统计owner数量失败", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// APIKeyRepository 自动化客户端API Key仓储（表由DBA手动创建，见 scripts/create_api_keys_table.sql）
+type APIKeyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, logger *zap.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, allowed_ips, is_active, expires_at, last_used_at, last_used_ip, created_by, created_at, updated_at`
+
+func scanAPIKey(scanner interface{ Scan(...interface{}) error }) (*model.APIKey, error) {
+	var (
+		key        model.APIKey
+		scopes     sql.NullString
+		allowedIPs sql.NullString
+		lastUsedIP sql.NullString
+		createdBy  sql.NullInt64
+	)
+	err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.KeyPrefix,
+		&key.KeyHash,
+		&scopes,
+		&allowedIPs,
+		&key.IsActive,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&lastUsedIP,
+		&createdBy,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = decodeStringList(scopes)
+	key.AllowedIPs = decodeStringList(allowedIPs)
+	key.LastUsedIP = lastUsedIP.String
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.CreatedBy = &id
+	}
+	return &key, nil
+}
+
+// encodeStringList 将字符串列表编码为JSON列值
+func encodeStringList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+// decodeStringList 解析JSON列值，空值或格式错误时返回空列表
+func decodeStringList(value sql.NullString) []string {
+	list := []string{}
+	if value.Valid && value.String != "" {
+		_ = json.Unmarshal([]byte(value.String), &list)
+	}
+	return list
+}
+
+// CreateKey 保存API Key（仅保存摘要）
+func (r *APIKeyRepository) CreateKey(key *model.APIKey) (int, error) {
+	query := `INSERT INTO api_keys (name, key_prefix, key_hash, scopes, allowed_ips, is_active, expires_at, created_by)
+	          VALUES (?, ?, ?, ?, ?, TRUE, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		key.Name,
+		key.KeyPrefix,
+		key.KeyHash,
+		encodeStringList(key.Scopes),
+		encodeStringList(key.AllowedIPs),
+		key.ExpiresAt,
+		key.CreatedBy,
+	)
+	if err != nil {
+		r.logger.Error("创建API Key失败", zap.Error(err), zap.String("name", key.Name))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// FindByHash 按摘要查找API Key，不存在时返回 nil（是否启用/过期由调用方判断）
+func (r *APIKeyRepository) FindByHash(keyHash string) (*model.APIKey, error) {
+	row := r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ? LIMIT 1`, keyHash)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询API Key失败", zap.Error(err))
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindByID 按ID查找API Key，不存在时返回 nil
+func (r *APIKeyRepository) FindByID(id int) (*model.APIKey, error) {
+	row := r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询API Key失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetAllKeys 获取全部API Key
+func (r *APIKeyRepository) GetAllKeys() ([]model.APIKey, error) {
+	rows, err := r.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		r.logger.Error("查询API Key列表失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.Error("扫描API Key数据失败", zap.Error(err))
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+// UpdateKey 更新API Key的名称、权限、IP白名单、过期时间与启用状态
+func (r *APIKeyRepository) UpdateKey(key *model.APIKey) (bool, error) {
+	query := `UPDATE api_keys SET name = ?, scopes = ?, allowed_ips = ?, expires_at = ?, is_active = ? WHERE id = ?`
+
+	result, err := r.db.Exec(query,
+		key.Name,
+		encodeStringList(key.Scopes),
+		encodeStringList(key.AllowedIPs),
+		key.ExpiresAt,
+		key.IsActive,
+		key.ID,
+	)
+	if err != nil {
+		r.logger.Error("更新API Key失败", zap.Error(err), zap.Int("id", key.ID))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteKey 删除API Key
+func (r *APIKeyRepository) DeleteKey(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM api_keys WHERE id = ?`, id)
+	if err != nil {
+		r.logger.Error("删除API Key失败", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// TouchKey 记录最近使用时间与来源IP（一分钟内同IP重复请求不再写库）
+func (r *APIKeyRepository) TouchKey(id int, ip string) error {
+	query := `UPDATE api_keys SET last_used_at = NOW(), last_used_ip = ?, updated_at = updated_at
+	          WHERE id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE OR last_used_ip <> ?)`
+	_, err := r.db.Exec(query, ip, id, ip)
+	return err
+}
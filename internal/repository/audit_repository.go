@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// AuditRepository 管理操作审计记录（表由DBA手动创建，见 scripts/create_audit_events_table.sql）
+type AuditRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logger *zap.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateEvent 写入一条审计记录
+func (r *AuditRepository) CreateEvent(e *model.AuditEvent) (int64, error) {
+	query := `
+        INSERT INTO audit_events
+        (actor_user_id, actor_name, actor_role, actor_token_id, action, method, path, target_ids,
+         request_summary, result, status_code, error_message, ip, user_agent)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	res, err := r.db.Exec(query,
+		e.ActorUserID,
+		e.ActorName,
+		e.ActorRole,
+		e.ActorTokenID,
+		e.Action,
+		e.Method,
+		e.Path,
+		e.TargetIDs,
+		e.RequestSummary,
+		e.Result,
+		e.StatusCode,
+		e.ErrorMessage,
+		e.IP,
+		e.UserAgent,
+	)
+	if err != nil {
+		r.logger.Error("写入审计记录失败", zap.Error(err), zap.String("action", e.Action))
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ListEvents 按条件查询审计记录（按时间倒序）
+func (r *AuditRepository) ListEvents(filter model.AuditEventFilter) ([]model.AuditEvent, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.Actor != "" {
+		conds = append(conds, "actor_name = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Result != "" {
+		conds = append(conds, "result = ?")
+		args = append(args, filter.Result)
+	}
+	if filter.Target != "" {
+		// target_ids 以逗号分隔存储，前后补逗号后精确匹配单个ID
+		conds = append(conds, "CONCAT(',', target_ids, ',') LIKE ?")
+		args = append(args, "%,"+filter.Target+",%")
+	}
+	if filter.Since != nil {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		conds = append(conds, "created_at <= ?")
+		args = append(args, *filter.Until)
+	}
+
+	query := `SELECT id, actor_user_id, actor_name, actor_role, actor_token_id, action, method, path, target_ids,
+                     request_summary, result, status_code, error_message, ip, user_agent, created_at
+              FROM audit_events`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("查询审计记录失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.AuditEvent, 0)
+	for rows.Next() {
+		var e model.AuditEvent
+		err := rows.Scan(
+			&e.ID,
+			&e.ActorUserID,
+			&e.ActorName,
+			&e.ActorRole,
+			&e.ActorTokenID,
+			&e.Action,
+			&e.Method,
+			&e.Path,
+			&e.TargetIDs,
+			&e.RequestSummary,
+			&e.Result,
+			&e.StatusCode,
+			&e.ErrorMessage,
+			&e.IP,
+			&e.UserAgent,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("扫描审计记录失败", zap.Error(err))
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -64,3 +65,8 @@ func (d *Database) Close() error {
 func (d *Database) Ping() error {
 	return d.db.Ping()
 }
+
+// PingContext 带超时的健康检查
+func (d *Database) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
@@ -221,6 +221,24 @@ func (r *JobRepository) CleanOldJobs(olderThan time.Duration) (int, error) {
 	return int(rowsAffected), nil
 }
 
+// GetOldestPendingCreatedAt 获取已到执行时间但仍未处理的最早任务创建时间（无积压时返回nil）
+func (r *JobRepository) GetOldestPendingCreatedAt() (*time.Time, error) {
+	var oldest sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT MIN(created_at)
+		FROM jobs
+		WHERE status = 'pending' AND next_run_at <= NOW()
+	`).Scan(&oldest)
+	if err != nil {
+		r.logger.Error("查询最早待处理任务失败", zap.Error(err))
+		return nil, err
+	}
+	if !oldest.Valid {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
+
 // GetJobStats 获取任务统计信息
 func (r *JobRepository) GetJobStats() (map[string]int, error) {
 	query := `
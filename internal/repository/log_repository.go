@@ -7,6 +7,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// resultCategoryColumn 读取日志的结果分类（写入日志时由 client.ClassifyRedeemResult 确定；
+// 旧日志回填前为空，回填见 AccountRepository.BackfillResultCategories）
+const resultCategoryColumn = `COALESCE(result_category, '')`
+
 type LogRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -26,6 +30,7 @@ func (r *LogRepository) CreateRedeemLog(
 	fid string,
 	code string,
 	result string,
+	resultCategory string,
 	errorMessage *string,
 	successMessage *string,
 	captchaRecognized *string,
@@ -34,8 +39,8 @@ func (r *LogRepository) CreateRedeemLog(
 ) (int, error) {
 	query := `
 		INSERT INTO redeem_logs 
-		(redeem_code_id, game_account_id, fid, code, result, error_message, success_message, captcha_recognized, processing_time, err_code) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(redeem_code_id, game_account_id, fid, code, result, result_category, error_message, success_message, captcha_recognized, processing_time, err_code) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result_db, err := r.db.Exec(query,
@@ -44,6 +49,7 @@ func (r *LogRepository) CreateRedeemLog(
 		fid,
 		code,
 		result,
+		resultCategory,
 		errorMessage,
 		successMessage,
 		captchaRecognized,
@@ -75,6 +81,7 @@ func (r *LogRepository) ReplaceRedeemLog(
 	fid string,
 	code string,
 	result string,
+	resultCategory string,
 	errorMessage *string,
 	successMessage *string,
 	captchaRecognized *string,
@@ -97,8 +104,8 @@ func (r *LogRepository) ReplaceRedeemLog(
 	// 插入新记录
 	insQuery := `
         INSERT INTO redeem_logs 
-        (redeem_code_id, game_account_id, fid, code, result, error_message, success_message, captcha_recognized, processing_time, err_code) 
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        (redeem_code_id, game_account_id, fid, code, result, result_category, error_message, success_message, captcha_recognized, processing_time, err_code) 
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
 	res, err := tx.Exec(insQuery,
 		redeemCodeID,
@@ -106,6 +113,7 @@ func (r *LogRepository) ReplaceRedeemLog(
 		fid,
 		code,
 		result,
+		resultCategory,
 		errorMessage,
 		successMessage,
 		captchaRecognized,
@@ -131,7 +139,7 @@ func (r *LogRepository) ReplaceRedeemLog(
 // GetLogsByRedeemCodeID 获取兑换码的所有日志（与Node版本对齐）
 func (r *LogRepository) GetLogsByRedeemCodeID(redeemCodeID int) ([]model.RedeemLog, error) {
 	query := `
-        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
         FROM redeem_logs rl
         LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -157,6 +165,7 @@ func (r *LogRepository) GetLogsByRedeemCodeID(redeemCodeID int) ([]model.RedeemL
 			&log.Nickname,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -177,7 +186,7 @@ func (r *LogRepository) GetLogsByRedeemCodeID(redeemCodeID int) ([]model.RedeemL
 // GetLogsByAccountID 获取账号的兑换历史（与Node版本对齐）
 func (r *LogRepository) GetLogsByAccountID(accountID int) ([]model.RedeemLog, error) {
 	query := `
-        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
         FROM redeem_logs rl
         LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -203,6 +212,7 @@ func (r *LogRepository) GetLogsByAccountID(accountID int) ([]model.RedeemLog, er
 			&log.Nickname,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -223,7 +233,7 @@ func (r *LogRepository) GetLogsByAccountID(accountID int) ([]model.RedeemLog, er
 // GetRecentLogs 获取最近的兑换记录（与Node版本对齐）
 func (r *LogRepository) GetRecentLogs(limit int) ([]model.RedeemLog, error) {
 	query := `
-        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
         FROM redeem_logs rl
         LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -247,6 +257,7 @@ func (r *LogRepository) GetRecentLogs(limit int) ([]model.RedeemLog, error) {
 			&log.Nickname,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -274,12 +285,12 @@ func (r *LogRepository) GetRecentLogsFiltered(limit int, result string) ([]model
 
 	if result == "" {
 		// 无过滤时与 GetRecentLogs 一致
-		query = `SELECT id, redeem_code_id, game_account_id, fid, code, result, error_message, success_message,
+		query = `SELECT id, redeem_code_id, game_account_id, fid, code, result, ` + resultCategoryColumn + `, error_message, success_message,
                  captcha_recognized, processing_time, err_code, redeemed_at
                  FROM redeem_logs ORDER BY redeemed_at DESC LIMIT ?`
 		rows, err = r.db.Query(query, limit)
 	} else {
-		query = `SELECT id, redeem_code_id, game_account_id, fid, code, result, error_message, success_message,
+		query = `SELECT id, redeem_code_id, game_account_id, fid, code, result, ` + resultCategoryColumn + `, error_message, success_message,
                  captcha_recognized, processing_time, err_code, redeemed_at
                  FROM redeem_logs WHERE result = ? ORDER BY redeemed_at DESC LIMIT ?`
 		rows, err = r.db.Query(query, result, limit)
@@ -300,6 +311,7 @@ func (r *LogRepository) GetRecentLogsFiltered(limit int, result string) ([]model
 			&log.FID,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -319,7 +331,7 @@ func (r *LogRepository) GetRecentLogsFiltered(limit int, result string) ([]model
 // GetAllLogs 获取全部兑换记录（去除分页）
 func (r *LogRepository) GetAllLogs() ([]model.RedeemLog, error) {
 	query := `
-        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+        SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
         FROM redeem_logs rl
         LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -343,6 +355,7 @@ func (r *LogRepository) GetAllLogs() ([]model.RedeemLog, error) {
 			&log.Nickname,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -369,7 +382,7 @@ func (r *LogRepository) GetAllLogsFiltered(result string) ([]model.RedeemLog, er
 
 	if result == "" {
 		query = `
-            SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+            SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                    rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
             FROM redeem_logs rl
             LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -377,7 +390,7 @@ func (r *LogRepository) GetAllLogsFiltered(result string) ([]model.RedeemLog, er
 		rows, err = r.db.Query(query)
 	} else {
 		query = `
-            SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result,
+            SELECT rl.id, rl.redeem_code_id, rl.game_account_id, rl.fid, ga.nickname, rl.code, rl.result, ` + resultCategoryColumn + `,
                    rl.error_message, rl.success_message, rl.captcha_recognized, rl.processing_time, rl.err_code, rl.redeemed_at
             FROM redeem_logs rl
             LEFT JOIN game_accounts ga ON ga.id = rl.game_account_id
@@ -401,6 +414,7 @@ func (r *LogRepository) GetAllLogsFiltered(result string) ([]model.RedeemLog, er
 			&log.Nickname,
 			&log.Code,
 			&log.Result,
+			&log.ResultCategory,
 			&log.ErrorMessage,
 			&log.SuccessMessage,
 			&log.CaptchaRecognized,
@@ -494,3 +508,56 @@ func (r *LogRepository) GetGlobalLogStats() (total, success, failed int, err err
 	}
 	return total, success, failed, nil
 }
+
+// sqlQuerier 兼容 *sql.DB 与 *sql.Tx 的查询接口（统计在事务内外均可复用）
+type sqlQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryCategoryStats 按结果分类聚合日志数量（未回填分类的旧日志不计入）；redeemCodeID<=0 时统计全局
+func queryCategoryStats(q sqlQuerier, redeemCodeID int) (map[string]int, error) {
+	query := `SELECT result_category, COUNT(*) FROM redeem_logs WHERE result_category IS NOT NULL`
+	var args []interface{}
+	if redeemCodeID > 0 {
+		query += ` AND redeem_code_id = ?`
+		args = append(args, redeemCodeID)
+	}
+	query += ` GROUP BY result_category`
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var cnt int
+		if err := rows.Scan(&category, &cnt); err != nil {
+			return nil, err
+		}
+		stats[category] = cnt
+	}
+	return stats, rows.Err()
+}
+
+// GetLogCategoryStats 获取兑换码按结果分类的统计
+func (r *LogRepository) GetLogCategoryStats(redeemCodeID int) (map[string]int, error) {
+	stats, err := queryCategoryStats(r.db, redeemCodeID)
+	if err != nil {
+		r.logger.Error("获取兑换分类统计失败", zap.Error(err), zap.Int("redeem_code_id", redeemCodeID))
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetGlobalCategoryStats 获取全局按结果分类的统计（不按兑换码）
+func (r *LogRepository) GetGlobalCategoryStats() (map[string]int, error) {
+	stats, err := queryCategoryStats(r.db, 0)
+	if err != nil {
+		r.logger.Error("获取全局兑换分类统计失败", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
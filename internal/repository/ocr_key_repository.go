@@ -21,7 +21,7 @@ func NewOCRKeyRepository(db *sql.DB, logger *zap.Logger) *OCRKeyRepository {
 
 // ListAll 返回全部 Key（可用于管理端列表）
 func (r *OCRKeyRepository) ListAll() ([]model.OCRKey, error) {
-	query := `SELECT id, provider, name, api_key, secret_key, is_active, has_quota, monthly_quota, remaining_quota, weight, success_count, fail_count, last_error, last_used_at, created_at, updated_at
+	query := `SELECT id, provider, name, api_key, secret_key, api_key_hint, options, is_active, has_quota, disabled_reason, monthly_quota, remaining_quota, daily_quota, remaining_daily_quota, weight, qps_limit, success_count, fail_count, verified_correct, verified_wrong, last_error, last_used_at, created_at, updated_at
               FROM ocr_keys ORDER BY id ASC`
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -34,10 +34,10 @@ func (r *OCRKeyRepository) ListAll() ([]model.OCRKey, error) {
 	for rows.Next() {
 		var k model.OCRKey
 		var lastUsedAt sql.NullTime
-		var lastError sql.NullString
+		var lastError, options sql.NullString
 		if err := rows.Scan(
-			&k.ID, &k.Provider, &k.Name, &k.APIKey, &k.SecretKey, &k.IsActive, &k.HasQuota, &k.MonthlyQuota, &k.RemainingQuota, &k.Weight,
-			&k.SuccessCount, &k.FailCount, &lastError, &lastUsedAt, &k.CreatedAt, &k.UpdatedAt,
+			&k.ID, &k.Provider, &k.Name, &k.APIKey, &k.SecretKey, &k.APIKeyHint, &options, &k.IsActive, &k.HasQuota, &k.DisabledReason, &k.MonthlyQuota, &k.RemainingQuota, &k.DailyQuota, &k.RemainingDailyQuota, &k.Weight, &k.QPSLimit,
+			&k.SuccessCount, &k.FailCount, &k.VerifiedCorrect, &k.VerifiedWrong, &lastError, &lastUsedAt, &k.CreatedAt, &k.UpdatedAt,
 		); err != nil {
 			r.logger.Error("扫描 OCR Key 失败", zap.Error(err))
 			return nil, err
@@ -50,6 +50,10 @@ func (r *OCRKeyRepository) ListAll() ([]model.OCRKey, error) {
 			s := lastError.String
 			k.LastError = &s
 		}
+		if options.Valid {
+			s := options.String
+			k.Options = &s
+		}
 		keys = append(keys, k)
 	}
 	return keys, nil
@@ -57,7 +61,7 @@ func (r *OCRKeyRepository) ListAll() ([]model.OCRKey, error) {
 
 // ListUsable 返回可参与调度的 Key
 func (r *OCRKeyRepository) ListUsable() ([]model.OCRKey, error) {
-	query := `SELECT id, provider, name, api_key, secret_key, is_active, has_quota, monthly_quota, remaining_quota, weight, success_count, fail_count, last_error, last_used_at, created_at, updated_at
+	query := `SELECT id, provider, name, api_key, secret_key, api_key_hint, options, is_active, has_quota, disabled_reason, monthly_quota, remaining_quota, daily_quota, remaining_daily_quota, weight, qps_limit, success_count, fail_count, verified_correct, verified_wrong, last_error, last_used_at, created_at, updated_at
               FROM ocr_keys WHERE is_active = TRUE AND has_quota = TRUE ORDER BY id ASC`
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -70,10 +74,10 @@ func (r *OCRKeyRepository) ListUsable() ([]model.OCRKey, error) {
 	for rows.Next() {
 		var k model.OCRKey
 		var lastUsedAt sql.NullTime
-		var lastError sql.NullString
+		var lastError, options sql.NullString
 		if err := rows.Scan(
-			&k.ID, &k.Provider, &k.Name, &k.APIKey, &k.SecretKey, &k.IsActive, &k.HasQuota, &k.MonthlyQuota, &k.RemainingQuota, &k.Weight,
-			&k.SuccessCount, &k.FailCount, &lastError, &lastUsedAt, &k.CreatedAt, &k.UpdatedAt,
+			&k.ID, &k.Provider, &k.Name, &k.APIKey, &k.SecretKey, &k.APIKeyHint, &options, &k.IsActive, &k.HasQuota, &k.DisabledReason, &k.MonthlyQuota, &k.RemainingQuota, &k.DailyQuota, &k.RemainingDailyQuota, &k.Weight, &k.QPSLimit,
+			&k.SuccessCount, &k.FailCount, &k.VerifiedCorrect, &k.VerifiedWrong, &lastError, &lastUsedAt, &k.CreatedAt, &k.UpdatedAt,
 		); err != nil {
 			r.logger.Error("扫描可用 OCR Key 失败", zap.Error(err))
 			return nil, err
@@ -86,6 +90,10 @@ func (r *OCRKeyRepository) ListUsable() ([]model.OCRKey, error) {
 			s := lastError.String
 			k.LastError = &s
 		}
+		if options.Valid {
+			s := options.String
+			k.Options = &s
+		}
 		keys = append(keys, k)
 	}
 	return keys, nil
@@ -93,9 +101,10 @@ func (r *OCRKeyRepository) ListUsable() ([]model.OCRKey, error) {
 
 // Create 新增 Key
 func (r *OCRKeyRepository) Create(k model.OCRKey) (int, error) {
-	query := `INSERT INTO ocr_keys (provider, name, api_key, secret_key, is_active, has_quota, monthly_quota, remaining_quota, weight)
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := r.db.Exec(query, k.Provider, k.Name, k.APIKey, k.SecretKey, k.IsActive, k.HasQuota, k.MonthlyQuota, k.RemainingQuota, k.Weight)
+	query := `INSERT INTO ocr_keys (provider, name, api_key, secret_key, api_key_hint, options, is_active, has_quota, disabled_reason, monthly_quota, remaining_quota, daily_quota, remaining_daily_quota, weight, qps_limit)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := r.db.Exec(query, k.Provider, k.Name, k.APIKey, k.SecretKey, k.APIKeyHint, k.Options, k.IsActive, k.HasQuota, k.DisabledReason, k.MonthlyQuota, k.RemainingQuota,
+		k.DailyQuota, k.RemainingDailyQuota, k.Weight, k.QPSLimit)
 	if err != nil {
 		r.logger.Error("创建 OCR Key 失败", zap.Error(err))
 		return 0, err
@@ -107,11 +116,12 @@ func (r *OCRKeyRepository) Create(k model.OCRKey) (int, error) {
 	return int(id64), nil
 }
 
-// Update 更新部分字段（name/is_active/has_quota/weight）
+// Update 更新部分字段（name/options/is_active/has_quota/weight/qps_limit 等）
 func (r *OCRKeyRepository) Update(id int, patch map[string]interface{}) error {
 	// 简化：拼接动态 SQL（只允许已知字段）
 	allowed := map[string]bool{
-		"name": true, "is_active": true, "has_quota": true, "monthly_quota": true, "remaining_quota": true, "weight": true,
+		"name": true, "options": true, "is_active": true, "has_quota": true, "disabled_reason": true, "monthly_quota": true, "remaining_quota": true,
+		"daily_quota": true, "remaining_daily_quota": true, "weight": true, "qps_limit": true,
 	}
 	sets := make([]string, 0, len(patch))
 	args := make([]interface{}, 0, len(patch)+1)
@@ -143,27 +153,100 @@ func (r *OCRKeyRepository) Delete(id int) error {
 	return err
 }
 
-// MarkQuota 设置额度状态
-func (r *OCRKeyRepository) MarkQuota(id int, hasQuota bool) error {
-	_, err := r.db.Exec("UPDATE ocr_keys SET has_quota = ?, updated_at = NOW() WHERE id = ?", hasQuota, id)
+// MarkQuota 设置额度状态及停用原因（启用时清除原因）
+func (r *OCRKeyRepository) MarkQuota(id int, hasQuota bool, reason string) error {
+	if hasQuota {
+		reason = ""
+	}
+	// 已因鉴权/手动停用的 Key 再次报告额度错误时保留原因，避免被额度重置恢复
+	query := `UPDATE ocr_keys
+              SET disabled_reason = CASE WHEN has_quota OR ? <> 'quota' THEN ? ELSE disabled_reason END,
+                  has_quota = ?, updated_at = NOW()
+              WHERE id = ?`
+	_, err := r.db.Exec(query, reason, reason, hasQuota, id)
 	if err != nil {
 		r.logger.Error("更新 OCR Key 额度失败", zap.Error(err), zap.Int("id", id))
 	}
 	return err
 }
 
-// TouchUsage 更新使用统计
-func (r *OCRKeyRepository) TouchUsage(id int, success bool, errMsg *string) error {
-	if success {
-		_, err := r.db.Exec("UPDATE ocr_keys SET success_count = success_count + 1, last_used_at = ?, last_error = NULL, updated_at = NOW() WHERE id = ?", time.Now(), id)
-		if err != nil {
-			r.logger.Error("更新 OCR Key 成功统计失败", zap.Error(err), zap.Int("id", id))
+// UpdateCredentials 替换凭据密文（主密钥轮换时重新加密）
+func (r *OCRKeyRepository) UpdateCredentials(id int, apiKey, secretKey, hint string) error {
+	_, err := r.db.Exec("UPDATE ocr_keys SET api_key = ?, secret_key = ?, api_key_hint = ?, updated_at = NOW() WHERE id = ?", apiKey, secretKey, hint, id)
+	if err != nil {
+		r.logger.Error("更新 OCR Key 凭据失败", zap.Error(err), zap.Int("id", id))
+	}
+	return err
+}
+
+// OCRUsageDelta 一段时间内累积的使用统计，由 ApplyUsage 一次写入
+type OCRUsageDelta struct {
+	Success    int
+	Fail       int
+	Billed     int     // 计费调用次数（扣减额度）
+	LastError  *string // 最后一次调用的错误，成功时为空
+	LastUsedAt time.Time
+}
+
+// ApplyUsage 累加使用统计并扣减额度（仅扣减设置了额度的周期），任一额度降至0时 has_quota=false 并记录停用原因 quota
+// 注意 MySQL 按顺序求值 SET 子句，disabled_reason 与 has_quota 需在扣减前根据原值判断（已因其他原因停用的保留原因）
+func (r *OCRKeyRepository) ApplyUsage(id int, d OCRUsageDelta) error {
+	query := `UPDATE ocr_keys
+              SET disabled_reason = CASE WHEN has_quota AND ? > 0 AND ((monthly_quota IS NOT NULL AND remaining_quota - ? <= 0) OR (daily_quota IS NOT NULL AND remaining_daily_quota - ? <= 0))
+                                         THEN 'quota' ELSE disabled_reason END,
+                  has_quota = CASE WHEN disabled_reason = 'quota' THEN FALSE ELSE has_quota END,
+                  remaining_quota = CASE WHEN monthly_quota IS NOT NULL THEN GREATEST(remaining_quota - ?, 0) ELSE remaining_quota END,
+                  remaining_daily_quota = CASE WHEN daily_quota IS NOT NULL THEN GREATEST(remaining_daily_quota - ?, 0) ELSE remaining_daily_quota END,
+                  success_count = success_count + ?,
+                  fail_count = fail_count + ?,
+                  last_used_at = ?,
+                  last_error = ?,
+                  updated_at = NOW()
+              WHERE id = ?`
+	_, err := r.db.Exec(query, d.Billed, d.Billed, d.Billed, d.Billed, d.Billed, d.Success, d.Fail, d.LastUsedAt, d.LastError, id)
+	if err != nil {
+		r.logger.Error("更新 OCR Key 使用统计失败", zap.Error(err), zap.Int("id", id))
+	}
+	return err
+}
+
+// GetQuotaStates 查询指定 Key 的额度状态
+func (r *OCRKeyRepository) GetQuotaStates(ids []int) ([]model.OCRKey, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := r.db.Query(`SELECT id, provider, name, has_quota, monthly_quota, remaining_quota, daily_quota, remaining_daily_quota
+              FROM ocr_keys WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		r.logger.Error("查询 OCR Key 额度失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []model.OCRKey
+	for rows.Next() {
+		var k model.OCRKey
+		if err := rows.Scan(&k.ID, &k.Provider, &k.Name, &k.HasQuota, &k.MonthlyQuota, &k.RemainingQuota, &k.DailyQuota, &k.RemainingDailyQuota); err != nil {
+			return nil, err
 		}
-		return err
+		keys = append(keys, k)
 	}
-	_, err := r.db.Exec("UPDATE ocr_keys SET fail_count = fail_count + 1, last_used_at = ?, last_error = ?, updated_at = NOW() WHERE id = ?", time.Now(), errMsg, id)
+	return keys, rows.Err()
+}
+
+// RecordVerdict 累加游戏判定次数（correct=false 表示提交的验证码被判定为错误）
+func (r *OCRKeyRepository) RecordVerdict(id int, correct bool) error {
+	column := "verified_wrong"
+	if correct {
+		column = "verified_correct"
+	}
+	_, err := r.db.Exec("UPDATE ocr_keys SET "+column+" = "+column+" + 1 WHERE id = ?", id)
 	if err != nil {
-		r.logger.Error("更新 OCR Key 失败统计失败", zap.Error(err), zap.Int("id", id))
+		r.logger.Error("更新 OCR Key 判定统计失败", zap.Error(err), zap.Int("id", id))
 	}
 	return err
 }
@@ -182,25 +265,31 @@ func (r *OCRKeyRepository) GetProviderByID(id int) (string, error) {
 	return strings.ToLower(strings.TrimSpace(provider)), nil
 }
 
-// DecrementQuota 扣减一次额度；若降至0则自动 has_quota=false
-func (r *OCRKeyRepository) DecrementQuota(id int) error {
-	query := `UPDATE ocr_keys 
-              SET remaining_quota = CASE WHEN remaining_quota > 0 THEN remaining_quota - 1 ELSE 0 END,
-                  has_quota = CASE WHEN remaining_quota - 1 <= 0 THEN FALSE ELSE has_quota END,
-                  updated_at = NOW()
-              WHERE id = ?`
-	if _, err := r.db.Exec(query, id); err != nil {
-		r.logger.Error("扣减OCR Key额度失败", zap.Error(err), zap.Int("id", id))
+// ResetMonthlyQuota 将剩余额度重置为每月额度；仅恢复因额度停用且当日额度未用尽的 Key（不改变 is_active 与其他原因的停用）
+func (r *OCRKeyRepository) ResetMonthlyQuota() error {
+	query := `UPDATE ocr_keys
+              SET remaining_quota = COALESCE(monthly_quota, remaining_quota),
+                  has_quota = CASE WHEN disabled_reason = 'quota' AND COALESCE(monthly_quota, 1) > 0 AND (daily_quota IS NULL OR remaining_daily_quota > 0)
+                                   THEN TRUE ELSE has_quota END,
+                  disabled_reason = CASE WHEN has_quota THEN '' ELSE disabled_reason END,
+                  updated_at = NOW()`
+	if _, err := r.db.Exec(query); err != nil {
+		r.logger.Error("重置OCR Key月额度失败", zap.Error(err))
 		return err
 	}
 	return nil
 }
 
-// ResetMonthlyQuota 将剩余额度重置为每月额度，并启用 has_quota=true（不改变 is_active）
-func (r *OCRKeyRepository) ResetMonthlyQuota() error {
-	query := `UPDATE ocr_keys SET remaining_quota = monthly_quota, has_quota = (monthly_quota > 0), updated_at = NOW()`
+// ResetDailyQuota 将当日剩余额度重置为每日额度；仅恢复因额度停用且当月额度未用尽的 Key（不改变 is_active 与其他原因的停用）
+func (r *OCRKeyRepository) ResetDailyQuota() error {
+	query := `UPDATE ocr_keys
+              SET remaining_daily_quota = COALESCE(daily_quota, remaining_daily_quota),
+                  has_quota = CASE WHEN disabled_reason = 'quota' AND COALESCE(daily_quota, 1) > 0 AND (monthly_quota IS NULL OR remaining_quota > 0)
+                                   THEN TRUE ELSE has_quota END,
+                  disabled_reason = CASE WHEN has_quota THEN '' ELSE disabled_reason END,
+                  updated_at = NOW()`
 	if _, err := r.db.Exec(query); err != nil {
-		r.logger.Error("重置OCR Key月额度失败", zap.Error(err))
+		r.logger.Error("重置OCR Key日额度失败", zap.Error(err))
 		return err
 	}
 	return nil
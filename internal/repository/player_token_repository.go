@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// PlayerTokenRepository 玩家访问令牌仓储（表由DBA手动创建，见 scripts/create_player_tokens_table.sql）
+type PlayerTokenRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPlayerTokenRepository(db *sql.DB, logger *zap.Logger) *PlayerTokenRepository {
+	return &PlayerTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateToken 保存玩家token摘要
+func (r *PlayerTokenRepository) CreateToken(gameAccountID int, fid, tokenHash string, expiresAt time.Time) (int, error) {
+	query := `INSERT INTO player_tokens (game_account_id, fid, token_hash, expires_at) VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, gameAccountID, fid, tokenHash, expiresAt)
+	if err != nil {
+		r.logger.Error("创建玩家token失败", zap.Error(err), zap.String("fid", fid))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// FindValidToken 按摘要查找未过期的玩家token，不存在时返回 nil
+func (r *PlayerTokenRepository) FindValidToken(tokenHash string) (*model.PlayerToken, error) {
+	query := `SELECT id, game_account_id, fid, token_hash, expires_at, last_used_at, created_at
+	          FROM player_tokens WHERE token_hash = ? AND expires_at > NOW() LIMIT 1`
+
+	row := r.db.QueryRow(query, tokenHash)
+
+	var token model.PlayerToken
+	err := row.Scan(
+		&token.ID,
+		&token.GameAccountID,
+		&token.FID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询玩家token失败", zap.Error(err))
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// TouchToken 更新token最近使用时间
+func (r *PlayerTokenRepository) TouchToken(id int) error {
+	_, err := r.db.Exec(`UPDATE player_tokens SET last_used_at = NOW() WHERE id = ?`, id)
+	return err
+}
+
+// DeleteToken 删除单个token（退出登录）
+func (r *PlayerTokenRepository) DeleteToken(tokenHash string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM player_tokens WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		r.logger.Error("删除玩家token失败", zap.Error(err))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteByAccountID 删除账号的全部token（账号移除时调用）
+func (r *PlayerTokenRepository) DeleteByAccountID(gameAccountID int) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM player_tokens WHERE game_account_id = ?`, gameAccountID)
+	if err != nil {
+		r.logger.Error("删除账号token失败", zap.Error(err), zap.Int("account_id", gameAccountID))
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// CleanExpiredTokens 清理过期的玩家token
+func (r *PlayerTokenRepository) CleanExpiredTokens() (int, error) {
+	result, err := r.db.Exec(`DELETE FROM player_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		r.logger.Error("清理过期玩家token失败", zap.Error(err))
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
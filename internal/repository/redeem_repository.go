@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"wjdr-backend-go/internal/model"
 
@@ -41,7 +42,7 @@ func (r *RedeemRepository) CreateRedeemCode(code string, isLong bool) (int, erro
 
 // GetAllRedeemCodes 获取兑换码列表（与Node版本对齐）
 func (r *RedeemRepository) GetAllRedeemCodes(limit, offset int) ([]model.RedeemCode, error) {
-	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at 
+	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at, category_stats 
 	          FROM redeem_codes ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
 	rows, err := r.db.Query(query, limit, offset)
@@ -54,6 +55,7 @@ func (r *RedeemRepository) GetAllRedeemCodes(limit, offset int) ([]model.RedeemC
 	var codes []model.RedeemCode
 	for rows.Next() {
 		var code model.RedeemCode
+		var categoryStats sql.NullString
 		err := rows.Scan(
 			&code.ID,
 			&code.Code,
@@ -63,11 +65,13 @@ func (r *RedeemRepository) GetAllRedeemCodes(limit, offset int) ([]model.RedeemC
 			&code.SuccessCount,
 			&code.FailedCount,
 			&code.CreatedAt,
+			&categoryStats,
 		)
 		if err != nil {
 			r.logger.Error("扫描兑换码数据失败", zap.Error(err))
 			return nil, err
 		}
+		code.CategoryStats = decodeCategoryStats(categoryStats)
 		codes = append(codes, code)
 	}
 
@@ -76,7 +80,7 @@ func (r *RedeemRepository) GetAllRedeemCodes(limit, offset int) ([]model.RedeemC
 
 // GetAllRedeemCodesAll 获取全部兑换码（不分页）
 func (r *RedeemRepository) GetAllRedeemCodesAll() ([]model.RedeemCode, error) {
-	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at 
+	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at, category_stats 
               FROM redeem_codes ORDER BY created_at DESC`
 
 	rows, err := r.db.Query(query)
@@ -89,6 +93,7 @@ func (r *RedeemRepository) GetAllRedeemCodesAll() ([]model.RedeemCode, error) {
 	var codes []model.RedeemCode
 	for rows.Next() {
 		var code model.RedeemCode
+		var categoryStats sql.NullString
 		err := rows.Scan(
 			&code.ID,
 			&code.Code,
@@ -98,11 +103,13 @@ func (r *RedeemRepository) GetAllRedeemCodesAll() ([]model.RedeemCode, error) {
 			&code.SuccessCount,
 			&code.FailedCount,
 			&code.CreatedAt,
+			&categoryStats,
 		)
 		if err != nil {
 			r.logger.Error("扫描兑换码数据失败", zap.Error(err))
 			return nil, err
 		}
+		code.CategoryStats = decodeCategoryStats(categoryStats)
 		codes = append(codes, code)
 	}
 	return codes, nil
@@ -110,12 +117,13 @@ func (r *RedeemRepository) GetAllRedeemCodesAll() ([]model.RedeemCode, error) {
 
 // FindRedeemCodeByID 通过ID查找兑换码
 func (r *RedeemRepository) FindRedeemCodeByID(id int) (*model.RedeemCode, error) {
-	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at 
+	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at, category_stats 
 	          FROM redeem_codes WHERE id = ?`
 
 	row := r.db.QueryRow(query, id)
 
 	var code model.RedeemCode
+	var categoryStats sql.NullString
 	err := row.Scan(
 		&code.ID,
 		&code.Code,
@@ -125,6 +133,7 @@ func (r *RedeemRepository) FindRedeemCodeByID(id int) (*model.RedeemCode, error)
 		&code.SuccessCount,
 		&code.FailedCount,
 		&code.CreatedAt,
+		&categoryStats,
 	)
 
 	if err != nil {
@@ -135,17 +144,19 @@ func (r *RedeemRepository) FindRedeemCodeByID(id int) (*model.RedeemCode, error)
 		return nil, err
 	}
 
+	code.CategoryStats = decodeCategoryStats(categoryStats)
 	return &code, nil
 }
 
 // FindRedeemCodeByCode 通过兑换码字符串查找
 func (r *RedeemRepository) FindRedeemCodeByCode(code string) (*model.RedeemCode, error) {
-	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at 
+	query := `SELECT id, code, status, is_long, total_accounts, success_count, failed_count, created_at, category_stats 
 	          FROM redeem_codes WHERE code = ?`
 
 	row := r.db.QueryRow(query, code)
 
 	var redeemCode model.RedeemCode
+	var categoryStats sql.NullString
 	err := row.Scan(
 		&redeemCode.ID,
 		&redeemCode.Code,
@@ -155,6 +166,7 @@ func (r *RedeemRepository) FindRedeemCodeByCode(code string) (*model.RedeemCode,
 		&redeemCode.SuccessCount,
 		&redeemCode.FailedCount,
 		&redeemCode.CreatedAt,
+		&categoryStats,
 	)
 
 	if err != nil {
@@ -165,6 +177,7 @@ func (r *RedeemRepository) FindRedeemCodeByCode(code string) (*model.RedeemCode,
 		return nil, err
 	}
 
+	redeemCode.CategoryStats = decodeCategoryStats(categoryStats)
 	return &redeemCode, nil
 }
 
@@ -181,11 +194,11 @@ func (r *RedeemRepository) UpdateRedeemCodeStatus(id int, status string, totalAc
 	return nil
 }
 
-// UpdateRedeemCodeStats 更新兑换码统计
-func (r *RedeemRepository) UpdateRedeemCodeStats(id int, successCount, failedCount, totalAccounts int) error {
-	query := `UPDATE redeem_codes SET success_count = ?, failed_count = ?, total_accounts = ? WHERE id = ?`
+// UpdateRedeemCodeStats 更新兑换码统计（categoryStats 为按结果分类的计数）
+func (r *RedeemRepository) UpdateRedeemCodeStats(id int, successCount, failedCount, totalAccounts int, categoryStats map[string]int) error {
+	query := `UPDATE redeem_codes SET success_count = ?, failed_count = ?, total_accounts = ?, category_stats = ? WHERE id = ?`
 
-	_, err := r.db.Exec(query, successCount, failedCount, totalAccounts, id)
+	_, err := r.db.Exec(query, successCount, failedCount, totalAccounts, encodeCategoryStats(categoryStats), id)
 	if err != nil {
 		r.logger.Error("更新兑换码统计失败", zap.Error(err), zap.Int("id", id))
 		return err
@@ -323,3 +336,27 @@ func (r *RedeemRepository) GetCompletedRedeemCodes() ([]model.RedeemCode, error)
 
 	return codes, nil
 }
+
+// encodeCategoryStats 将分类计数序列化为 JSON 列值（空统计写入 NULL）
+func encodeCategoryStats(stats map[string]int) interface{} {
+	if len(stats) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+// decodeCategoryStats 解析 category_stats 列（NULL 或非法 JSON 时返回空统计）
+func decodeCategoryStats(raw sql.NullString) map[string]int {
+	stats := make(map[string]int)
+	if !raw.Valid || raw.String == "" {
+		return stats
+	}
+	if err := json.Unmarshal([]byte(raw.String), &stats); err != nil {
+		return make(map[string]int)
+	}
+	return stats
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+// SignNonceRepository 已使用签名的持久化记录（多实例部署时共享防重放状态）
+type SignNonceRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSignNonceRepository(db *sql.DB, logger *zap.Logger) *SignNonceRepository {
+	return &SignNonceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UseNonce 登记签名；签名已存在时返回 false（即重放）
+func (r *SignNonceRepository) UseNonce(nonce string, expiresAt time.Time) (bool, error) {
+	_, err := r.db.Exec(`INSERT INTO sign_nonces (nonce, expires_at) VALUES (?, ?)`, nonce, expiresAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		// 1062: Duplicate entry
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return false, nil
+		}
+		r.logger.Error("登记签名nonce失败", zap.Error(err))
+		return false, err
+	}
+	return true, nil
+}
+
+// CleanExpired 清理已过期的签名记录
+func (r *SignNonceRepository) CleanExpired() (int, error) {
+	result, err := r.db.Exec(`DELETE FROM sign_nonces WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
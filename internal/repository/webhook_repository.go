@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"database/sql"
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// WebhookRepository 出站Webhook订阅与投递记录仓储（表由DBA手动创建，见 scripts/create_webhooks_tables.sql）
+type WebhookRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const webhookColumns = `id, name, url, secret, events, is_active, created_by, created_at, updated_at`
+
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*model.Webhook, error) {
+	var (
+		hook      model.Webhook
+		events    sql.NullString
+		createdBy sql.NullInt64
+	)
+	err := scanner.Scan(
+		&hook.ID,
+		&hook.Name,
+		&hook.URL,
+		&hook.Secret,
+		&events,
+		&hook.IsActive,
+		&createdBy,
+		&hook.CreatedAt,
+		&hook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hook.Events = decodeStringList(events)
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		hook.CreatedBy = &id
+	}
+	return &hook, nil
+}
+
+// CreateWebhook 保存Webhook订阅
+func (r *WebhookRepository) CreateWebhook(hook *model.Webhook) (int, error) {
+	query := `INSERT INTO webhooks (name, url, secret, events, is_active, created_by) VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		hook.Name,
+		hook.URL,
+		hook.Secret,
+		encodeStringList(hook.Events),
+		hook.IsActive,
+		hook.CreatedBy,
+	)
+	if err != nil {
+		r.logger.Error("创建Webhook失败", zap.Error(err), zap.String("name", hook.Name))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// FindByID 按ID查找Webhook，不存在时返回 nil
+func (r *WebhookRepository) FindByID(id int) (*model.Webhook, error) {
+	row := r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id)
+	hook, err := scanWebhook(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查询Webhook失败", zap.Error(err), zap.Int("id", id))
+		return nil, err
+	}
+	return hook, nil
+}
+
+// GetAllWebhooks 获取全部Webhook
+func (r *WebhookRepository) GetAllWebhooks() ([]model.Webhook, error) {
+	return r.queryWebhooks(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at DESC`)
+}
+
+// GetActiveWebhooks 获取启用中的Webhook
+func (r *WebhookRepository) GetActiveWebhooks() ([]model.Webhook, error) {
+	return r.queryWebhooks(`SELECT ` + webhookColumns + ` FROM webhooks WHERE is_active = TRUE ORDER BY id`)
+}
+
+func (r *WebhookRepository) queryWebhooks(query string) ([]model.Webhook, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error("查询Webhook列表失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []model.Webhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			r.logger.Error("扫描Webhook数据失败", zap.Error(err))
+			return nil, err
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook 更新Webhook的名称、地址、事件过滤与启用状态（密钥不变）
+func (r *WebhookRepository) UpdateWebhook(hook *model.Webhook) (bool, error) {
+	query := `UPDATE webhooks SET name = ?, url = ?, events = ?, is_active = ? WHERE id = ?`
+
+	result, err := r.db.Exec(query,
+		hook.Name,
+		hook.URL,
+		encodeStringList(hook.Events),
+		hook.IsActive,
+		hook.ID,
+	)
+	if err != nil {
+		r.logger.Error("更新Webhook失败", zap.Error(err), zap.Int("id", hook.ID))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteWebhook 删除Webhook及其投递记录
+func (r *WebhookRepository) DeleteWebhook(id int) (bool, error) {
+	if _, err := r.db.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		r.logger.Error("删除Webhook投递记录失败", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	result, err := r.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		r.logger.Error("删除Webhook失败", zap.Error(err), zap.Int("id", id))
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// CreateDelivery 新建一条待投递记录
+func (r *WebhookRepository) CreateDelivery(webhookID int, event, payload string) (int64, error) {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, status, attempts) VALUES (?, ?, ?, ?, 0)`
+
+	result, err := r.db.Exec(query, webhookID, event, payload, model.WebhookDeliveryPending)
+	if err != nil {
+		r.logger.Error("创建Webhook投递记录失败", zap.Error(err), zap.Int("webhook_id", webhookID))
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateDelivery 记录一次投递尝试的结果；status 为 success 时同时写入送达时间
+func (r *WebhookRepository) UpdateDelivery(id int64, status string, attempts int, responseStatus *int, errorMessage *string) error {
+	query := `UPDATE webhook_deliveries
+	          SET status = ?, attempts = ?, response_status = ?, error_message = ?,
+	              delivered_at = CASE WHEN ? = 'success' THEN NOW() ELSE delivered_at END
+	          WHERE id = ?`
+
+	if _, err := r.db.Exec(query, status, attempts, responseStatus, errorMessage, status, id); err != nil {
+		r.logger.Error("更新Webhook投递记录失败", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+	return nil
+}
+
+// GetDeliveries 获取某个Webhook最近的投递记录
+func (r *WebhookRepository) GetDeliveries(webhookID, limit int) ([]model.WebhookDelivery, error) {
+	query := `SELECT id, webhook_id, event, payload, status, attempts, response_status, error_message, created_at, delivered_at
+	          FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?`
+	return r.queryDeliveries(query, webhookID, limit)
+}
+
+// GetPendingDeliveries 获取全部未完成的投递记录（按创建顺序）
+func (r *WebhookRepository) GetPendingDeliveries() ([]model.WebhookDelivery, error) {
+	query := `SELECT id, webhook_id, event, payload, status, attempts, response_status, error_message, created_at, delivered_at
+	          FROM webhook_deliveries WHERE status = ? ORDER BY id ASC`
+	return r.queryDeliveries(query, model.WebhookDeliveryPending)
+}
+
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]model.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		r.logger.Error("查询Webhook投递记录失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d              model.WebhookDelivery
+			responseStatus sql.NullInt64
+			errorMessage   sql.NullString
+		)
+		if err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&responseStatus,
+			&errorMessage,
+			&d.CreatedAt,
+			&d.DeliveredAt,
+		); err != nil {
+			r.logger.Error("扫描Webhook投递记录失败", zap.Error(err))
+			return nil, err
+		}
+		if responseStatus.Valid {
+			code := int(responseStatus.Int64)
+			d.ResponseStatus = &code
+		}
+		if errorMessage.Valid {
+			msg := errorMessage.String
+			d.ErrorMessage = &msg
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
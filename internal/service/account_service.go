@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/logging"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
 
@@ -14,6 +16,7 @@ import (
 type AccountService struct {
 	accountRepo *repository.AccountRepository
 	gameClient  *client.GameClient
+	webhooks    *WebhookService
 	logger      *zap.Logger
 }
 
@@ -29,13 +32,19 @@ func NewAccountService(
 	}
 }
 
+// SetWebhookService 设置出站Webhook（账号验证失效时通知）
+func (s *AccountService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
 // GetAllAccounts 获取所有账号（与Node版本对齐）
 func (s *AccountService) GetAllAccounts() ([]model.Account, error) {
 	return s.accountRepo.GetAll()
 }
 
 // CreateAccount 创建新账号（与Node版本对齐）
-func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
+func (s *AccountService) CreateAccount(ctx context.Context, fid string) (*model.APIResponse, error) {
+	ctx, log := logging.With(ctx, s.logger, zap.String("fid", fid))
 	if fid == "" {
 		return &model.APIResponse{
 			Success: false,
@@ -46,7 +55,7 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 	// 检查账号是否已存在
 	existingAccount, err := s.accountRepo.FindByFID(fid)
 	if err != nil {
-		s.logger.Error("查询账号失败", zap.Error(err))
+		log.Error("查询账号失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "查询账号失败",
@@ -62,11 +71,11 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 	}
 
 	// 验证账号有效性（与Node版本逻辑一致）
-	s.logger.Info("🔍 验证账号", zap.String("fid", fid))
+	log.Info("🔍 验证账号")
 
-	loginResult, err := s.gameClient.Login(fid)
+	loginResult, err := s.gameClient.Login(ctx, fid)
 	if err != nil {
-		s.logger.Error("账号验证异常", zap.Error(err))
+		log.Error("账号验证异常", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "验证账号时发生异常",
@@ -74,8 +83,7 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 	}
 
 	if !loginResult.Success {
-		s.logger.Warn("账号验证失败",
-			zap.String("fid", fid),
+		log.Warn("账号验证失败",
 			zap.String("error", loginResult.Error))
 
 		return &model.APIResponse{
@@ -117,7 +125,7 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 	// 创建账号
 	accountID, err := s.accountRepo.Create(fid, nickname, avatarImage, stoveLv, stoveLvContent)
 	if err != nil {
-		s.logger.Error("创建账号失败", zap.Error(err))
+		log.Error("创建账号失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "创建账号失败",
@@ -127,16 +135,15 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 	// 获取创建的账号信息
 	account, err := s.accountRepo.FindByFID(fid)
 	if err != nil {
-		s.logger.Error("获取新创建的账号失败", zap.Error(err))
+		log.Error("获取新创建的账号失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "获取账号信息失败",
 		}, err
 	}
 
-	s.logger.Info("✅ 账号创建成功",
+	log.Info("✅ 账号创建成功",
 		zap.Int("id", accountID),
-		zap.String("fid", fid),
 		zap.String("nickname", nickname))
 
 	return &model.APIResponse{
@@ -147,7 +154,8 @@ func (s *AccountService) CreateAccount(fid string) (*model.APIResponse, error) {
 }
 
 // VerifyAccount 手动验证账号（与Node版本对齐）
-func (s *AccountService) VerifyAccount(id int) (*model.APIResponse, error) {
+func (s *AccountService) VerifyAccount(ctx context.Context, id int) (*model.APIResponse, error) {
+	log := logging.FromContext(ctx, s.logger)
 	// 先获取账号信息
 	accounts, err := s.accountRepo.GetAll()
 	if err != nil {
@@ -172,14 +180,13 @@ func (s *AccountService) VerifyAccount(id int) (*model.APIResponse, error) {
 		}, nil
 	}
 
-	s.logger.Info("🔍 手动验证账号",
-		zap.Int("id", id),
-		zap.String("fid", targetAccount.FID))
+	ctx, log = logging.With(ctx, s.logger, zap.String("fid", targetAccount.FID))
+	log.Info("🔍 手动验证账号", zap.Int("id", id))
 
 	// 验证账号
-	loginResult, err := s.gameClient.Login(targetAccount.FID)
+	loginResult, err := s.gameClient.Login(ctx, targetAccount.FID)
 	if err != nil {
-		s.logger.Error("账号验证异常", zap.Error(err))
+		log.Error("账号验证异常", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "验证账号时发生异常",
@@ -218,24 +225,31 @@ func (s *AccountService) VerifyAccount(id int) (*model.APIResponse, error) {
 		}
 	} else {
 		if err := s.accountRepo.UpdateVerifyStatus(id, false); err != nil {
-			s.logger.Error("更新验证状态失败", zap.Error(err))
+			log.Error("更新验证状态失败", zap.Error(err))
 			return &model.APIResponse{Success: false, Error: "更新验证状态失败"}, err
 		}
+		// 原本已验证的账号验证失败后不再参与兑换，通知运维关注
+		if targetAccount.IsVerified {
+			s.webhooks.Notify(model.WebhookEventAccountDisabled, map[string]interface{}{
+				"account_id": targetAccount.ID,
+				"fid":        targetAccount.FID,
+				"nickname":   targetAccount.Nickname,
+				"reason":     loginResult.Error,
+			})
+		}
 	}
 
 	if loginResult.Success {
-		s.logger.Info("✅ 账号验证成功",
-			zap.Int("id", id),
-			zap.String("fid", targetAccount.FID))
+		log.Info("✅ 账号验证成功",
+			zap.Int("id", id))
 
 		return &model.APIResponse{
 			Success: true,
 			Message: "账号验证成功",
 		}, nil
 	} else {
-		s.logger.Warn("❌ 账号验证失败",
+		log.Warn("❌ 账号验证失败",
 			zap.Int("id", id),
-			zap.String("fid", targetAccount.FID),
 			zap.String("error", loginResult.Error))
 
 		return &model.APIResponse{
@@ -316,11 +330,23 @@ func (s *AccountService) BulkDeleteAccounts(ids []int) (*model.APIResponse, erro
 	}, nil
 }
 
-// FixAllStats 修复所有兑换码统计（与Node版本对齐）
+// FixAllStats 修复所有兑换码统计（与Node版本对齐），先为旧日志回填结果分类
 func (s *AccountService) FixAllStats() (*model.APIResponse, error) {
 	s.logger.Info("🔧 开始修复所有兑换码统计")
 
-	fixedCount, err := s.accountRepo.FixAllRedeemCodeStats()
+	backfilled, err := s.accountRepo.BackfillResultCategories(client.ClassifyRedeemResult)
+	if err != nil {
+		s.logger.Error("回填兑换结果分类失败", zap.Error(err))
+		return &model.APIResponse{
+			Success: false,
+			Error:   "修复统计失败",
+		}, err
+	}
+	if backfilled > 0 {
+		s.logger.Info("回填兑换结果分类", zap.Int("count", backfilled))
+	}
+
+	fixedCount, categoryTotals, err := s.accountRepo.FixAllRedeemCodeStats()
 	if err != nil {
 		s.logger.Error("修复统计失败", zap.Error(err))
 		return &model.APIResponse{
@@ -329,13 +355,15 @@ func (s *AccountService) FixAllStats() (*model.APIResponse, error) {
 		}, err
 	}
 
-	s.logger.Info("✅ 修复统计完成", zap.Int("fixed_count", fixedCount))
+	s.logger.Info("✅ 修复统计完成", zap.Int("fixed_count", fixedCount), zap.Any("category_totals", categoryTotals))
 
 	return &model.APIResponse{
 		Success: true,
 		Message: fmt.Sprintf("已修复 %d 个兑换码的统计数据", fixedCount),
 		Data: map[string]interface{}{
-			"fixed_count": fixedCount,
+			"fixed_count":     fixedCount,
+			"backfilled_logs": backfilled,
+			"category_totals": categoryTotals,
 		},
 	}, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/utils"
 
 	"go.uber.org/zap"
 )
@@ -16,9 +17,17 @@ type AdminService struct {
 	adminRepo      *repository.AdminRepository
 	AccountService *AccountService
 	CronService    *CronService
+	legacy         LegacyLoginOptions
+	auditService   *AuditService
 	logger         *zap.Logger
 }
 
+// LegacyLoginOptions 旧版共享密码登录（迁移期保留）
+type LegacyLoginOptions struct {
+	Enabled bool
+	Role    string // 共享密码签发的token所具备的角色
+}
+
 // TokenExpireTime Token过期时间（30天，与Node版本一致）
 const TokenExpireTime = 30 * 24 * time.Hour
 
@@ -26,18 +35,25 @@ func NewAdminService(
 	adminRepo *repository.AdminRepository,
 	accountSvc *AccountService,
 	cronSvc *CronService,
+	legacy LegacyLoginOptions,
+	auditService *AuditService,
 	logger *zap.Logger,
 ) *AdminService {
+	if !model.IsValidAdminRole(legacy.Role) {
+		legacy.Role = model.AdminRoleOwner
+	}
 	return &AdminService{
 		adminRepo:      adminRepo,
 		AccountService: accountSvc,
 		CronService:    cronSvc,
+		legacy:         legacy,
+		auditService:   auditService,
 		logger:         logger,
 	}
 }
 
 // VerifyPassword 验证管理员密码（与Node版本对齐）
-func (s *AdminService) VerifyPassword(password string) (*model.APIResponse, error) {
+func (s *AdminService) VerifyPassword(password string, client model.ClientInfo) (*model.APIResponse, error) {
 	if password == "" {
 		return &model.APIResponse{
 			Success: false,
@@ -45,10 +61,17 @@ func (s *AdminService) VerifyPassword(password string) (*model.APIResponse, erro
 		}, nil
 	}
 
+	if !s.legacy.Enabled {
+		return &model.APIResponse{
+			Success: false,
+			Error:   "共享密码登录已停用，请使用管理员账号登录",
+		}, nil
+	}
+
 	s.logger.Info("🔐 验证管理员密码")
 
 	// 验证密码
-	isValid, err := s.adminRepo.ValidatePassword(password)
+	passwordID, needsRehash, err := s.adminRepo.ValidatePassword(password)
 	if err != nil {
 		s.logger.Error("密码验证失败", zap.Error(err))
 		return &model.APIResponse{
@@ -57,14 +80,46 @@ func (s *AdminService) VerifyPassword(password string) (*model.APIResponse, erro
 		}, err
 	}
 
-	if !isValid {
-		s.logger.Warn("❌ 管理员密码错误")
+	if passwordID == 0 {
+		s.logger.Warn("❌ 管理员密码错误", zap.String("ip", client.IP))
+		s.recordLoginFailure("admin.verify_failed", "legacy", "/api/admin/verify", client, "密码错误")
 		return &model.APIResponse{
 			Success: false,
 			Error:   "密码错误",
 		}, nil
 	}
 
+	// 旧版SHA256摘要在登录成功后透明升级为 bcrypt
+	if needsRehash {
+		if err := s.adminRepo.UpdatePasswordHash(passwordID, password); err != nil {
+			s.logger.Warn("升级管理员密码摘要失败", zap.Error(err), zap.Int("id", passwordID))
+		} else {
+			s.logger.Info("🔐 管理员密码摘要已升级为bcrypt", zap.Int("id", passwordID))
+		}
+	}
+
+	s.logger.Info("✅ 管理员共享密码验证成功", zap.String("role", s.legacy.Role))
+
+	return s.issueToken(nil, s.legacy.Role, client)
+}
+
+// recordLoginFailure 将登录失败写入审计记录
+func (s *AdminService) recordLoginFailure(action, username, path string, client model.ClientInfo, reason string) {
+	s.auditService.Record(&model.AuditEvent{
+		ActorName:    username,
+		Action:       action,
+		Method:       "POST",
+		Path:         path,
+		Result:       "failed",
+		StatusCode:   401,
+		ErrorMessage: &reason,
+		IP:           client.IP,
+		UserAgent:    client.UserAgent,
+	})
+}
+
+// issueToken 签发管理员token并返回登录响应（数据库仅保存token摘要）
+func (s *AdminService) issueToken(userID *int, role string, client model.ClientInfo) (*model.APIResponse, error) {
 	// 生成新token
 	token, err := s.generateToken()
 	if err != nil {
@@ -79,7 +134,7 @@ func (s *AdminService) VerifyPassword(password string) (*model.APIResponse, erro
 	expiresAt := time.Now().Add(TokenExpireTime)
 
 	// 添加token到数据库
-	_, err = s.adminRepo.CreateToken(token, expiresAt)
+	_, err = s.adminRepo.CreateToken(utils.HashToken(token), userID, role, client, expiresAt)
 	if err != nil {
 		s.logger.Error("保存token失败", zap.Error(err))
 		return &model.APIResponse{
@@ -98,14 +153,13 @@ func (s *AdminService) VerifyPassword(password string) (*model.APIResponse, erro
 		}
 	}()
 
-	s.logger.Info("✅ 管理员验证成功，token生成完成")
-
 	return &model.APIResponse{
 		Success: true,
 		Message: "验证成功",
 		Data: map[string]interface{}{
 			"token":     token,
 			"expiresIn": int64(TokenExpireTime.Milliseconds()),
+			"role":      role,
 		},
 	}, nil
 }
@@ -119,7 +173,7 @@ func (s *AdminService) VerifyToken(token string) (*model.APIResponse, error) {
 		}, nil
 	}
 
-	isValid, err := s.adminRepo.VerifyToken(token)
+	principal, err := s.Authenticate(token)
 	if err != nil {
 		s.logger.Error("Token验证失败", zap.Error(err))
 		return &model.APIResponse{
@@ -128,7 +182,7 @@ func (s *AdminService) VerifyToken(token string) (*model.APIResponse, error) {
 		}, err
 	}
 
-	if !isValid {
+	if principal == nil {
 		return &model.APIResponse{
 			Success: false,
 			Error:   "Token无效或已过期",
@@ -138,9 +192,27 @@ func (s *AdminService) VerifyToken(token string) (*model.APIResponse, error) {
 	return &model.APIResponse{
 		Success: true,
 		Message: "Token有效",
+		Data:    principal,
 	}, nil
 }
 
+// Authenticate 解析token对应的管理员身份，无效时返回 nil
+func (s *AdminService) Authenticate(token string) (*model.AdminPrincipal, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	principal, err := s.adminRepo.FindPrincipal(utils.HashToken(token))
+	if err != nil || principal == nil {
+		return nil, err
+	}
+
+	if err := s.adminRepo.TouchToken(principal.TokenID); err != nil {
+		s.logger.Debug("更新token使用时间失败", zap.Error(err))
+	}
+	return principal, nil
+}
+
 // GetAllPasswords 获取所有管理员密码信息（与Node版本对齐）
 func (s *AdminService) GetAllPasswords() (*model.APIResponse, error) {
 	passwords, err := s.adminRepo.GetAllPasswords()
@@ -310,7 +382,7 @@ func (s *AdminService) generateToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// RevokeToken 撤销Token（额外功能）
+// RevokeToken 撤销Token（退出登录）
 func (s *AdminService) RevokeToken(token string) (*model.APIResponse, error) {
 	if token == "" {
 		return &model.APIResponse{
@@ -319,7 +391,7 @@ func (s *AdminService) RevokeToken(token string) (*model.APIResponse, error) {
 		}, nil
 	}
 
-	success, err := s.adminRepo.DeleteToken(token)
+	success, err := s.adminRepo.DeleteToken(utils.HashToken(token))
 	if err != nil {
 		s.logger.Error("撤销token失败", zap.Error(err))
 		return &model.APIResponse{
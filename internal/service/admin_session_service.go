@@ -0,0 +1,49 @@
+package service
+
+import (
+	"wjdr-backend-go/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ListSessions 获取当前管理员的有效会话（共享密码登录的会话彼此可见）
+func (s *AdminService) ListSessions(principal *model.AdminPrincipal) (*model.APIResponse, error) {
+	sessions, err := s.adminRepo.ListSessions(principal.UserID)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取会话列表失败"}, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.TokenID
+	}
+	return &model.APIResponse{Success: true, Data: sessions}, nil
+}
+
+// RevokeSession 撤销当前管理员的指定会话
+func (s *AdminService) RevokeSession(principal *model.AdminPrincipal, sessionID int) (*model.APIResponse, error) {
+	deleted, err := s.adminRepo.DeleteSession(sessionID, principal.UserID)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "撤销会话失败"}, err
+	}
+	if !deleted {
+		return &model.APIResponse{Success: false, Error: "会话不存在"}, nil
+	}
+
+	s.logger.Info("✅ 会话已撤销", zap.String("username", principal.Username), zap.Int("session_id", sessionID))
+	return &model.APIResponse{Success: true, Message: "会话已撤销"}, nil
+}
+
+// RevokeAllSessions 撤销当前管理员的全部会话（含当前会话，需重新登录）
+func (s *AdminService) RevokeAllSessions(principal *model.AdminPrincipal) (*model.APIResponse, error) {
+	count, err := s.adminRepo.DeleteSessions(principal.UserID)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "撤销会话失败"}, err
+	}
+
+	s.logger.Info("✅ 已撤销全部会话", zap.String("username", principal.Username), zap.Int("count", count))
+	return &model.APIResponse{
+		Success: true,
+		Message: "已撤销全部会话，请重新登录",
+		Data:    map[string]interface{}{"revoked": count},
+	}, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// minAdminPasswordLength 管理员账号密码最小长度
+const minAdminPasswordLength = 8
+
+// Login 管理员账号登录，签发绑定用户的token
+func (s *AdminService) Login(username, password string, client model.ClientInfo) (*model.APIResponse, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return &model.APIResponse{Success: false, Error: "用户名和密码不能为空"}, nil
+	}
+
+	user, err := s.adminRepo.FindUserByUsername(username)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "登录失败"}, err
+	}
+	// 用户不存在或已禁用时同样执行一次 bcrypt 比较，避免按响应时间区分用户名是否存在
+	passwordOK := false
+	if user == nil {
+		utils.CheckDummyPassword(password)
+	} else {
+		passwordOK = utils.CheckPassword(user.PasswordHash, password)
+	}
+	if !passwordOK || !user.IsActive {
+		s.logger.Warn("❌ 管理员登录失败", zap.String("username", username), zap.String("ip", client.IP))
+		s.recordLoginFailure("admin.login_failed", truncateUsername(username), "/api/admin/login", client, "用户名或密码错误")
+		return &model.APIResponse{Success: false, Error: "用户名或密码错误"}, nil
+	}
+
+	// bcrypt 成本调整后登录时透明升级摘要
+	if utils.PasswordNeedsRehash(user.PasswordHash) {
+		if hash, err := utils.HashPassword(password); err == nil {
+			if err := s.adminRepo.UpdateUserPassword(user.ID, hash); err != nil {
+				s.logger.Warn("升级管理员密码摘要失败", zap.Error(err), zap.Int("user_id", user.ID))
+			}
+		}
+	}
+
+	if err := s.adminRepo.TouchUserLogin(user.ID); err != nil {
+		s.logger.Debug("更新最近登录时间失败", zap.Error(err))
+	}
+
+	s.logger.Info("✅ 管理员登录成功", zap.String("username", user.Username), zap.String("role", user.Role))
+
+	resp, err := s.issueToken(&user.ID, user.Role, client)
+	if resp != nil && resp.Success {
+		resp.Data.(map[string]interface{})["user"] = user
+	}
+	return resp, err
+}
+
+// truncateUsername 截断登录失败时提交的用户名，避免超长输入写入审计表
+func truncateUsername(username string) string {
+	if runes := []rune(username); len(runes) > 64 {
+		return string(runes[:64])
+	}
+	return username
+}
+
+// ListUsers 获取管理员账号列表
+func (s *AdminService) ListUsers() (*model.APIResponse, error) {
+	users, err := s.adminRepo.GetAllUsers()
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取管理员列表失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: users}, nil
+}
+
+// CreateUser 创建管理员账号
+func (s *AdminService) CreateUser(username, password, role string) (*model.APIResponse, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return &model.APIResponse{Success: false, Error: "用户名不能为空"}, nil
+	}
+	if len(password) < minAdminPasswordLength {
+		return &model.APIResponse{Success: false, Error: fmt.Sprintf("密码长度不能少于%d位", minAdminPasswordLength)}, nil
+	}
+	if !model.IsValidAdminRole(role) {
+		return &model.APIResponse{Success: false, Error: "无效的角色，仅支持 viewer/operator/owner"}, nil
+	}
+
+	existing, err := s.adminRepo.FindUserByUsername(username)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建管理员失败"}, err
+	}
+	if existing != nil {
+		return &model.APIResponse{Success: false, Error: "用户名已存在"}, nil
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建管理员失败"}, err
+	}
+
+	id, err := s.adminRepo.CreateUser(username, hash, role)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建管理员失败"}, err
+	}
+
+	return &model.APIResponse{
+		Success: true,
+		Message: "管理员创建成功",
+		Data:    map[string]interface{}{"id": id},
+	}, nil
+}
+
+// UpdateUser 更新管理员角色/状态，password 非空时同时重置密码
+func (s *AdminService) UpdateUser(id int, role string, isActive bool, password string) (*model.APIResponse, error) {
+	if !model.IsValidAdminRole(role) {
+		return &model.APIResponse{Success: false, Error: "无效的角色，仅支持 viewer/operator/owner"}, nil
+	}
+	if password != "" && len(password) < minAdminPasswordLength {
+		return &model.APIResponse{Success: false, Error: fmt.Sprintf("密码长度不能少于%d位", minAdminPasswordLength)}, nil
+	}
+
+	user, err := s.adminRepo.FindUserByID(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "更新管理员失败"}, err
+	}
+	if user == nil {
+		return &model.APIResponse{Success: false, Error: "管理员不存在"}, nil
+	}
+
+	// 不允许移除最后一个启用的 owner
+	if user.Role == model.AdminRoleOwner && user.IsActive && (role != model.AdminRoleOwner || !isActive) {
+		if resp, err := s.ensureAnotherOwner(); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	if _, err := s.adminRepo.UpdateUser(id, role, isActive); err != nil {
+		return &model.APIResponse{Success: false, Error: "更新管理员失败"}, err
+	}
+
+	if password != "" {
+		hash, err := utils.HashPassword(password)
+		if err != nil {
+			return &model.APIResponse{Success: false, Error: "更新密码失败"}, err
+		}
+		if err := s.adminRepo.UpdateUserPassword(id, hash); err != nil {
+			return &model.APIResponse{Success: false, Error: "更新密码失败"}, err
+		}
+	}
+
+	// 禁用或重置密码后，作废该用户已签发的token
+	if !isActive || password != "" {
+		if _, err := s.adminRepo.DeleteTokensByUser(id); err != nil {
+			s.logger.Warn("作废管理员token失败", zap.Error(err), zap.Int("user_id", id))
+		}
+	}
+
+	s.logger.Info("✅ 管理员账号已更新", zap.Int("id", id), zap.String("role", role), zap.Bool("is_active", isActive))
+	return &model.APIResponse{Success: true, Message: "管理员更新成功"}, nil
+}
+
+// DeleteUser 删除管理员账号
+func (s *AdminService) DeleteUser(id int) (*model.APIResponse, error) {
+	user, err := s.adminRepo.FindUserByID(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "删除管理员失败"}, err
+	}
+	if user == nil {
+		return &model.APIResponse{Success: false, Error: "管理员不存在"}, nil
+	}
+
+	if user.Role == model.AdminRoleOwner && user.IsActive {
+		if resp, err := s.ensureAnotherOwner(); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	if _, err := s.adminRepo.DeleteTokensByUser(id); err != nil {
+		s.logger.Warn("作废管理员token失败", zap.Error(err), zap.Int("user_id", id))
+	}
+	if _, err := s.adminRepo.DeleteUser(id); err != nil {
+		return &model.APIResponse{Success: false, Error: "删除管理员失败"}, err
+	}
+
+	s.logger.Info("🗑️ 管理员账号已删除", zap.Int("id", id), zap.String("username", user.Username))
+	return &model.APIResponse{Success: true, Message: "管理员删除成功"}, nil
+}
+
+// BootstrapOwner 初始化第一个 owner（仅在尚无启用的 owner 时允许）
+func (s *AdminService) BootstrapOwner(username, password string) (*model.APIResponse, error) {
+	count, err := s.adminRepo.CountActiveOwners()
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "统计owner失败"}, err
+	}
+	if count > 0 {
+		return &model.APIResponse{Success: false, Error: "已存在owner账号，请使用管理接口新增管理员"}, nil
+	}
+	return s.CreateUser(username, password, model.AdminRoleOwner)
+}
+
+// ensureAnotherOwner 确认除当前用户外仍有其他启用的 owner
+func (s *AdminService) ensureAnotherOwner() (*model.APIResponse, error) {
+	count, err := s.adminRepo.CountActiveOwners()
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "统计owner失败"}, err
+	}
+	if count <= 1 {
+		return &model.APIResponse{Success: false, Error: "至少需要保留一个启用的owner"}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// APIKeyPrefix API Key 明文前缀，AuthMiddleware 据此区分会话token与API Key
+const APIKeyPrefix = "wjdr_"
+
+// APIKeyService 自动化客户端API Key管理与认证
+type APIKeyService struct {
+	keyRepo *repository.APIKeyRepository
+	logger  *zap.Logger
+}
+
+// APIKeyInput 创建/更新API Key的参数（更新时整体替换）
+type APIKeyInput struct {
+	Name       string
+	Scopes     []string
+	AllowedIPs []string
+	ExpiresAt  *time.Time
+	IsActive   bool
+}
+
+func NewAPIKeyService(keyRepo *repository.APIKeyRepository, logger *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		keyRepo: keyRepo,
+		logger:  logger,
+	}
+}
+
+// IsAPIKey 判断 Bearer 凭据是否为API Key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// validateInput 校验名称、权限范围与IP白名单
+func (s *APIKeyService) validateInput(input *APIKeyInput) string {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return "名称不能为空"
+	}
+	if len(input.Scopes) == 0 {
+		return "至少需要一个权限范围"
+	}
+	for _, scope := range input.Scopes {
+		if !model.IsValidAPIKeyScope(scope) {
+			return fmt.Sprintf("无效的权限范围: %s，可选: %s", scope, strings.Join(model.APIKeyScopes, ", "))
+		}
+	}
+	for i, entry := range input.AllowedIPs {
+		entry = strings.TrimSpace(entry)
+		input.AllowedIPs[i] = entry
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Sprintf("无效的IP或CIDR: %s", entry)
+			}
+		}
+	}
+	return ""
+}
+
+// ListKeys 获取全部API Key（不含明文）
+func (s *APIKeyService) ListKeys() (*model.APIResponse, error) {
+	keys, err := s.keyRepo.GetAllKeys()
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取API Key列表失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: keys}, nil
+}
+
+// CreateKey 创建API Key，明文仅在创建时返回一次
+func (s *APIKeyService) CreateKey(input APIKeyInput, createdBy *int) (*model.APIResponse, error) {
+	if msg := s.validateInput(&input); msg != "" {
+		return &model.APIResponse{Success: false, Error: msg}, nil
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return &model.APIResponse{Success: false, Error: "过期时间必须晚于当前时间"}, nil
+	}
+
+	random, err := utils.GenerateToken(24)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "生成API Key失败"}, err
+	}
+	plaintext := APIKeyPrefix + random
+
+	key := &model.APIKey{
+		Name:       input.Name,
+		KeyPrefix:  plaintext[:len(APIKeyPrefix)+6],
+		KeyHash:    utils.HashToken(plaintext),
+		Scopes:     input.Scopes,
+		AllowedIPs: input.AllowedIPs,
+		IsActive:   true,
+		ExpiresAt:  input.ExpiresAt,
+		CreatedBy:  createdBy,
+	}
+	id, err := s.keyRepo.CreateKey(key)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建API Key失败"}, err
+	}
+	key.ID = id
+
+	s.logger.Info("✅ API Key创建成功", zap.Int("id", id), zap.String("name", key.Name), zap.Strings("scopes", key.Scopes))
+
+	return &model.APIResponse{
+		Success: true,
+		Message: "API Key创建成功，请妥善保存，明文不会再次显示",
+		Data: map[string]interface{}{
+			"key":     plaintext,
+			"api_key": key,
+		},
+	}, nil
+}
+
+// UpdateKey 更新API Key配置
+func (s *APIKeyService) UpdateKey(id int, input APIKeyInput) (*model.APIResponse, error) {
+	key, err := s.keyRepo.FindByID(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "更新API Key失败"}, err
+	}
+	if key == nil {
+		return &model.APIResponse{Success: false, Error: "API Key不存在"}, nil
+	}
+
+	if msg := s.validateInput(&input); msg != "" {
+		return &model.APIResponse{Success: false, Error: msg}, nil
+	}
+
+	key.Name = input.Name
+	key.Scopes = input.Scopes
+	key.AllowedIPs = input.AllowedIPs
+	key.ExpiresAt = input.ExpiresAt
+	key.IsActive = input.IsActive
+
+	if _, err := s.keyRepo.UpdateKey(key); err != nil {
+		return &model.APIResponse{Success: false, Error: "更新API Key失败"}, err
+	}
+
+	s.logger.Info("✅ API Key更新成功", zap.Int("id", id), zap.Strings("scopes", key.Scopes), zap.Bool("is_active", key.IsActive))
+	return &model.APIResponse{Success: true, Message: "API Key更新成功", Data: key}, nil
+}
+
+// DeleteKey 删除API Key
+func (s *APIKeyService) DeleteKey(id int) (*model.APIResponse, error) {
+	deleted, err := s.keyRepo.DeleteKey(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "删除API Key失败"}, err
+	}
+	if !deleted {
+		return &model.APIResponse{Success: false, Error: "API Key不存在"}, nil
+	}
+
+	s.logger.Info("🗑️ API Key已删除", zap.Int("id", id))
+	return &model.APIResponse{Success: true, Message: "API Key删除成功"}, nil
+}
+
+// Authenticate 校验API Key并解析为管理员身份；无效、停用、过期或来源IP不在白名单时返回 nil
+func (s *APIKeyService) Authenticate(plaintext, ip string) (*model.AdminPrincipal, error) {
+	if s == nil || !IsAPIKey(plaintext) {
+		return nil, nil
+	}
+
+	key, err := s.keyRepo.FindByHash(utils.HashToken(plaintext))
+	if err != nil || key == nil {
+		return nil, err
+	}
+	if !key.IsActive || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return nil, nil
+	}
+	if !ipAllowed(key.AllowedIPs, ip) {
+		s.logger.Warn("🚫 API Key来源IP不在白名单", zap.Int("id", key.ID), zap.String("ip", ip))
+		return nil, nil
+	}
+
+	if err := s.keyRepo.TouchKey(key.ID, ip); err != nil {
+		s.logger.Debug("更新API Key使用时间失败", zap.Error(err))
+	}
+
+	id := key.ID
+	return &model.AdminPrincipal{
+		Username: "apikey:" + key.Name,
+		Role:     model.AdminRoleAPIKey,
+		APIKeyID: &id,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// ipAllowed 判断IP是否命中白名单（空白名单表示不限制）
+func ipAllowed(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
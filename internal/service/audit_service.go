@@ -0,0 +1,45 @@
+package service
+
+import (
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AuditService 管理操作审计
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+	logger    *zap.Logger
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository, logger *zap.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record 异步写入审计记录（写入失败仅记录日志，不影响业务请求）
+func (s *AuditService) Record(event *model.AuditEvent) {
+	if s == nil || event == nil {
+		return
+	}
+	go func() {
+		if _, err := s.auditRepo.CreateEvent(event); err != nil {
+			s.logger.Warn("审计记录写入失败",
+				zap.Error(err),
+				zap.String("action", event.Action),
+				zap.String("actor", event.ActorName))
+		}
+	}()
+}
+
+// ListEvents 按条件查询审计记录
+func (s *AuditService) ListEvents(filter model.AuditEventFilter) (*model.APIResponse, error) {
+	events, err := s.auditRepo.ListEvents(filter)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取审计记录失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: events}, nil
+}
@@ -1,16 +1,19 @@
 package service
 
 import (
+	"context"
 	"encoding/xml"
 	"html"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"wjdr-backend-go/internal/client"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/tracing"
 	"wjdr-backend-go/internal/worker"
 
 	"github.com/robfig/cron/v3"
@@ -20,6 +23,7 @@ import (
 // CronService 定时任务服务（与Node版本对齐）
 type CronService struct {
 	cron          *cron.Cron
+	running       atomic.Bool
 	redeemRepo    *repository.RedeemRepository
 	logRepo       *repository.LogRepository
 	accountRepo   *repository.AccountRepository
@@ -33,6 +37,7 @@ type CronService struct {
 	reloadOCRKeys func() error
 	feedURL       string
 	updateURL     string
+	webhooks      *WebhookService
 }
 
 func NewCronService(
@@ -71,6 +76,11 @@ func NewCronService(
 	}
 }
 
+// SetWebhookService 设置出站Webhook（RSS 创建兑换码时通知）
+func (s *CronService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
 // Start 启动定时任务（与Node版本对齐）
 func (s *CronService) Start() error {
 	s.logger.Info("🕒 启动定时任务服务")
@@ -96,6 +106,13 @@ func (s *CronService) Start() error {
 		return err
 	}
 
+	// 每天00:00 重置OCR Key日额度
+	_, err = s.cron.AddFunc("0 0 0 * * *", s.resetOCRDailyQuota)
+	if err != nil {
+		s.logger.Error("添加重置OCR日额度任务失败", zap.Error(err))
+		return err
+	}
+
 	// 4. 每天03:00 刷新所有用户数据
 	_, err = s.cron.AddFunc("0 0 3 * * *", s.RefreshAllAccounts)
 	if err != nil {
@@ -121,6 +138,7 @@ func (s *CronService) Start() error {
 
 	// 启动cron
 	s.cron.Start()
+	s.running.Store(true)
 
 	s.logger.Info("✅ 定时任务服务启动成功")
 	s.logger.Info("📅 定时任务计划:")
@@ -156,6 +174,23 @@ func (s *CronService) resetOCRMonthlyQuota() {
 	s.logger.Info("✅ OCR Key额度月度重置完成")
 }
 
+// resetOCRDailyQuota 每天0点将当日剩余额度重置为每日额度，并热更新到内存
+func (s *CronService) resetOCRDailyQuota() {
+	if s.ocrKeySvc == nil {
+		return
+	}
+	if err := s.ocrKeySvc.ResetDailyQuota(); err != nil {
+		s.logger.Error("重置OCR Key日额度失败", zap.Error(err))
+		return
+	}
+	if s.reloadOCRKeys != nil {
+		if err := s.reloadOCRKeys(); err != nil {
+			s.logger.Warn("重置后热更新OCR Keys失败", zap.Error(err))
+		}
+	}
+	s.logger.Info("✅ OCR Key额度每日重置完成")
+}
+
 // refreshAllAccounts 每天03:00刷新所有活跃账号的数据（登录一次以更新昵称、头像、等级等）
 // RefreshAllAccounts 导出：供管理端手动触发
 func (s *CronService) RefreshAllAccounts() {
@@ -173,12 +208,15 @@ func (s *CronService) RefreshAllAccounts() {
 		s.logger.Info("💫 无活跃账号需要刷新")
 		return
 	}
+
+	ctx, span := tracing.Start(context.Background(), "cron.refresh_accounts")
+	defer span.End()
 	updated := 0
 	batch := 0
 	for i, acc := range accounts {
 		// 复用创建账号时的登录解析逻辑：调用 GameClient.Login 并写入账号表
 		// 这里调用 AccountService.VerifyAccount 可更新 is_verified 和 last_login_check
-		if _, err := s.accountSvc.VerifyAccount(acc.ID); err != nil {
+		if _, err := s.accountSvc.VerifyAccount(ctx, acc.ID); err != nil {
 			s.logger.Debug("刷新账号失败(验证)", zap.Int("id", acc.ID), zap.String("fid", acc.FID), zap.Error(err))
 			continue
 		}
@@ -193,10 +231,22 @@ func (s *CronService) RefreshAllAccounts() {
 	s.logger.Info("✅ 刷新活跃账号数据完成", zap.Int("updated", updated), zap.Int("total", len(accounts)))
 }
 
+// SchedulerStatus 返回调度器是否运行、已注册任务数与最近一次计划执行时间
+func (s *CronService) SchedulerStatus() (running bool, jobs int, nextRun time.Time) {
+	entries := s.cron.Entries()
+	for _, e := range entries {
+		if nextRun.IsZero() || (!e.Next.IsZero() && e.Next.Before(nextRun)) {
+			nextRun = e.Next
+		}
+	}
+	return s.running.Load(), len(entries), nextRun
+}
+
 // Stop 停止定时任务
 func (s *CronService) Stop() {
 	s.logger.Info("🛑 停止定时任务服务")
-	s.cron.Stop()
+	<-s.cron.Stop().Done() // 等待正在执行的任务结束
+	s.running.Store(false)
 	s.logger.Info("✅ 定时任务服务已停止")
 }
 
@@ -219,6 +269,9 @@ func (s *CronService) FetchAndProcessRSS() {
 	}
 	s.logger.Info("📰 开始RSS抓取", zap.String("url", s.feedURL))
 
+	ctx, span := tracing.Start(context.Background(), "cron.rss_fetch")
+	defer span.End()
+
 	// 拉取
 	req, _ := http.NewRequest("GET", s.feedURL, nil)
 	// 部分源站对UA敏感，补充常见UA；同时提高超时以适配较大内容
@@ -334,7 +387,7 @@ func (s *CronService) FetchAndProcessRSS() {
 			extracted = append(extracted, code)
 
 			// 提交到兑换流程（内部会验证是否有效与是否已存在）
-			res, err := s.redeemSvc.SubmitRedeemCode(code, false)
+			res, err := s.redeemSvc.SubmitRedeemCode(ctx, code, false)
 			if err != nil {
 				s.logger.Warn("提交兑换码失败", zap.String("code", code), zap.Error(err))
 				continue
@@ -342,6 +395,16 @@ func (s *CronService) FetchAndProcessRSS() {
 			if res != nil && res.Success {
 				created++
 				s.logger.Info("已从RSS创建兑换码并触发处理", zap.String("code", code))
+				data := map[string]interface{}{
+					"code":          code,
+					"source":        "rss",
+					"article_title": e.Title,
+					"article_link":  e.Link.Href,
+				}
+				if rc, ok := res.Data.(*model.RedeemCode); ok {
+					data["redeem_code_id"] = rc.ID
+				}
+				s.webhooks.Notify(model.WebhookEventRedeemCodeCreated, data)
 			} else {
 				s.logger.Info("RSS兑换码未创建", zap.String("code", code), zap.String("error", res.Error))
 			}
@@ -411,6 +474,9 @@ func (s *CronService) cleanExpiredRedeemCodes() {
 
 	s.logger.Info("🔍 开始检查兑换码有效性", zap.Int("count", len(codes)))
 
+	ctx, span := tracing.Start(context.Background(), "cron.clean_expired_codes")
+	defer span.End()
+
 	expiredCodes := []int{}
 	testFID := "362872592" // 使用固定的测试FID（与Node版本一致）
 
@@ -420,7 +486,7 @@ func (s *CronService) cleanExpiredRedeemCodes() {
 			zap.String("code", code.Code))
 
 		// 使用备用账号测试兑换码
-		result, err := s.automationSvc.RedeemSingle(testFID, code.Code)
+		result, err := s.automationSvc.RedeemSingle(ctx, testFID, code.Code)
 		if err != nil {
 			s.logger.Error("测试兑换码失败",
 				zap.Error(err),
@@ -467,6 +533,9 @@ func (s *CronService) cleanExpiredRedeemCodes() {
 func (s *CronService) supplementRedeemCodes() {
 	s.logger.Info("🔄 开始执行自动补充兑换任务")
 
+	ctx, span := tracing.Start(context.Background(), "cron.supplement_redeem")
+	defer span.End()
+
 	// 获取所有已完成的兑换码
 	completedCodes, err := s.redeemRepo.GetCompletedRedeemCodes()
 	if err != nil {
@@ -524,7 +593,7 @@ func (s *CronService) supplementRedeemCodes() {
 		}
 
 		// 提交补充兑换任务
-		jobID, err := s.workerManager.SubmitSupplementTask(code.ID)
+		jobID, err := s.workerManager.SubmitSupplementTask(ctx, code.ID)
 		if err != nil {
 			s.logger.Error("提交补充兑换任务失败",
 				zap.Error(err),
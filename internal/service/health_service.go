@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"wjdr-backend-go/internal/buildinfo"
+	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/worker"
+
+	"go.uber.org/zap"
+)
+
+// HealthService 依赖健康检查：数据库、OCR Key、Worker、任务积压、定时任务与游戏接口
+type HealthService struct {
+	db            *repository.Database
+	ocrManager    *client.OCRKeyManager
+	gameClient    *client.GameClient
+	workerManager *worker.Manager
+	cronService   *CronService
+	cfg           config.HealthConfig
+	logger        *zap.Logger
+}
+
+func NewHealthService(
+	db *repository.Database,
+	ocrManager *client.OCRKeyManager,
+	gameClient *client.GameClient,
+	workerManager *worker.Manager,
+	cronService *CronService,
+	cfg config.HealthConfig,
+	logger *zap.Logger,
+) *HealthService {
+	return &HealthService{
+		db:            db,
+		ocrManager:    ocrManager,
+		gameClient:    gameClient,
+		workerManager: workerManager,
+		cronService:   cronService,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// Check 执行全部检查；任一关键项 down 时整体为 down，其余异常为 degraded
+func (s *HealthService) Check(ctx context.Context) *model.HealthReport {
+	checks := map[string]model.HealthCheck{
+		"database": s.checkDatabase(ctx),
+		"worker":   s.checkWorker(),
+		"queue":    s.checkQueue(),
+		"ocr_keys": s.checkOCRKeys(),
+		"cron":     s.checkCron(),
+		"game_api": s.checkGameAPI(),
+	}
+
+	status := model.HealthStatusOK
+	for name, check := range checks {
+		if check.Status == model.HealthStatusOK {
+			continue
+		}
+		if check.Critical && check.Status == model.HealthStatusDown {
+			status = model.HealthStatusDown
+		} else if status == model.HealthStatusOK {
+			status = model.HealthStatusDegraded
+		}
+		s.logger.Debug("健康检查异常", zap.String("check", name), zap.String("status", check.Status), zap.String("message", check.Message))
+	}
+
+	info := buildinfo.Get()
+	return &model.HealthReport{
+		Status:    status,
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildTime: info.BuildTime,
+		Uptime:    time.Since(buildinfo.StartedAt).Truncate(time.Second).String(),
+		Timestamp: time.Now().Format(time.RFC3339),
+		Checks:    checks,
+	}
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.db.PingContext(ctx); err != nil {
+		return model.HealthCheck{Status: model.HealthStatusDown, Critical: true, Message: "数据库不可用: " + err.Error()}
+	}
+	return model.HealthCheck{
+		Status:   model.HealthStatusOK,
+		Critical: true,
+		Details:  map[string]interface{}{"latency_ms": time.Since(start).Milliseconds()},
+	}
+}
+
+func (s *HealthService) checkWorker() model.HealthCheck {
+	if !s.workerManager.IsStarted() {
+		return model.HealthCheck{Status: model.HealthStatusDown, Critical: true, Message: "Worker管理器未启动"}
+	}
+	return model.HealthCheck{Status: model.HealthStatusOK, Critical: true}
+}
+
+func (s *HealthService) checkQueue() model.HealthCheck {
+	age, err := s.workerManager.BacklogAge()
+	if err != nil {
+		return model.HealthCheck{Status: model.HealthStatusDegraded, Message: "查询任务积压失败"}
+	}
+	check := model.HealthCheck{
+		Status:  model.HealthStatusOK,
+		Details: map[string]interface{}{"backlog_age_seconds": int(age.Seconds())},
+	}
+	if s.cfg.QueueMaxAge > 0 && age > s.cfg.QueueMaxAge {
+		check.Status = model.HealthStatusDegraded
+		check.Message = "任务积压超过 " + s.cfg.QueueMaxAge.String()
+	}
+	return check
+}
+
+func (s *HealthService) checkOCRKeys() model.HealthCheck {
+	usable := s.ocrManager.UsableKeyCount()
+	check := model.HealthCheck{
+		Status:  model.HealthStatusOK,
+		Details: map[string]interface{}{"usable_keys": usable},
+	}
+	if usable < s.cfg.MinOCRKeys {
+		check.Status = model.HealthStatusDegraded
+		check.Message = "可用OCR Key不足"
+	}
+	return check
+}
+
+func (s *HealthService) checkCron() model.HealthCheck {
+	running, jobs, nextRun := s.cronService.SchedulerStatus()
+	check := model.HealthCheck{
+		Status:  model.HealthStatusOK,
+		Details: map[string]interface{}{"jobs": jobs},
+	}
+	if !nextRun.IsZero() {
+		check.Details["next_run_at"] = nextRun.Format(time.RFC3339)
+	}
+	if !running {
+		check.Status = model.HealthStatusDegraded
+		check.Message = "定时任务调度器未运行"
+	}
+	return check
+}
+
+// checkGameAPI 依据最近一次调用结果判断：最近一次失败晚于最近一次成功时视为降级（从未调用过时不判定）
+func (s *HealthService) checkGameAPI() model.HealthCheck {
+	success, failure := s.gameClient.LastCallTimes()
+	check := model.HealthCheck{Status: model.HealthStatusOK, Details: map[string]interface{}{}}
+	if !success.IsZero() {
+		check.Details["last_success_at"] = success.Format(time.RFC3339)
+	}
+	if !failure.IsZero() {
+		check.Details["last_failure_at"] = failure.Format(time.RFC3339)
+	}
+	if success.IsZero() && failure.IsZero() {
+		check.Message = "启动后尚未调用游戏接口"
+	} else if failure.After(success) {
+		check.Status = model.HealthStatusDegraded
+		check.Message = "最近一次游戏接口调用失败"
+	}
+	return check
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// SetCredentialCipher 设置凭据加密器（与 OCRKeyManager 使用同一主密钥配置）
+func (s *OCRKeyService) SetCredentialCipher(c *utils.CredentialCipher) {
+	s.cipher = c
+}
+
+// encryptCredentials 写入数据库前加密凭据，并记录 API Key 末尾提示用于脱敏展示
+func (s *OCRKeyService) encryptCredentials(k *model.OCRKey) error {
+	k.APIKeyHint = utils.CredentialHint(k.APIKey)
+	apiKey, err := s.cipher.Encrypt(k.APIKey)
+	if err != nil {
+		return err
+	}
+	secretKey, err := s.cipher.Encrypt(k.SecretKey)
+	if err != nil {
+		return err
+	}
+	k.APIKey, k.SecretKey = apiKey, secretKey
+	return nil
+}
+
+// ReencryptResult 重新加密结果
+type ReencryptResult struct {
+	Total     int // Key 总数
+	Updated   int // 已重新加密（或本次需要重新加密，dryRun 时）
+	Unchanged int // 已使用当前主密钥
+	Failed    int // 无法解密（主密钥缺失或密文损坏）
+}
+
+// ReencryptCredentials 用当前主密钥重新加密所有 Key（明文与旧主密钥加密的值），用于启用加密或轮换主密钥；
+// 轮换时需同时配置新旧主密钥，完成后即可移除旧主密钥
+func (s *OCRKeyService) ReencryptCredentials(dryRun bool) (ReencryptResult, error) {
+	var res ReencryptResult
+	keys, err := s.repo.ListAll()
+	if err != nil {
+		return res, err
+	}
+	active := s.cipher.ActiveKeyID()
+	for _, k := range keys {
+		res.Total++
+		apiKeyID, secretKeyID := utils.CredentialKeyID(k.APIKey), utils.CredentialKeyID(k.SecretKey)
+		if apiKeyID == active && (k.SecretKey == "" || secretKeyID == active) && k.APIKeyHint != "" {
+			res.Unchanged++
+			continue
+		}
+		apiKey, err := s.cipher.Decrypt(k.APIKey)
+		if err == nil {
+			k.SecretKey, err = s.cipher.Decrypt(k.SecretKey)
+		}
+		if err != nil {
+			res.Failed++
+			s.logger.Error("解密OCR Key凭据失败", zap.Int("key_id", k.ID), zap.Error(err))
+			continue
+		}
+		k.APIKey = apiKey
+		if apiKeyID == active && (k.SecretKey == "" || secretKeyID == active) && utils.CredentialHint(apiKey) == "" {
+			// 已是当前主密钥，仅因凭据过短没有末尾提示
+			res.Unchanged++
+			continue
+		}
+		if dryRun {
+			res.Updated++
+			continue
+		}
+		if err := s.encryptCredentials(&k); err != nil {
+			return res, err
+		}
+		if err := s.repo.UpdateCredentials(k.ID, k.APIKey, k.SecretKey, k.APIKeyHint); err != nil {
+			return res, err
+		}
+		res.Updated++
+	}
+	return res, nil
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"errors"
+	"strings"
+	"sync"
+	"wjdr-backend-go/internal/client"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/utils"
 
 	"go.uber.org/zap"
 )
@@ -12,10 +16,24 @@ import (
 type OCRKeyService struct {
 	repo   *repository.OCRKeyRepository
 	logger *zap.Logger
+	// manager 运行中的调度器，提供准确率与耗时的滚动统计（由 main 注入，可为空）
+	manager *client.OCRKeyManager
+
+	// 使用统计与额度扣减在内存中累积，定时批量写入（见 ocr_quota.go）
+	usageMu        sync.Mutex
+	pending        map[int]*repository.OCRUsageDelta
+	stopFlush      chan struct{}
+	flushDone      chan struct{}
+	webhooks       *WebhookService
+	warnThresholds []float64
+	reload         func() error
+
+	// cipher 凭据加密（见 ocr_credentials.go），为空时按明文保存
+	cipher *utils.CredentialCipher
 }
 
 func NewOCRKeyService(repo *repository.OCRKeyRepository, logger *zap.Logger) *OCRKeyService {
-	return &OCRKeyService{repo: repo, logger: logger}
+	return &OCRKeyService{repo: repo, logger: logger, pending: make(map[int]*repository.OCRUsageDelta)}
 }
 
 func (s *OCRKeyService) ListAll() ([]model.OCRKey, error) {
@@ -27,15 +45,38 @@ func (s *OCRKeyService) ListUsable() ([]model.OCRKey, error) {
 }
 
 func (s *OCRKeyService) Create(k *model.OCRKey) (int, error) {
+	k.Provider = strings.ToLower(strings.TrimSpace(k.Provider))
 	if k.Provider == "" {
 		k.Provider = "baidu"
 	}
 	if k.Weight <= 0 {
 		k.Weight = 1
 	}
+	options := ""
+	if k.Options != nil {
+		options = *k.Options
+	}
+	if err := s.ValidateProviderConfig(k.Provider, k.APIKey, options); err != nil {
+		return 0, err
+	}
+	if err := s.encryptCredentials(k); err != nil {
+		return 0, err
+	}
 	return s.repo.Create(*k)
 }
 
+// ValidateProviderConfig 校验 provider 自定义配置：http 需要合法的服务地址与请求模板，其他 provider 不校验
+func (s *OCRKeyService) ValidateProviderConfig(provider, apiKey, options string) error {
+	if strings.ToLower(strings.TrimSpace(provider)) != "http" {
+		return nil
+	}
+	if err := client.ValidateHTTPOCREndpoint(apiKey); err != nil {
+		return err
+	}
+	_, err := client.ParseHTTPOCROptions(options)
+	return err
+}
+
 func (s *OCRKeyService) Update(id int, patch map[string]interface{}) error {
 	if len(patch) == 0 {
 		return errors.New("empty update patch")
@@ -43,27 +84,86 @@ func (s *OCRKeyService) Update(id int, patch map[string]interface{}) error {
 	return s.repo.Update(id, patch)
 }
 
+// ValidateOptionsUpdate 校验对已有 Key 的 options 修改（http 的请求模板写入前先校验，避免 Reload 后该 Key 每次都失败）
+func (s *OCRKeyService) ValidateOptionsUpdate(id int, options string) error {
+	prov, err := s.repo.GetProviderByID(id)
+	if err != nil {
+		return err
+	}
+	if prov != "http" {
+		return nil
+	}
+	_, err = client.ParseHTTPOCROptions(options)
+	return err
+}
+
 func (s *OCRKeyService) Delete(id int) error {
 	return s.repo.Delete(id)
 }
 
-func (s *OCRKeyService) MarkQuota(id int, hasQuota bool) error {
-	return s.repo.MarkQuota(id, hasQuota)
+// MarkQuota 设置额度状态；停用时记录原因（model.OCRKeyDisabled*），启用时清除
+func (s *OCRKeyService) MarkQuota(id int, hasQuota bool, reason string) error {
+	return s.repo.MarkQuota(id, hasQuota, reason)
 }
 
-func (s *OCRKeyService) TouchUsage(id int, success bool, errMsg *string) error {
-	// 对于 provider=paddle（自研本地模型）不扣减额度；其他正常扣减
-	prov, _ := s.repo.GetProviderByID(id)
-	if err := s.repo.TouchUsage(id, success, errMsg); err != nil {
-		return err
+// RecordVerdict 持久化一次游戏判定（重启后用于初始化准确率窗口）
+func (s *OCRKeyService) RecordVerdict(id int, correct bool) error {
+	return s.repo.RecordVerdict(id, correct)
+}
+
+// SetManager 注入运行中的调度器
+func (s *OCRKeyService) SetManager(m *client.OCRKeyManager) {
+	s.manager = m
+}
+
+// KeyStats 各 Key 的滚动统计（未注入调度器时为空）
+func (s *OCRKeyService) KeyStats() map[int]client.OCRKeyStats {
+	if s.manager == nil {
+		return nil
+	}
+	return s.manager.KeyStats()
+}
+
+// BreakerStates 已加载 Key 的熔断与限流状态（未注入调度器时为空）
+func (s *OCRKeyService) BreakerStates() map[int]client.BreakerStatus {
+	if s.manager == nil {
+		return nil
+	}
+	return s.manager.BreakerStates()
+}
+
+// ProviderStats 按 provider 汇总的滚动统计
+func (s *OCRKeyService) ProviderStats() []client.OCRKeyStats {
+	if s.manager == nil {
+		return []client.OCRKeyStats{}
+	}
+	return s.manager.ProviderStats()
+}
+
+// PreviewPreprocess 用指定流水线（为空时使用 provider 当前配置的流水线）处理样本验证码，返回流水线描述与各步骤的中间图片
+func (s *OCRKeyService) PreviewPreprocess(image, spec, provider string) (string, []client.PipelineStage, error) {
+	var pipeline *client.CaptchaPipeline
+	if strings.TrimSpace(spec) != "" {
+		p, err := client.ParseCaptchaPipeline(spec)
+		if err != nil {
+			return "", nil, err
+		}
+		pipeline = p
+	} else if s.manager != nil {
+		pipeline = s.manager.Pipelines().For(provider)
+	}
+	if pipeline == nil {
+		pipeline, _ = client.ParseCaptchaPipeline(client.DefaultCaptchaPipeline)
 	}
-	if prov != "paddle" {
-		_ = s.repo.DecrementQuota(id)
+	stages, err := pipeline.Trace(image)
+	if err != nil {
+		return "", nil, err
 	}
-	return nil
+	return pipeline.String(), stages, nil
 }
 
 // ResetMonthlyQuota 每月1号执行：将 remaining_quota 重置为 monthly_quota，并启用 has_quota=true（若仍 active）
 func (s *OCRKeyService) ResetMonthlyQuota() error {
+	s.FlushUsage()
 	return s.repo.ResetMonthlyQuota()
 }
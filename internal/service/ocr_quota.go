@@ -0,0 +1,212 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// 额度周期
+const (
+	quotaPeriodDaily   = "daily"
+	quotaPeriodMonthly = "monthly"
+)
+
+// RecordUsage 记录一次识别调用（仅在内存中累积，由 FlushUsage 批量写入）；billable 表示该次调用消耗额度
+func (s *OCRKeyService) RecordUsage(id int, success, billable bool, errMsg *string) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	d, ok := s.pending[id]
+	if !ok {
+		d = &repository.OCRUsageDelta{}
+		s.pending[id] = d
+	}
+	if success {
+		d.Success++
+	} else {
+		d.Fail++
+	}
+	if billable {
+		d.Billed++
+	}
+	d.LastError = errMsg
+	d.LastUsedAt = time.Now()
+}
+
+// StartUsageFlusher 按间隔批量写入使用统计与额度扣减
+func (s *OCRKeyService) StartUsageFlusher(interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	s.stopFlush = make(chan struct{})
+	s.flushDone = make(chan struct{})
+	go func() {
+		defer close(s.flushDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.FlushUsage()
+			case <-s.stopFlush:
+				s.FlushUsage()
+				return
+			}
+		}
+	}()
+}
+
+// StopUsageFlusher 停止定时写入并写入剩余统计（关闭服务时调用）
+func (s *OCRKeyService) StopUsageFlusher() {
+	if s.stopFlush == nil {
+		return
+	}
+	close(s.stopFlush)
+	<-s.flushDone
+	s.stopFlush = nil
+}
+
+// FlushUsage 将累积的使用统计写入数据库；额度降至预警阈值时发出 ocr_key.quota_low，用尽时发出 ocr_key.exhausted 并热更新
+func (s *OCRKeyService) FlushUsage() {
+	s.usageMu.Lock()
+	pending := s.pending
+	s.pending = make(map[int]*repository.OCRUsageDelta)
+	s.usageMu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	var billed []int
+	for id, d := range pending {
+		if err := s.repo.ApplyUsage(id, *d); err != nil {
+			s.requeueUsage(id, d)
+			continue
+		}
+		if d.Billed > 0 {
+			billed = append(billed, id)
+		}
+	}
+	if len(billed) == 0 {
+		return
+	}
+	states, err := s.repo.GetQuotaStates(billed)
+	if err != nil {
+		return
+	}
+	type event struct {
+		name string
+		data map[string]interface{}
+	}
+	var events []event
+	exhausted := false
+	for _, k := range states {
+		used := pending[k.ID].Billed
+		for _, p := range []struct {
+			period    string
+			quota     *int
+			remaining int
+		}{
+			{quotaPeriodDaily, k.DailyQuota, k.RemainingDailyQuota},
+			{quotaPeriodMonthly, k.MonthlyQuota, k.RemainingQuota},
+		} {
+			if p.quota == nil || *p.quota <= 0 {
+				continue // 不限或无额度
+			}
+			quota := *p.quota
+			before := min(p.remaining+used, quota)
+			if p.remaining <= 0 && before > 0 {
+				exhausted = true
+				s.logger.Warn("OCR Key 额度已用尽，已停止调度", zap.Int("key_id", k.ID), zap.String("provider", k.Provider), zap.String("period", p.period))
+				events = append(events, event{model.WebhookEventOCRKeyExhausted, map[string]interface{}{
+					"key_id":     k.ID,
+					"error_code": 0,
+					"message":    p.period + " quota exhausted",
+					"period":     p.period,
+				}})
+				continue
+			}
+			if t, ok := s.crossedThreshold(before, p.remaining, quota); ok {
+				s.logger.Warn("OCR Key 剩余额度不足", zap.Int("key_id", k.ID), zap.String("provider", k.Provider), zap.String("period", p.period),
+					zap.Int("remaining", p.remaining), zap.Int("quota", quota))
+				events = append(events, event{model.WebhookEventOCRQuotaLow, map[string]interface{}{
+					"key_id":    k.ID,
+					"name":      k.Name,
+					"provider":  k.Provider,
+					"period":    p.period,
+					"remaining": p.remaining,
+					"quota":     quota,
+					"threshold": t,
+				}})
+			}
+		}
+	}
+	if exhausted && s.reload != nil {
+		if err := s.reload(); err != nil {
+			s.logger.Warn("额度用尽后热更新OCR Keys失败", zap.Error(err))
+		}
+	}
+	for _, e := range events {
+		s.notify(e.name, e.data)
+	}
+}
+
+// crossedThreshold 本次扣减使剩余比例跌破的最低预警阈值
+func (s *OCRKeyService) crossedThreshold(before, after, quota int) (float64, bool) {
+	crossed, found := 0.0, false
+	for _, t := range s.warnThresholds {
+		if float64(before) > t*float64(quota) && float64(after) <= t*float64(quota) {
+			crossed, found = t, true // 阈值按降序排列，取最后一个
+		}
+	}
+	return crossed, found
+}
+
+// requeueUsage 写入失败时放回待写入队列，下次一并重试
+func (s *OCRKeyService) requeueUsage(id int, d *repository.OCRUsageDelta) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	cur, ok := s.pending[id]
+	if !ok {
+		s.pending[id] = d
+		return
+	}
+	cur.Success += d.Success
+	cur.Fail += d.Fail
+	cur.Billed += d.Billed
+	// 队列中已有的是更新的调用，仅在其更早时用失败批次的最后状态覆盖
+	if d.LastUsedAt.After(cur.LastUsedAt) {
+		cur.LastUsedAt, cur.LastError = d.LastUsedAt, d.LastError
+	}
+}
+
+func (s *OCRKeyService) notify(event string, data map[string]interface{}) {
+	if s.webhooks == nil {
+		return
+	}
+	if s.manager != nil {
+		data["usable_keys"] = s.manager.UsableKeyCount()
+	}
+	s.webhooks.Notify(event, data)
+}
+
+// SetQuotaAlerts 设置额度预警：thresholds 为剩余比例（如 0.2 表示剩余 20%），reload 在额度用尽后热更新调度器
+func (s *OCRKeyService) SetQuotaAlerts(webhooks *WebhookService, thresholds []float64, reload func() error) {
+	valid := make([]float64, 0, len(thresholds))
+	for _, t := range thresholds {
+		if t > 0 && t < 1 {
+			valid = append(valid, t)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(valid)))
+	s.webhooks, s.warnThresholds, s.reload = webhooks, valid, reload
+}
+
+// ResetDailyQuota 每天0点执行：将 remaining_daily_quota 重置为 daily_quota（当月额度未用尽时恢复 has_quota）
+func (s *OCRKeyService) ResetDailyQuota() error {
+	s.FlushUsage()
+	return s.repo.ResetDailyQuota()
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// PlayerService 玩家自助服务：玩家把游戏昵称改为包含验证码以证明拥有该账号后签发访问令牌，仅能查看/管理自己的账号
+type PlayerService struct {
+	accountRepo    *repository.AccountRepository
+	logRepo        *repository.LogRepository
+	tokenRepo      *repository.PlayerTokenRepository
+	accountService *AccountService
+	gameClient     *client.GameClient
+	challengeKey   []byte
+	logger         *zap.Logger
+	stopSweep      chan struct{}
+	sweepDone      chan struct{}
+}
+
+// PlayerTokenExpireTime 玩家token有效期（90天，过期后重新验证登录即可）
+const PlayerTokenExpireTime = 90 * 24 * time.Hour
+
+// 登录验证码：按时间窗口由 HMAC 派生（无需存储，多实例需配置相同的 PLAYER_CHALLENGE_SECRET），当前与上一窗口的验证码有效
+const (
+	playerChallengeWindow = 10 * time.Minute
+	playerChallengeLength = 6
+)
+
+func NewPlayerService(
+	accountRepo *repository.AccountRepository,
+	logRepo *repository.LogRepository,
+	tokenRepo *repository.PlayerTokenRepository,
+	accountService *AccountService,
+	gameClient *client.GameClient,
+	challengeSecret string,
+	logger *zap.Logger,
+) *PlayerService {
+	key := []byte(challengeSecret)
+	if len(key) == 0 {
+		// 未配置时使用进程内随机密钥：重启后未完成的验证码失效，多实例部署需配置
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			logger.Fatal("生成玩家验证码密钥失败", zap.Error(err))
+		}
+	}
+	return &PlayerService{
+		accountRepo:    accountRepo,
+		logRepo:        logRepo,
+		tokenRepo:      tokenRepo,
+		accountService: accountService,
+		gameClient:     gameClient,
+		challengeKey:   key,
+		logger:         logger,
+	}
+}
+
+// challengeCode FID 在指定时间窗口的验证码（大写字母与数字）
+func (s *PlayerService) challengeCode(fid string, window int64) string {
+	mac := hmac.New(sha256.New, s.challengeKey)
+	mac.Write([]byte(fid + "|" + strconv.FormatInt(window, 10)))
+	return base32.StdEncoding.EncodeToString(mac.Sum(nil))[:playerChallengeLength]
+}
+
+// Challenge 生成登录验证码：玩家需把游戏昵称改为包含该验证码后调用 Login
+func (s *PlayerService) Challenge(fid string) (*model.APIResponse, error) {
+	account, err := s.accountRepo.FindByFID(fid)
+	if err != nil {
+		s.logger.Error("查询账号失败", zap.Error(err), zap.String("fid", fid))
+		return &model.APIResponse{Success: false, Error: "查询账号失败"}, err
+	}
+	if account == nil {
+		return &model.APIResponse{Success: false, Error: "账号不存在，请先添加账号"}, nil
+	}
+
+	now := time.Now()
+	window := now.UnixNano() / int64(playerChallengeWindow)
+	expiresAt := time.Unix(0, (window+2)*int64(playerChallengeWindow))
+	return &model.APIResponse{
+		Success: true,
+		Message: "请将游戏昵称修改为包含验证码后登录，登录成功后可改回",
+		Data: map[string]interface{}{
+			"code":      s.challengeCode(fid, window),
+			"expiresIn": expiresAt.Sub(now).Milliseconds(),
+		},
+	}, nil
+}
+
+// nicknameHasChallenge 昵称是否包含当前或上一时间窗口的验证码（不区分大小写）
+func (s *PlayerService) nicknameHasChallenge(fid, nickname string) bool {
+	nickname = strings.ToUpper(nickname)
+	window := time.Now().UnixNano() / int64(playerChallengeWindow)
+	for _, w := range []int64{window, window - 1} {
+		if strings.Contains(nickname, s.challengeCode(fid, w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// issueToken 为账号签发访问令牌，返回明文token（仅此一次可见）
+func (s *PlayerService) issueToken(account *model.Account) (string, error) {
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(PlayerTokenExpireTime)
+	if _, err := s.tokenRepo.CreateToken(account.ID, account.FID, utils.HashToken(token), expiresAt); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// StartTokenSweeper 按间隔清理过期玩家token
+func (s *PlayerService) StartTokenSweeper(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	s.stopSweep = make(chan struct{})
+	s.sweepDone = make(chan struct{})
+	go func() {
+		defer close(s.sweepDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanExpiredTokens()
+			case <-s.stopSweep:
+				return
+			}
+		}
+	}()
+}
+
+// StopTokenSweeper 停止定时清理（关闭服务时调用）
+func (s *PlayerService) StopTokenSweeper() {
+	if s.stopSweep == nil {
+		return
+	}
+	close(s.stopSweep)
+	<-s.sweepDone
+	s.stopSweep = nil
+}
+
+// cleanExpiredTokens 清理过期token
+func (s *PlayerService) cleanExpiredTokens() {
+	if cleaned, err := s.tokenRepo.CleanExpiredTokens(); err != nil {
+		s.logger.Error("清理过期玩家token失败", zap.Error(err))
+	} else if cleaned > 0 {
+		s.logger.Info("清理过期玩家token", zap.Int("count", cleaned))
+	}
+}
+
+// Login 玩家登录：FID 已通过签名校验，再经游戏接口确认游戏昵称包含验证码（证明调用方能操作该游戏账号）后签发token
+func (s *PlayerService) Login(ctx context.Context, fid string) (*model.APIResponse, error) {
+	ctx, log := logging.With(ctx, s.logger, zap.String("fid", fid))
+	account, err := s.accountRepo.FindByFID(fid)
+	if err != nil {
+		log.Error("查询账号失败", zap.Error(err))
+		return &model.APIResponse{Success: false, Error: "查询账号失败"}, err
+	}
+	if account == nil {
+		return &model.APIResponse{Success: false, Error: "账号不存在，请先添加账号"}, nil
+	}
+
+	loginResult, err := s.gameClient.Login(ctx, fid)
+	if err != nil {
+		log.Error("玩家登录验证异常", zap.Error(err))
+		return &model.APIResponse{Success: false, Error: "验证账号时发生异常"}, err
+	}
+	if !loginResult.Success {
+		log.Warn("玩家登录验证失败", zap.String("error", loginResult.Error))
+		return &model.APIResponse{Success: false, Error: fmt.Sprintf("账号验证失败: %s", loginResult.Error)}, nil
+	}
+	nickname := ""
+	if data, ok := loginResult.Data.(map[string]interface{}); ok {
+		nickname, _ = data["nickname"].(string)
+	}
+	if !s.nicknameHasChallenge(fid, nickname) {
+		log.Warn("玩家登录验证失败：昵称不含验证码")
+		return &model.APIResponse{Success: false, Error: "游戏昵称中未找到有效验证码，请先获取验证码并修改昵称"}, nil
+	}
+
+	token, err := s.issueToken(account)
+	if err != nil {
+		log.Error("签发玩家token失败", zap.Error(err))
+		return &model.APIResponse{Success: false, Error: "生成访问令牌失败"}, err
+	}
+
+	log.Info("✅ 玩家登录成功")
+
+	return &model.APIResponse{
+		Success: true,
+		Message: "登录成功",
+		Data: map[string]interface{}{
+			"token":     token,
+			"expiresIn": int64(PlayerTokenExpireTime.Milliseconds()),
+			"account":   account,
+		},
+	}, nil
+}
+
+// Authenticate 校验玩家token，返回token记录（无效或过期时返回 nil）
+func (s *PlayerService) Authenticate(token string) (*model.PlayerToken, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	record, err := s.tokenRepo.FindValidToken(utils.HashToken(token))
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.TouchToken(record.ID); err != nil {
+		s.logger.Debug("更新玩家token使用时间失败", zap.Error(err))
+	}
+	return record, nil
+}
+
+// GetProfile 获取玩家账号资料与兑换概况
+func (s *PlayerService) GetProfile(accountID int) (*model.APIResponse, error) {
+	account, err := s.accountRepo.FindByID(accountID)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取账号信息失败"}, err
+	}
+	if account == nil {
+		return &model.APIResponse{Success: false, Error: "账号不存在"}, nil
+	}
+
+	logs, err := s.logRepo.GetLogsByAccountID(accountID)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取兑换记录失败"}, err
+	}
+
+	categoryStats := make(map[string]int)
+	var lastRedeemedAt *time.Time
+	for i := range logs {
+		categoryStats[logs[i].ResultCategory]++
+		if lastRedeemedAt == nil || logs[i].RedeemedAt.After(*lastRedeemedAt) {
+			lastRedeemedAt = &logs[i].RedeemedAt
+		}
+	}
+
+	status := "active"
+	if !account.IsActive {
+		status = "paused"
+	}
+
+	return &model.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"account":          account,
+			"status":           status,
+			"total_redeemed":   len(logs),
+			"category_stats":   categoryStats,
+			"last_redeemed_at": lastRedeemedAt,
+		},
+	}, nil
+}
+
+// GetLogs 获取玩家自己的兑换历史
+func (s *PlayerService) GetLogs(accountID int) (*model.APIResponse, error) {
+	logs, err := s.logRepo.GetLogsByAccountID(accountID)
+	if err != nil {
+		s.logger.Error("获取玩家兑换历史失败", zap.Error(err), zap.Int("account_id", accountID))
+		return &model.APIResponse{Success: false, Error: "获取兑换记录失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: logs}, nil
+}
+
+// GetRewards 获取玩家成功兑换的礼包列表
+func (s *PlayerService) GetRewards(accountID int) (*model.APIResponse, error) {
+	logs, err := s.logRepo.GetLogsByAccountID(accountID)
+	if err != nil {
+		s.logger.Error("获取玩家兑换历史失败", zap.Error(err), zap.Int("account_id", accountID))
+		return &model.APIResponse{Success: false, Error: "获取兑换记录失败"}, err
+	}
+
+	rewards := make([]map[string]interface{}, 0, len(logs))
+	for _, log := range logs {
+		if log.Result != "success" {
+			continue
+		}
+		rewards = append(rewards, map[string]interface{}{
+			"redeem_code_id":  log.RedeemCodeID,
+			"code":            log.Code,
+			"success_message": log.SuccessMessage,
+			"redeemed_at":     log.RedeemedAt,
+		})
+	}
+	return &model.APIResponse{Success: true, Data: rewards}, nil
+}
+
+// SetPaused 暂停/恢复自动兑换
+func (s *PlayerService) SetPaused(accountID int, paused bool) (*model.APIResponse, error) {
+	if err := s.accountRepo.UpdateActiveStatus(accountID, !paused); err != nil {
+		return &model.APIResponse{Success: false, Error: "更新账号状态失败"}, err
+	}
+
+	message := "已恢复自动兑换"
+	if paused {
+		message = "已暂停自动兑换"
+	}
+	s.logger.Info("🔄 玩家更新账号状态", zap.Int("account_id", accountID), zap.Bool("paused", paused))
+
+	return &model.APIResponse{Success: true, Message: message}, nil
+}
+
+// RemoveAccount 玩家移除自己的账号（同时作废全部token）
+func (s *PlayerService) RemoveAccount(accountID int) (*model.APIResponse, error) {
+	result, err := s.accountService.DeleteAccount(accountID)
+	if err != nil || !result.Success {
+		return result, err
+	}
+
+	if _, err := s.tokenRepo.DeleteByAccountID(accountID); err != nil {
+		s.logger.Warn("移除账号后清理token失败", zap.Error(err), zap.Int("account_id", accountID))
+	}
+	return result, nil
+}
+
+// Logout 作废当前token
+func (s *PlayerService) Logout(token string) (*model.APIResponse, error) {
+	if _, err := s.tokenRepo.DeleteToken(utils.HashToken(token)); err != nil {
+		return &model.APIResponse{Success: false, Error: "退出登录失败"}, err
+	}
+	return &model.APIResponse{Success: true, Message: "已退出登录"}, nil
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/logging"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
 	"wjdr-backend-go/internal/worker"
@@ -40,7 +42,8 @@ func NewRedeemService(
 }
 
 // SubmitRedeemCode 提交新的兑换码（与Node版本对齐）
-func (s *RedeemService) SubmitRedeemCode(code string, isLong bool) (*model.APIResponse, error) {
+func (s *RedeemService) SubmitRedeemCode(ctx context.Context, code string, isLong bool) (*model.APIResponse, error) {
+	log := logging.FromContext(ctx, s.logger)
 	if code == "" {
 		return &model.APIResponse{
 			Success: false,
@@ -48,14 +51,14 @@ func (s *RedeemService) SubmitRedeemCode(code string, isLong bool) (*model.APIRe
 		}, nil
 	}
 
-	s.logger.Info("📝 提交新兑换码",
+	log.Info("📝 提交新兑换码",
 		zap.String("code", code),
 		zap.Bool("is_long", isLong))
 
 	// 检查兑换码是否已存在
 	existingCode, err := s.redeemRepo.FindRedeemCodeByCode(code)
 	if err != nil {
-		s.logger.Error("查询兑换码失败", zap.Error(err))
+		log.Error("查询兑换码失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "查询兑换码失败",
@@ -73,7 +76,7 @@ func (s *RedeemService) SubmitRedeemCode(code string, isLong bool) (*model.APIRe
 	// 直接创建兑换码记录（同步返回，后台异步处理）
 	redeemCodeID, err := s.redeemRepo.CreateRedeemCode(code, isLong)
 	if err != nil {
-		s.logger.Error("创建兑换码失败", zap.Error(err))
+		log.Error("创建兑换码失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "创建兑换码失败",
@@ -83,25 +86,25 @@ func (s *RedeemService) SubmitRedeemCode(code string, isLong bool) (*model.APIRe
 	// 获取创建的兑换码信息
 	redeemCode, err := s.redeemRepo.FindRedeemCodeByID(redeemCodeID)
 	if err != nil {
-		s.logger.Error("获取兑换码信息失败", zap.Error(err))
+		log.Error("获取兑换码信息失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "获取兑换码信息失败",
 		}, err
 	}
 
-	s.logger.Info("✅ 兑换码已创建",
+	log.Info("✅ 兑换码已创建",
 		zap.Int("redeem_code_id", redeemCodeID),
 		zap.String("code", code))
 
 	// 异步提交批量兑换任务
-	jobID, err := s.workerManager.SubmitRedeemTask(redeemCodeID, nil) // nil表示处理所有活跃账号
+	jobID, err := s.workerManager.SubmitRedeemTask(ctx, redeemCodeID, nil) // nil表示处理所有活跃账号
 	if err != nil {
-		s.logger.Error("提交兑换任务失败", zap.Error(err))
+		log.Error("提交兑换任务失败", zap.Error(err))
 		// 这里不返回错误，因为兑换码已经创建，只是异步处理失败
-		s.logger.Warn("⚠️ 兑换码已创建但异步任务提交失败，请手动重试")
+		log.Warn("⚠️ 兑换码已创建但异步任务提交失败，请手动重试")
 	} else {
-		s.logger.Info("📋 兑换任务已提交",
+		log.Info("📋 兑换任务已提交",
 			zap.Int64("job_id", jobID),
 			zap.Int("redeem_code_id", redeemCodeID))
 	}
@@ -193,6 +196,11 @@ func (s *RedeemService) GetGlobalLogStats() (total, success, failed int, err err
 	return s.logRepo.GetGlobalLogStats()
 }
 
+// GetGlobalCategoryStats 获取全局按结果分类的日志统计
+func (s *RedeemService) GetGlobalCategoryStats() (map[string]int, error) {
+	return s.logRepo.GetGlobalCategoryStats()
+}
+
 // DeleteRedeemCode 删除兑换码（与Node版本对齐）
 func (s *RedeemService) DeleteRedeemCode(id int) (*model.APIResponse, error) {
 	// 检查兑换码是否存在
@@ -267,11 +275,12 @@ func (s *RedeemService) BulkDeleteRedeemCodes(ids []int) (*model.APIResponse, er
 }
 
 // RetryRedeemCode 重试兑换码（与Node版本对齐）
-func (s *RedeemService) RetryRedeemCode(id int) (*model.APIResponse, error) {
+func (s *RedeemService) RetryRedeemCode(ctx context.Context, id int) (*model.APIResponse, error) {
+	log := logging.FromContext(ctx, s.logger)
 	// 检查兑换码是否存在
 	redeemCode, err := s.redeemRepo.FindRedeemCodeByID(id)
 	if err != nil {
-		s.logger.Error("查询兑换码失败", zap.Error(err))
+		log.Error("查询兑换码失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "查询兑换码失败",
@@ -292,21 +301,21 @@ func (s *RedeemService) RetryRedeemCode(id int) (*model.APIResponse, error) {
 		}, nil
 	}
 
-	s.logger.Info("🔄 重试兑换码",
+	log.Info("🔄 重试兑换码",
 		zap.Int("id", id),
 		zap.String("code", redeemCode.Code))
 
 	// 提交补充兑换任务（为新账号执行兑换）
-	jobID, err := s.workerManager.SubmitSupplementTask(id)
+	jobID, err := s.workerManager.SubmitSupplementTask(ctx, id)
 	if err != nil {
-		s.logger.Error("提交补充兑换任务失败", zap.Error(err))
+		log.Error("提交补充兑换任务失败", zap.Error(err))
 		return &model.APIResponse{
 			Success: false,
 			Error:   "提交补充兑换任务失败",
 		}, err
 	}
 
-	s.logger.Info("📋 补充兑换任务已提交",
+	log.Info("📋 补充兑换任务已提交",
 		zap.Int64("job_id", jobID),
 		zap.Int("redeem_code_id", id))
 
@@ -320,7 +329,8 @@ func (s *RedeemService) RetryRedeemCode(id int) (*model.APIResponse, error) {
 }
 
 // RetryRedeemCodes 批量重试多个兑换码（在现有补充兑换机制上逐个提交后台任务）
-func (s *RedeemService) RetryRedeemCodes(ids []int) (*model.APIResponse, error) {
+func (s *RedeemService) RetryRedeemCodes(ctx context.Context, ids []int) (*model.APIResponse, error) {
+	log := logging.FromContext(ctx, s.logger)
 	if len(ids) == 0 {
 		return &model.APIResponse{Success: false, Error: "没有指定要补充兑换的兑换码"}, nil
 	}
@@ -334,20 +344,20 @@ func (s *RedeemService) RetryRedeemCodes(ids []int) (*model.APIResponse, error)
 		// 校验兑换码
 		redeemCode, err := s.redeemRepo.FindRedeemCodeByID(id)
 		if err != nil || redeemCode == nil {
-			s.logger.Warn("跳过不存在的兑换码", zap.Int("redeem_code_id", id))
+			log.Warn("跳过不存在的兑换码", zap.Int("redeem_code_id", id))
 			failed++
 			invalidIDs = append(invalidIDs, id)
 			continue
 		}
 		if redeemCode.Status != "completed" {
-			s.logger.Warn("兑换码状态非completed，跳过", zap.Int("redeem_code_id", id), zap.String("status", redeemCode.Status))
+			log.Warn("兑换码状态非completed，跳过", zap.Int("redeem_code_id", id), zap.String("status", redeemCode.Status))
 			failed++
 			continue
 		}
 
-		jobID, err := s.workerManager.SubmitSupplementTask(id)
+		jobID, err := s.workerManager.SubmitSupplementTask(ctx, id)
 		if err != nil {
-			s.logger.Error("提交补充兑换任务失败", zap.Int("redeem_code_id", id), zap.Error(err))
+			log.Error("提交补充兑换任务失败", zap.Int("redeem_code_id", id), zap.Error(err))
 			failed++
 			continue
 		}
@@ -411,6 +421,7 @@ func (s *RedeemService) GetAccountsForRedeemCode(id int) (*model.APIResponse, er
 	for _, log := range logs {
 		accountStatusMap[log.GameAccountID] = map[string]interface{}{
 			"result":             log.Result,
+			"result_category":    log.ResultCategory,
 			"error_message":      log.ErrorMessage,
 			"success_message":    log.SuccessMessage,
 			"captcha_recognized": log.CaptchaRecognized,
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/metrics"
+	"wjdr-backend-go/internal/model"
+	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// Webhook 请求头：签名为 HMAC-SHA256(secret, 时间戳 + "." + 请求体) 的十六进制
+const (
+	WebhookHeaderEvent     = "X-WJDR-Event"
+	WebhookHeaderDelivery  = "X-WJDR-Delivery"
+	WebhookHeaderTimestamp = "X-WJDR-Timestamp"
+	WebhookHeaderSignature = "X-WJDR-Signature"
+)
+
+const (
+	webhookSecretPrefix    = "whsec_"
+	webhookMaxConcurrency  = 4               // 同时进行的投递数
+	webhookMaxBackoff      = 5 * time.Minute // 单次重试等待上限
+	webhookDeliveriesLimit = 50              // 默认返回的投递记录条数
+	webhookMaxSecretLen    = 128             // 与 webhooks.secret 列宽一致
+	webhookMaxNameLen      = 64              // 与 webhooks.name 列宽一致
+	webhookMaxURLLen       = 512             // 与 webhooks.url 列宽一致
+)
+
+// WebhookService 出站Webhook：订阅管理、签名投递、失败退避重试与投递记录
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	httpClient  *http.Client
+	maxAttempts int
+	sem         chan struct{}
+	logger      *zap.Logger
+}
+
+// WebhookInput 创建/更新Webhook的参数（更新时整体替换，密钥不变）
+type WebhookInput struct {
+	Name     string
+	URL      string
+	Secret   string // 仅创建时使用，为空则自动生成
+	Events   []string
+	IsActive bool
+}
+
+// webhookPayload 投递的请求体
+type webhookPayload struct {
+	Event     string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+func NewWebhookService(webhookRepo *repository.WebhookRepository, cfg config.WebhookConfig, logger *zap.Logger) *WebhookService {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		httpClient:  &http.Client{Timeout: cfg.Timeout},
+		maxAttempts: maxAttempts,
+		sem:         make(chan struct{}, webhookMaxConcurrency),
+		logger:      logger,
+	}
+}
+
+// SignWebhookPayload 计算签名头的值，接收方按同样方式校验
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateInput 校验名称、地址与事件过滤
+func (s *WebhookService) validateInput(input *WebhookInput) string {
+	input.Name = strings.TrimSpace(input.Name)
+	input.URL = strings.TrimSpace(input.URL)
+	if input.Name == "" {
+		return "名称不能为空"
+	}
+	if utf8.RuneCountInString(input.Name) > webhookMaxNameLen {
+		return fmt.Sprintf("名称不能超过%d个字符", webhookMaxNameLen)
+	}
+	if len(input.URL) > webhookMaxURLLen {
+		return fmt.Sprintf("URL不能超过%d个字符", webhookMaxURLLen)
+	}
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "无效的URL，仅支持 http/https"
+	}
+	for _, event := range input.Events {
+		if !model.IsValidWebhookEvent(event) {
+			return fmt.Sprintf("无效的事件: %s，可选: %s", event, strings.Join(model.WebhookEvents, ", "))
+		}
+	}
+	return ""
+}
+
+// ListWebhooks 获取全部Webhook（不含密钥）
+func (s *WebhookService) ListWebhooks() (*model.APIResponse, error) {
+	hooks, err := s.webhookRepo.GetAllWebhooks()
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取Webhook列表失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: hooks}, nil
+}
+
+// CreateWebhook 创建Webhook，密钥仅在创建时返回一次
+func (s *WebhookService) CreateWebhook(input WebhookInput, createdBy *int) (*model.APIResponse, error) {
+	if msg := s.validateInput(&input); msg != "" {
+		return &model.APIResponse{Success: false, Error: msg}, nil
+	}
+
+	secret := strings.TrimSpace(input.Secret)
+	if utf8.RuneCountInString(secret) > webhookMaxSecretLen {
+		return &model.APIResponse{Success: false, Error: fmt.Sprintf("签名密钥不能超过%d个字符", webhookMaxSecretLen)}, nil
+	}
+	if secret == "" {
+		random, err := utils.GenerateToken(24)
+		if err != nil {
+			return &model.APIResponse{Success: false, Error: "生成Webhook密钥失败"}, err
+		}
+		secret = webhookSecretPrefix + random
+	}
+
+	hook := &model.Webhook{
+		Name:      input.Name,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		IsActive:  true,
+		CreatedBy: createdBy,
+	}
+	id, err := s.webhookRepo.CreateWebhook(hook)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建Webhook失败"}, err
+	}
+	hook.ID = id
+
+	s.logger.Info("✅ Webhook创建成功", zap.Int("id", id), zap.String("name", hook.Name), zap.Strings("events", hook.Events))
+
+	return &model.APIResponse{
+		Success: true,
+		Message: "Webhook创建成功，请妥善保存签名密钥，不会再次显示",
+		Data: map[string]interface{}{
+			"secret":  secret,
+			"webhook": hook,
+		},
+	}, nil
+}
+
+// UpdateWebhook 更新Webhook配置
+func (s *WebhookService) UpdateWebhook(id int, input WebhookInput) (*model.APIResponse, error) {
+	hook, err := s.webhookRepo.FindByID(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "更新Webhook失败"}, err
+	}
+	if hook == nil {
+		return &model.APIResponse{Success: false, Error: "Webhook不存在"}, nil
+	}
+
+	if msg := s.validateInput(&input); msg != "" {
+		return &model.APIResponse{Success: false, Error: msg}, nil
+	}
+
+	hook.Name = input.Name
+	hook.URL = input.URL
+	hook.Events = input.Events
+	hook.IsActive = input.IsActive
+
+	if _, err := s.webhookRepo.UpdateWebhook(hook); err != nil {
+		return &model.APIResponse{Success: false, Error: "更新Webhook失败"}, err
+	}
+
+	s.logger.Info("✅ Webhook更新成功", zap.Int("id", id), zap.Strings("events", hook.Events), zap.Bool("is_active", hook.IsActive))
+	return &model.APIResponse{Success: true, Message: "Webhook更新成功", Data: hook}, nil
+}
+
+// DeleteWebhook 删除Webhook
+func (s *WebhookService) DeleteWebhook(id int) (*model.APIResponse, error) {
+	deleted, err := s.webhookRepo.DeleteWebhook(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "删除Webhook失败"}, err
+	}
+	if !deleted {
+		return &model.APIResponse{Success: false, Error: "Webhook不存在"}, nil
+	}
+
+	s.logger.Info("🗑️ Webhook已删除", zap.Int("id", id))
+	return &model.APIResponse{Success: true, Message: "Webhook删除成功"}, nil
+}
+
+// ListDeliveries 获取Webhook最近的投递记录
+func (s *WebhookService) ListDeliveries(id, limit int) (*model.APIResponse, error) {
+	if limit <= 0 || limit > 200 {
+		limit = webhookDeliveriesLimit
+	}
+	deliveries, err := s.webhookRepo.GetDeliveries(id, limit)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取投递记录失败"}, err
+	}
+	return &model.APIResponse{Success: true, Data: deliveries}, nil
+}
+
+// TestWebhook 同步投递一次 ping 事件（不重试），用于验证地址与签名
+func (s *WebhookService) TestWebhook(ctx context.Context, id int) (*model.APIResponse, error) {
+	hook, err := s.webhookRepo.FindByID(id)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "获取Webhook失败"}, err
+	}
+	if hook == nil {
+		return &model.APIResponse{Success: false, Error: "Webhook不存在"}, nil
+	}
+
+	delivery, err := s.deliver(ctx, hook, model.WebhookEventPing, map[string]interface{}{"webhook_id": hook.ID}, 1)
+	if err != nil {
+		return &model.APIResponse{Success: false, Error: "创建投递记录失败"}, err
+	}
+	if delivery.Status != model.WebhookDeliverySuccess {
+		msg := "测试投递失败"
+		if delivery.ErrorMessage != nil {
+			msg += ": " + *delivery.ErrorMessage
+		}
+		return &model.APIResponse{Success: false, Error: msg, Data: delivery}, nil
+	}
+	return &model.APIResponse{Success: true, Message: "测试投递成功", Data: delivery}, nil
+}
+
+// Notify 通知订阅了该事件的Webhook：同步写入投递记录后异步投递，进程退出时未完成的记录由 ResumePendingDeliveries 继续；
+// s 为 nil 时不做任何事
+func (s *WebhookService) Notify(event string, data interface{}) {
+	if s == nil {
+		return
+	}
+	hooks, err := s.webhookRepo.GetActiveWebhooks()
+	if err != nil {
+		s.logger.Warn("获取Webhook订阅失败", zap.String("event", event), zap.Error(err))
+		return
+	}
+	for i := range hooks {
+		if !subscribes(&hooks[i], event) {
+			continue
+		}
+		delivery, err := s.createDelivery(&hooks[i], event, data)
+		if err != nil {
+			s.logger.Warn("Webhook投递失败", zap.Int("webhook_id", hooks[i].ID), zap.String("event", event), zap.Error(err))
+			continue
+		}
+		go func(hook *model.Webhook) {
+			if _, err := s.attemptDelivery(context.Background(), hook, delivery, s.maxAttempts); err != nil {
+				s.logger.Warn("Webhook投递失败", zap.Int("webhook_id", hook.ID), zap.String("event", event), zap.Error(err))
+			}
+		}(&hooks[i])
+	}
+}
+
+// ResumePendingDeliveries 启动时继续投递上次进程退出前未完成的记录（重试仅在内存中等待，重启后不会自动继续）；
+// Webhook 已删除或停用、ping 测试及已达最大尝试次数的记录直接标记为失败
+func (s *WebhookService) ResumePendingDeliveries() {
+	deliveries, err := s.webhookRepo.GetPendingDeliveries()
+	if err != nil {
+		s.logger.Warn("获取未完成的Webhook投递失败", zap.Error(err))
+		return
+	}
+	hooks := map[int]*model.Webhook{}
+	resumed := 0
+	for i := range deliveries {
+		d := &deliveries[i]
+		hook, ok := hooks[d.WebhookID]
+		if !ok {
+			if hook, err = s.webhookRepo.FindByID(d.WebhookID); err != nil {
+				continue // 下次启动再处理
+			}
+			hooks[d.WebhookID] = hook
+		}
+
+		reason := ""
+		switch {
+		case hook == nil || !hook.IsActive:
+			reason = "webhook deleted or disabled before delivery completed"
+		case d.Event == model.WebhookEventPing:
+			reason = "test delivery interrupted by restart"
+		case d.Attempts >= s.maxAttempts:
+			reason = "max attempts reached before restart"
+		}
+		if reason != "" {
+			if d.ErrorMessage == nil {
+				d.ErrorMessage = &reason
+			}
+			_ = s.webhookRepo.UpdateDelivery(d.ID, model.WebhookDeliveryFailed, d.Attempts, d.ResponseStatus, d.ErrorMessage)
+			continue
+		}
+
+		resumed++
+		go func(hook *model.Webhook, d *model.WebhookDelivery) {
+			if _, err := s.attemptDelivery(context.Background(), hook, d, s.maxAttempts); err != nil {
+				s.logger.Warn("Webhook投递失败", zap.Int("webhook_id", hook.ID), zap.String("event", d.Event), zap.Error(err))
+			}
+		}(hook, d)
+	}
+	if len(deliveries) > 0 {
+		s.logger.Info("📤 已处理未完成的Webhook投递", zap.Int("pending", len(deliveries)), zap.Int("resumed", resumed))
+	}
+}
+
+// subscribes 事件过滤为空表示订阅全部
+func subscribes(hook *model.Webhook, event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver 写入投递记录并按退避（1s、5s、25s...）投递，直至成功或达到 maxAttempts
+func (s *WebhookService) deliver(ctx context.Context, hook *model.Webhook, event string, data interface{}, maxAttempts int) (*model.WebhookDelivery, error) {
+	delivery, err := s.createDelivery(hook, event, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.attemptDelivery(ctx, hook, delivery, maxAttempts)
+}
+
+// createDelivery 生成请求体并写入待投递记录
+func (s *WebhookService) createDelivery(hook *model.Webhook, event string, data interface{}) (*model.WebhookDelivery, error) {
+	body, err := json.Marshal(webhookPayload{Event: event, Timestamp: time.Now(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	deliveryID, err := s.webhookRepo.CreateDelivery(hook.ID, event, string(body))
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebhookDelivery{
+		ID:        deliveryID,
+		WebhookID: hook.ID,
+		Event:     event,
+		Payload:   string(body),
+		Status:    model.WebhookDeliveryPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// attemptDelivery 从 delivery.Attempts+1 次开始投递已有记录，直至成功或达到 maxAttempts
+func (s *WebhookService) attemptDelivery(ctx context.Context, hook *model.Webhook, delivery *model.WebhookDelivery, maxAttempts int) (*model.WebhookDelivery, error) {
+	deliveryID, event, body := delivery.ID, delivery.Event, []byte(delivery.Payload)
+	log := s.logger.With(zap.Int("webhook_id", hook.ID), zap.Int64("delivery_id", deliveryID), zap.String("event", event))
+
+	backoff := time.Second
+	for attempt := delivery.Attempts + 1; attempt <= maxAttempts; attempt++ {
+		s.sem <- struct{}{}
+		statusCode, sendErr := s.send(ctx, hook, event, deliveryID, body)
+		<-s.sem
+
+		delivery.Attempts = attempt
+		delivery.ResponseStatus = nil
+		delivery.ErrorMessage = nil
+		if statusCode > 0 {
+			code := statusCode
+			delivery.ResponseStatus = &code
+		}
+
+		if sendErr == nil {
+			now := time.Now()
+			delivery.Status = model.WebhookDeliverySuccess
+			delivery.DeliveredAt = &now
+			metrics.WebhookDeliveries.WithLabelValues(event, "success").Inc()
+		} else {
+			msg := sendErr.Error()
+			delivery.ErrorMessage = &msg
+			delivery.Status = model.WebhookDeliveryPending
+			if attempt == maxAttempts {
+				delivery.Status = model.WebhookDeliveryFailed
+			}
+			metrics.WebhookDeliveries.WithLabelValues(event, "failed").Inc()
+		}
+
+		if err := s.webhookRepo.UpdateDelivery(deliveryID, delivery.Status, attempt, delivery.ResponseStatus, delivery.ErrorMessage); err != nil {
+			log.Warn("更新Webhook投递记录失败", zap.Error(err))
+		}
+
+		if sendErr == nil {
+			log.Info("📤 Webhook投递成功", zap.Int("attempt", attempt), zap.Int("status", statusCode))
+			return delivery, nil
+		}
+		if attempt == maxAttempts {
+			log.Warn("❌ Webhook投递失败，已达最大尝试次数", zap.Int("attempts", attempt), zap.Error(sendErr))
+			return delivery, nil
+		}
+
+		log.Debug("Webhook投递失败，稍后重试", zap.Int("attempt", attempt), zap.Duration("delay", backoff), zap.Error(sendErr))
+		select {
+		case <-ctx.Done():
+			return delivery, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 5
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+	return delivery, nil
+}
+
+// send 发送一次签名请求，2xx 视为成功
+func (s *WebhookService) send(ctx context.Context, hook *model.Webhook, event string, deliveryID int64, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wjdr-webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, event)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(hook.Secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(strings.ToValidUTF8(string(snippet), "")))
+	}
+	return resp.StatusCode, nil
+}
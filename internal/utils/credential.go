@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// credentialPrefix 加密凭据的前缀，格式为 enc:v1:<密钥ID>:<base64(nonce+密文)>；无此前缀的值视为旧版明文
+const credentialPrefix = "enc:v1:"
+
+// ErrCredentialKeyMissing 凭据使用的主密钥未配置
+var ErrCredentialKeyMissing = errors.New("credential master key not configured")
+
+// CredentialCipher 使用 AES-256-GCM 加密数据库中的第三方凭据（如 OCR Key），主密钥带 ID 以支持轮换：
+// 加密始终使用当前主密钥，解密按密文中的 ID 选择主密钥
+type CredentialCipher struct {
+	activeID string
+	aeads    map[string]cipher.AEAD
+}
+
+// NewCredentialCipher keys 为 密钥ID -> base64 编码的 32 字节主密钥；activeID 为空时不加密（仍可解密已配置密钥加密的值）
+func NewCredentialCipher(activeID string, keys map[string]string) (*CredentialCipher, error) {
+	c := &CredentialCipher{activeID: activeID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid credential key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("credential key %s: invalid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("credential key %s: must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[id] = aead
+	}
+	if activeID != "" {
+		if _, ok := c.aeads[activeID]; !ok {
+			return nil, fmt.Errorf("active credential key %s not found", activeID)
+		}
+	}
+	return c, nil
+}
+
+// Enabled 是否配置了当前主密钥（未配置时新凭据按明文保存）
+func (c *CredentialCipher) Enabled() bool {
+	return c != nil && c.activeID != ""
+}
+
+// ActiveKeyID 当前主密钥ID
+func (c *CredentialCipher) ActiveKeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.activeID
+}
+
+// Encrypt 使用当前主密钥加密；空串与未启用加密时原样返回
+func (c *CredentialCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !c.Enabled() {
+		return plaintext, nil
+	}
+	aead := c.aeads[c.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(c.activeID))
+	return credentialPrefix + c.activeID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密凭据；旧版明文原样返回
+func (c *CredentialCipher) Decrypt(value string) (string, error) {
+	id := CredentialKeyID(value)
+	if id == "" {
+		return value, nil
+	}
+	var aead cipher.AEAD
+	if c != nil {
+		aead = c.aeads[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("%w: %s", ErrCredentialKeyMissing, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(credentialPrefix)+len(id)+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed credential (key %s)", id)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("decrypt credential (key %s): %w", id, err)
+	}
+	return string(plain), nil
+}
+
+// CredentialKeyID 返回加密凭据使用的主密钥ID；明文返回空串
+func CredentialKeyID(value string) string {
+	if !strings.HasPrefix(value, credentialPrefix) {
+		return ""
+	}
+	rest := value[len(credentialPrefix):]
+	i := strings.Index(rest, ":")
+	if i <= 0 {
+		return ""
+	}
+	return rest[:i]
+}
+
+// CredentialHint 凭据末尾 4 位，用于脱敏展示（不足 8 位时不保留任何字符）
+func CredentialHint(plaintext string) string {
+	if len(plaintext) < 8 {
+		return ""
+	}
+	return plaintext[len(plaintext)-4:]
+}
+
+// MaskCredential 脱敏展示：已设置时返回 "****" + 末尾提示，未设置返回空串
+func MaskCredential(set bool, hint string) string {
+	if !set {
+		return ""
+	}
+	return "****" + hint
+}
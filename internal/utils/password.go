@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHashCost bcrypt 计算成本
+const PasswordHashCost = 12
+
+// HashPassword 使用 bcrypt 生成加盐密码摘要
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordHashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码与 bcrypt 摘要是否匹配
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// CheckDummyPassword 与占位摘要做一次同成本的比较（结果恒为不匹配），用于用户不存在时对齐耗时，避免按响应时间枚举用户名
+func CheckDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), PasswordHashCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// PasswordNeedsRehash 判断摘要是否需要升级（非 bcrypt 或成本低于当前设置）
+func PasswordNeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < PasswordHashCost
+}
@@ -2,9 +2,12 @@ package utils
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // GenerateAccountSign 生成添加账号的签名（SHA256前32位）
@@ -38,8 +41,20 @@ func GenerateAccountSign(fid, timestamp, salt string) string {
 	return fullHash[:32]
 }
 
-// VerifyAccountSign 验证添加账号的签名
+// VerifyAccountSign 验证添加账号的签名（常量时间比较，避免时序侧信道）
 func VerifyAccountSign(fid, timestamp, providedSign, salt string) bool {
 	expectedSign := GenerateAccountSign(fid, timestamp, salt)
-	return expectedSign == providedSign
+	return subtle.ConstantTimeCompare([]byte(expectedSign), []byte(providedSign)) == 1
+}
+
+// ParseSignTimestamp 解析签名时间戳，兼容秒级与毫秒级（前端 Date.now()）
+func ParseSignTimestamp(timestamp string) (time.Time, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间戳: %s", timestamp)
+	}
+	if n > 1e12 {
+		return time.UnixMilli(n), nil
+	}
+	return time.Unix(n, 0), nil
 }
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"wjdr-backend-go/internal/metrics"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
 
@@ -42,7 +43,7 @@ const (
 func NewJobQueue(capacity int, repo *repository.JobRepository, logger *zap.Logger) *JobQueue {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &JobQueue{
+	jq := &JobQueue{
 		queue:       make(chan *Job, capacity),
 		repo:        repo,
 		logger:      logger,
@@ -50,6 +51,8 @@ func NewJobQueue(capacity int, repo *repository.JobRepository, logger *zap.Logge
 		cancel:      cancel,
 		maxCapacity: capacity,
 	}
+	metrics.RegisterQueueDepth(jq.GetQueueLength, jq.GetQueueCapacity)
+	return jq
 }
 
 // Start 启动任务队列
@@ -126,6 +129,7 @@ func (jq *JobQueue) MarkJobFailed(jobID int64, errorMessage string) error {
 // RetryJob 重试任务
 func (jq *JobQueue) RetryJob(job *Job, errorMessage string) error {
 	if job.Retries >= job.MaxRetries {
+		metrics.JobRetries.WithLabelValues(job.Type, "true").Inc()
 		// 达到最大重试次数，标记为失败
 		return jq.MarkJobFailed(job.ID, fmt.Sprintf("达到最大重试次数: %s", errorMessage))
 	}
@@ -144,6 +148,8 @@ func (jq *JobQueue) RetryJob(job *Job, errorMessage string) error {
 		return err
 	}
 
+	metrics.JobRetries.WithLabelValues(job.Type, "false").Inc()
+
 	jq.logger.Info("🔄 任务将重试",
 		zap.Int64("job_id", job.ID),
 		zap.Int("retries", job.Retries+1),
@@ -153,6 +159,15 @@ func (jq *JobQueue) RetryJob(job *Job, errorMessage string) error {
 	return nil
 }
 
+// OldestPendingAge 积压任务中最早一个已等待的时长（无积压时为0）
+func (jq *JobQueue) OldestPendingAge() (time.Duration, error) {
+	oldest, err := jq.repo.GetOldestPendingCreatedAt()
+	if err != nil || oldest == nil {
+		return 0, err
+	}
+	return time.Since(*oldest), nil
+}
+
 // GetQueueLength 获取队列长度
 func (jq *JobQueue) GetQueueLength() int {
 	return len(jq.queue)
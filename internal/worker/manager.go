@@ -1,11 +1,16 @@
 package worker
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/events"
+	"wjdr-backend-go/internal/logging"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/tracing"
 
 	"go.uber.org/zap"
 )
@@ -33,6 +38,7 @@ func NewManager(
 	accountRepo *repository.AccountRepository,
 	redeemRepo *repository.RedeemRepository,
 	logRepo *repository.LogRepository,
+	eventBus *events.Bus,
 	logger *zap.Logger,
 ) *Manager {
 	// 创建任务队列
@@ -53,6 +59,7 @@ func NewManager(
 		accountRepo,
 		redeemRepo,
 		logRepo,
+		eventBus,
 		logger,
 	)
 
@@ -64,6 +71,11 @@ func NewManager(
 	}
 }
 
+// SetNotifier 设置任务完成与重试耗尽的出站通知（需在 Start 前调用）
+func (m *Manager) SetNotifier(n Notifier) {
+	m.workerPool.SetNotifier(n)
+}
+
 // Start 启动Manager
 func (m *Manager) Start() error {
 	m.mu.Lock()
@@ -116,37 +128,48 @@ func (m *Manager) IsStarted() bool {
 }
 
 // SubmitRedeemTask 提交兑换任务
-func (m *Manager) SubmitRedeemTask(redeemCodeID int, accountIDs []int) (int64, error) {
+func (m *Manager) SubmitRedeemTask(ctx context.Context, redeemCodeID int, accountIDs []int) (int64, error) {
 	payload := model.JobPayload{
 		RedeemCodeID: redeemCodeID,
 		AccountIDs:   accountIDs,
 		IsRetry:      false,
+		TraceContext: tracing.Inject(ctx),
+		RequestID:    logging.RequestID(ctx),
 	}
 
 	return m.jobQueue.Enqueue(JobTypeRedeem, payload, 3)
 }
 
 // SubmitRetryTask 提交重试任务
-func (m *Manager) SubmitRetryTask(redeemCodeID int, accountIDs []int) (int64, error) {
+func (m *Manager) SubmitRetryTask(ctx context.Context, redeemCodeID int, accountIDs []int) (int64, error) {
 	payload := model.JobPayload{
 		RedeemCodeID: redeemCodeID,
 		AccountIDs:   accountIDs,
 		IsRetry:      true,
+		TraceContext: tracing.Inject(ctx),
+		RequestID:    logging.RequestID(ctx),
 	}
 
 	return m.jobQueue.Enqueue(JobTypeRetryRedeem, payload, 3)
 }
 
 // SubmitSupplementTask 提交补充兑换任务
-func (m *Manager) SubmitSupplementTask(redeemCodeID int) (int64, error) {
+func (m *Manager) SubmitSupplementTask(ctx context.Context, redeemCodeID int) (int64, error) {
 	payload := model.JobPayload{
 		RedeemCodeID: redeemCodeID,
 		IsRetry:      false,
+		TraceContext: tracing.Inject(ctx),
+		RequestID:    logging.RequestID(ctx),
 	}
 
 	return m.jobQueue.Enqueue(JobTypeSupplementRedeem, payload, 2)
 }
 
+// BacklogAge 返回任务积压时长（最早一个待处理任务已等待的时间）
+func (m *Manager) BacklogAge() (time.Duration, error) {
+	return m.jobQueue.OldestPendingAge()
+}
+
 // GetStats 获取统计信息
 func (m *Manager) GetStats() (map[string]interface{}, error) {
 	jobStats, err := m.jobQueue.GetJobStats()
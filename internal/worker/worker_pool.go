@@ -7,9 +7,14 @@ import (
 	"time"
 
 	"wjdr-backend-go/internal/client"
+	"wjdr-backend-go/internal/events"
+	"wjdr-backend-go/internal/logging"
+	"wjdr-backend-go/internal/metrics"
 	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
+	"wjdr-backend-go/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
@@ -22,6 +27,8 @@ type WorkerPool struct {
 	accountRepo   *repository.AccountRepository
 	redeemRepo    *repository.RedeemRepository
 	logRepo       *repository.LogRepository
+	eventBus      *events.Bus
+	notifier      Notifier
 	rateLimiter   *rate.Limiter
 	logger        *zap.Logger
 	ctx           context.Context
@@ -30,6 +37,11 @@ type WorkerPool struct {
 	workerWg      sync.WaitGroup
 }
 
+// Notifier 出站通知（由 service.WebhookService 实现）；任务完成与重试耗尽直接通知，不经过只用于 SSE 的事件总线
+type Notifier interface {
+	Notify(event string, data interface{})
+}
+
 // WorkerPoolConfig Worker池配置
 type WorkerPoolConfig struct {
 	Concurrency  int // Worker并发数
@@ -43,6 +55,7 @@ func NewWorkerPool(
 	accountRepo *repository.AccountRepository,
 	redeemRepo *repository.RedeemRepository,
 	logRepo *repository.LogRepository,
+	eventBus *events.Bus,
 	logger *zap.Logger,
 ) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -57,6 +70,7 @@ func NewWorkerPool(
 		accountRepo:   accountRepo,
 		redeemRepo:    redeemRepo,
 		logRepo:       logRepo,
+		eventBus:      eventBus,
 		rateLimiter:   limiter,
 		logger:        logger,
 		ctx:           ctx,
@@ -90,6 +104,17 @@ func (wp *WorkerPool) Stop() {
 	wp.logger.Info("✅ Worker池已停止")
 }
 
+// SetNotifier 设置出站通知（需在 Start 前调用）
+func (wp *WorkerPool) SetNotifier(n Notifier) {
+	wp.notifier = n
+}
+
+func (wp *WorkerPool) notify(event string, data map[string]interface{}) {
+	if wp.notifier != nil {
+		wp.notifier.Notify(event, data)
+	}
+}
+
 // worker 单个Worker的工作循环
 func (wp *WorkerPool) worker(workerID int) {
 	defer wp.workerWg.Done()
@@ -115,63 +140,102 @@ func (wp *WorkerPool) worker(workerID int) {
 func (wp *WorkerPool) processJob(workerID int, job *Job) {
 	startTime := time.Now()
 
-	wp.logger.Debug("🔨 开始处理任务",
-		zap.Int("worker_id", workerID),
+	// 任务内的日志统一带上 job_id / redeem_code_id，以及提交任务的 request_id
+	fields := []zap.Field{
 		zap.Int64("job_id", job.ID),
-		zap.String("type", job.Type))
+		zap.String("job_type", job.Type),
+		zap.Int("redeem_code_id", job.Payload.RedeemCodeID),
+	}
+	if job.Payload.RequestID != "" {
+		fields = append(fields, zap.String("request_id", job.Payload.RequestID))
+	}
+	log := wp.logger.With(fields...)
+
+	log.Debug("🔨 开始处理任务", zap.Int("worker_id", workerID))
 
 	// 标记任务为处理中
 	if err := wp.jobQueue.MarkJobProcessing(job.ID); err != nil {
-		wp.logger.Error("标记任务处理中失败", zap.Error(err))
+		log.Error("标记任务处理中失败", zap.Error(err))
 		return
 	}
 
 	// 限流控制
 	if err := wp.rateLimiter.Wait(wp.ctx); err != nil {
-		wp.logger.Error("限流等待被取消", zap.Error(err))
+		log.Error("限流等待被取消", zap.Error(err))
 		return
 	}
 
+	metrics.JobsInFlight.Inc()
+	defer metrics.JobsInFlight.Dec()
+
+	// 恢复提交任务时的链路上下文，使 Worker 的 span 挂在发起请求之下
+	ctx := logging.WithLogger(tracing.Extract(job.Payload.TraceContext), log)
+	ctx, span := tracing.Start(ctx, "job."+job.Type,
+		attribute.Int64("job_id", job.ID),
+		attribute.Int("redeem_code_id", job.Payload.RedeemCodeID))
+
 	var err error
 	switch job.Type {
 	case JobTypeRedeem:
-		err = wp.processRedeemJob(job)
+		err = wp.processRedeemJob(ctx, job)
 	case JobTypeRetryRedeem:
-		err = wp.processRetryRedeemJob(job)
+		err = wp.processRetryRedeemJob(ctx, job)
 	case JobTypeSupplementRedeem:
-		err = wp.processSupplementRedeemJob(job)
+		err = wp.processSupplementRedeemJob(ctx, job)
 	default:
 		err = fmt.Errorf("未知任务类型: %s", job.Type)
 	}
+	tracing.End(span, err)
 
 	duration := time.Since(startTime)
 
+	jobResult := "success"
+	if err != nil {
+		jobResult = "failed"
+	}
+	metrics.JobDuration.WithLabelValues(job.Type, jobResult).Observe(duration.Seconds())
+
 	if err != nil {
-		wp.logger.Error("❌ 任务处理失败",
+		log.Error("❌ 任务处理失败",
 			zap.Int("worker_id", workerID),
-			zap.Int64("job_id", job.ID),
+			zap.String("trace_id", tracing.TraceID(ctx)),
 			zap.Error(err),
 			zap.Duration("duration", duration))
 
-		// 尝试重试
+		// 尝试重试（达到最大重试次数时任务被标记为失败）
+		deadLettered := job.Retries >= job.MaxRetries
 		if retryErr := wp.jobQueue.RetryJob(job, err.Error()); retryErr != nil {
-			wp.logger.Error("任务重试失败", zap.Error(retryErr))
+			log.Error("任务重试失败", zap.Error(retryErr))
+		} else if deadLettered {
+			wp.eventBus.Publish(events.Event{
+				Type:         events.TypeJobDeadLettered,
+				JobID:        job.ID,
+				JobType:      job.Type,
+				RedeemCodeID: job.Payload.RedeemCodeID,
+				Message:      err.Error(),
+			})
+			wp.notify(model.WebhookEventJobDeadLettered, map[string]interface{}{
+				"job_id":         job.ID,
+				"job_type":       job.Type,
+				"redeem_code_id": job.Payload.RedeemCodeID,
+				"error":          err.Error(),
+			})
 		}
 	} else {
-		wp.logger.Debug("✅ 任务处理成功",
+		log.Debug("✅ 任务处理成功",
 			zap.Int("worker_id", workerID),
-			zap.Int64("job_id", job.ID),
 			zap.Duration("duration", duration))
 
 		// 标记任务为完成
 		if err := wp.jobQueue.MarkJobCompleted(job.ID); err != nil {
-			wp.logger.Error("标记任务完成失败", zap.Error(err))
+			log.Error("标记任务完成失败", zap.Error(err))
 		}
 	}
 }
 
 // processRedeemJob 处理兑换任务
-func (wp *WorkerPool) processRedeemJob(job *Job) error {
+func (wp *WorkerPool) processRedeemJob(ctx context.Context, job *Job) error {
+	log := logging.FromContext(ctx, wp.logger)
 	payload := job.Payload
 
 	// 获取兑换码信息
@@ -223,16 +287,16 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 	if accounts[0].FID != "" {
 		testFID = accounts[0].FID
 	}
-	wp.logger.Info("🔍 后台预验证兑换码",
+	log.Info("🔍 后台预验证兑换码",
 		zap.String("code", redeemCode.Code),
 		zap.String("test_fid", testFID))
-	verifyResult, err := wp.automationSvc.RedeemSingle(testFID, redeemCode.Code)
+	verifyResult, err := wp.automationSvc.RedeemSingle(ctx, testFID, redeemCode.Code)
 	if err != nil {
 		// 网络或服务异常，返回错误以触发重试
 		return fmt.Errorf("预验证异常: %w", err)
 	}
 	if verifyResult.IsFatal {
-		wp.logger.Warn("❌ 预验证致命错误，终止任务",
+		log.Warn("❌ 预验证致命错误，终止任务",
 			zap.String("error", verifyResult.Error),
 			zap.Int("err_code", verifyResult.ErrCode),
 			zap.String("code", redeemCode.Code))
@@ -243,7 +307,7 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 		return nil
 	}
 
-	wp.logger.Info("📦 开始批量兑换",
+	log.Info("📦 开始批量兑换",
 		zap.String("code", redeemCode.Code),
 		zap.Int("accounts_count", len(accounts)))
 
@@ -253,6 +317,14 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 		return fmt.Errorf("更新兑换码状态失败: %w", err)
 	}
 
+	wp.eventBus.Publish(events.Event{
+		Type:         events.TypeBatchStarted,
+		JobID:        job.ID,
+		RedeemCodeID: redeemCode.ID,
+		GiftCode:     redeemCode.Code,
+		Total:        len(accounts),
+	})
+
 	// 转换账号格式
 	clientAccounts := make([]client.Account, len(accounts))
 	for i, acc := range accounts {
@@ -263,7 +335,7 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 	}
 
 	// 执行批量兑换
-	results, err := wp.automationSvc.RedeemBatch(clientAccounts, redeemCode.Code)
+	results, err := wp.automationSvc.RedeemBatch(ctx, clientAccounts, redeemCode.Code)
 	if err != nil {
 		return fmt.Errorf("批量兑换失败: %w", err)
 	}
@@ -271,6 +343,7 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 	// 记录兑换日志（仅在账号最终结果明确后写入一次，不在中途重试阶段写入）
 	successCount := 0
 	failedCount := 0
+	categoryStats := make(map[string]int)
 
 	for _, result := range results {
 		var errorMessage, successMessage, captchaRecognized *string
@@ -301,6 +374,12 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 		if result.Success {
 			resultStr = "success"
 		}
+		category := result.Category
+		if category == "" {
+			category = client.ClassifyRedeemResult(result.Success, result.ErrCode)
+		}
+		categoryStats[category]++
+		metrics.RedeemResults.WithLabelValues(category).Inc()
 
 		// 替换式写入兑换日志（每个账号最终结果一次）
 		_, err := wp.logRepo.ReplaceRedeemLog(
@@ -309,6 +388,7 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 			result.FID,
 			redeemCode.Code,
 			resultStr,
+			category,
 			errorMessage,
 			successMessage,
 			captchaRecognized,
@@ -316,16 +396,16 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 			errCode,
 		)
 		if err != nil {
-			wp.logger.Error("创建兑换日志失败",
+			log.Error("创建兑换日志失败",
 				zap.Error(err),
 				zap.String("fid", result.FID))
 		}
 	}
 
 	// 更新兑换码统计
-	err = wp.redeemRepo.UpdateRedeemCodeStats(redeemCode.ID, successCount, failedCount, len(accounts))
+	err = wp.redeemRepo.UpdateRedeemCodeStats(redeemCode.ID, successCount, failedCount, len(accounts), categoryStats)
 	if err != nil {
-		wp.logger.Error("更新兑换码统计失败", zap.Error(err))
+		log.Error("更新兑换码统计失败", zap.Error(err))
 	}
 
 	// 更新兑换码状态为完成
@@ -334,7 +414,25 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 		return fmt.Errorf("更新兑换码完成状态失败: %w", err)
 	}
 
-	wp.logger.Info("📊 兑换任务完成",
+	wp.eventBus.Publish(events.Event{
+		Type:         events.TypeBatchCompleted,
+		JobID:        job.ID,
+		RedeemCodeID: redeemCode.ID,
+		GiftCode:     redeemCode.Code,
+		Total:        len(accounts),
+		SuccessCount: successCount,
+		FailedCount:  failedCount,
+	})
+	wp.notify(model.WebhookEventBatchCompleted, map[string]interface{}{
+		"job_id":         job.ID,
+		"redeem_code_id": redeemCode.ID,
+		"gift_code":      redeemCode.Code,
+		"total":          len(accounts),
+		"success_count":  successCount,
+		"failed_count":   failedCount,
+	})
+
+	log.Info("📊 兑换任务完成",
 		zap.String("code", redeemCode.Code),
 		zap.Int("success", successCount),
 		zap.Int("failed", failedCount),
@@ -344,13 +442,14 @@ func (wp *WorkerPool) processRedeemJob(job *Job) error {
 }
 
 // processRetryRedeemJob 处理重试兑换任务
-func (wp *WorkerPool) processRetryRedeemJob(job *Job) error {
+func (wp *WorkerPool) processRetryRedeemJob(ctx context.Context, job *Job) error {
 	// 重试兑换任务与普通兑换任务类似，但可能包含特定的账号列表
-	return wp.processRedeemJob(job)
+	return wp.processRedeemJob(ctx, job)
 }
 
 // processSupplementRedeemJob 处理补充兑换任务
-func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
+func (wp *WorkerPool) processSupplementRedeemJob(ctx context.Context, job *Job) error {
+	log := logging.FromContext(ctx, wp.logger)
 	payload := job.Payload
 
 	// 获取兑换码信息
@@ -388,15 +487,23 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 	}
 
 	if len(newAccounts) == 0 {
-		wp.logger.Info("💫 没有新账号需要补充兑换",
+		log.Info("💫 没有新账号需要补充兑换",
 			zap.String("code", redeemCode.Code))
 		return nil
 	}
 
-	wp.logger.Info("🔄 开始补充兑换",
+	log.Info("🔄 开始补充兑换",
 		zap.String("code", redeemCode.Code),
 		zap.Int("new_accounts", len(newAccounts)))
 
+	wp.eventBus.Publish(events.Event{
+		Type:         events.TypeBatchStarted,
+		JobID:        job.ID,
+		RedeemCodeID: redeemCode.ID,
+		GiftCode:     redeemCode.Code,
+		Total:        len(newAccounts),
+	})
+
 	// 转换账号格式
 	clientAccounts := make([]client.Account, len(newAccounts))
 	for i, acc := range newAccounts {
@@ -407,7 +514,7 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 	}
 
 	// 执行补充兑换
-	results, err := wp.automationSvc.RedeemBatch(clientAccounts, redeemCode.Code)
+	results, err := wp.automationSvc.RedeemBatch(ctx, clientAccounts, redeemCode.Code)
 	if err != nil {
 		return fmt.Errorf("补充兑换失败: %w", err)
 	}
@@ -415,6 +522,7 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 	// 记录兑换日志并更新统计（仅在账号最终结果明确后写入一次）
 	successCount := 0
 	failedCount := 0
+	categoryStats := make(map[string]int)
 
 	for _, result := range results {
 		var errorMessage, successMessage, captchaRecognized *string
@@ -445,6 +553,12 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 		if result.Success {
 			resultStr = "success"
 		}
+		category := result.Category
+		if category == "" {
+			category = client.ClassifyRedeemResult(result.Success, result.ErrCode)
+		}
+		categoryStats[category]++
+		metrics.RedeemResults.WithLabelValues(category).Inc()
 
 		// 替换式写入兑换日志（每个账号最终结果一次）
 		_, err := wp.logRepo.ReplaceRedeemLog(
@@ -453,6 +567,7 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 			result.FID,
 			redeemCode.Code,
 			resultStr,
+			category,
 			errorMessage,
 			successMessage,
 			captchaRecognized,
@@ -460,7 +575,7 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 			errCode,
 		)
 		if err != nil {
-			wp.logger.Error("创建补充兑换日志失败",
+			log.Error("创建补充兑换日志失败",
 				zap.Error(err),
 				zap.String("fid", result.FID))
 		}
@@ -469,15 +584,39 @@ func (wp *WorkerPool) processSupplementRedeemJob(job *Job) error {
 	// 重新计算并更新兑换码统计
 	total, success, failed, err := wp.logRepo.GetLogStats(redeemCode.ID)
 	if err != nil {
-		wp.logger.Error("获取兑换统计失败", zap.Error(err))
+		log.Error("获取兑换统计失败", zap.Error(err))
 	} else {
-		err = wp.redeemRepo.UpdateRedeemCodeStats(redeemCode.ID, success, failed, total)
+		// 分类统计同样以全量日志为准（包含此前批次的账号）
+		allCategoryStats, catErr := wp.logRepo.GetLogCategoryStats(redeemCode.ID)
+		if catErr != nil {
+			// 分类统计置空，可通过 /api/accounts/fix-stats 重算
+			log.Warn("获取兑换分类统计失败", zap.Error(catErr))
+		}
+		err = wp.redeemRepo.UpdateRedeemCodeStats(redeemCode.ID, success, failed, total, allCategoryStats)
 		if err != nil {
-			wp.logger.Error("更新兑换码统计失败", zap.Error(err))
+			log.Error("更新兑换码统计失败", zap.Error(err))
 		}
 	}
 
-	wp.logger.Info("📊 补充兑换完成",
+	wp.eventBus.Publish(events.Event{
+		Type:         events.TypeBatchCompleted,
+		JobID:        job.ID,
+		RedeemCodeID: redeemCode.ID,
+		GiftCode:     redeemCode.Code,
+		Total:        len(newAccounts),
+		SuccessCount: successCount,
+		FailedCount:  failedCount,
+	})
+	wp.notify(model.WebhookEventBatchCompleted, map[string]interface{}{
+		"job_id":         job.ID,
+		"redeem_code_id": redeemCode.ID,
+		"gift_code":      redeemCode.Code,
+		"total":          len(newAccounts),
+		"success_count":  successCount,
+		"failed_count":   failedCount,
+	})
+
+	log.Info("📊 补充兑换完成",
 		zap.String("code", redeemCode.Code),
 		zap.Int("success", successCount),
 		zap.Int("failed", failedCount),
@@ -13,13 +13,19 @@ import (
 
 	"wjdr-backend-go/internal/client"
 	"wjdr-backend-go/internal/config"
+	"wjdr-backend-go/internal/dataset"
+	"wjdr-backend-go/internal/events"
 	"wjdr-backend-go/internal/handler"
+	"wjdr-backend-go/internal/model"
 	"wjdr-backend-go/internal/repository"
 	"wjdr-backend-go/internal/service"
+	"wjdr-backend-go/internal/tracing"
+	"wjdr-backend-go/internal/utils"
 	"wjdr-backend-go/internal/worker"
 
 	"github.com/gin-gonic/gin"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -68,21 +74,32 @@ func main() {
 	logger := zap.New(zapcore.NewTee(consoleCore, fileCore), zap.AddStacktrace(zap.PanicLevel))
 	defer logger.Sync()
 
+	// 初始化链路追踪（未启用时 span 为空操作）
+	shutdownTracing, err := tracing.Init(cfg.Tracing, logger)
+	if err != nil {
+		logger.Fatal("初始化链路追踪失败", zap.Error(err))
+	}
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
-	// 创建路由
-	router := gin.Default()
-
-	// 健康检查端点
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
-			"timestamp": time.Now().Format(time.RFC3339),
-			"service":   "wjdr-backend-go",
-			"version":   "1.0.0",
-		})
-	})
+	// 创建路由：请求ID → zap 访问日志 → panic 恢复（替代 gin.Default 的文本日志）
+	router := gin.New()
+	// 客户端IP用于限流、登录锁定、API Key IP 白名单与审计，只采信可信代理转发的头部
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("TRUSTED_PROXIES 配置错误", zap.Error(err))
+	}
+	router.Use(
+		handler.RequestIDMiddleware(logger),
+		handler.AccessLogMiddleware(logger),
+		handler.RecoveryMiddleware(logger),
+	)
+	if cfg.Metrics.Enabled {
+		router.Use(handler.MetricsMiddleware())
+	}
+	if cfg.Tracing.Enabled {
+		router.Use(handler.TracingMiddleware())
+	}
 
 	// 基础信息端点（与Node版本保持一致）
 	router.GET("/", func(c *gin.Context) {
@@ -94,6 +111,7 @@ func main() {
 				"accounts": "/api/accounts",
 				"redeem":   "/api/redeem",
 				"admin":    "/api/admin",
+				"me":       "/api/me",
 			},
 		})
 	})
@@ -111,6 +129,13 @@ func main() {
 	logRepo := repository.NewLogRepository(db.GetDB(), logger)
 	adminRepo := repository.NewAdminRepository(db.GetDB(), logger)
 	jobRepo := repository.NewJobRepository(db.GetDB(), logger)
+	playerTokenRepo := repository.NewPlayerTokenRepository(db.GetDB(), logger)
+	auditRepo := repository.NewAuditRepository(db.GetDB(), logger)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.GetDB(), logger)
+	webhookRepo := repository.NewWebhookRepository(db.GetDB(), logger)
+
+	// 出站 Webhook（RSS新码、任务完成/失败、OCR额度用尽、账号失效）
+	webhookService := service.NewWebhookService(webhookRepo, cfg.Webhook, logger)
 
 	// 初始化Client
 	gameClient := client.NewGameClient(logger)
@@ -118,10 +143,37 @@ func main() {
 	ocrKeyRepo := repository.NewOCRKeyRepository(db.GetDB(), logger)
 	ocrKeySvc := service.NewOCRKeyService(ocrKeyRepo, logger)
 	ocrManager := client.NewOCRKeyManager(logger)
+	// OCR 凭据加密：数据库中保存密文，仅在 Reload 构建识别器时解密
+	credentialCipher, err := utils.NewCredentialCipher(cfg.Security.CredentialKeyID, cfg.Security.CredentialKeys)
+	if err != nil {
+		logger.Fatal("凭据加密主密钥配置错误", zap.Error(err))
+	}
+	if !credentialCipher.Enabled() {
+		logger.Warn("未配置 CREDENTIAL_KEYS，新增的OCR Key凭据将以明文保存")
+	}
+	ocrKeySvc.SetCredentialCipher(credentialCipher)
+	ocrManager.SetCredentialDecrypter(credentialCipher)
+	ocrManager.SetConsensus(client.ConsensusConfig{
+		Enabled:    cfg.OCR.ConsensusEnabled,
+		Voters:     cfg.OCR.ConsensusVoters,
+		MinAgree:   cfg.OCR.ConsensusMinAgree,
+		Confidence: cfg.OCR.ConsensusConfidence,
+		Fallback:   cfg.OCR.ConsensusFallback,
+	})
+	ocrManager.SetBreaker(client.BreakerConfig{
+		Failures:    cfg.OCR.BreakerFailures,
+		Cooldown:    cfg.OCR.BreakerCooldown,
+		MaxCooldown: cfg.OCR.BreakerMaxCooldown,
+	})
+	pipelines, err := client.NewCaptchaPipelines(cfg.OCR.Preprocess, cfg.OCR.PreprocessByProvider)
+	if err != nil {
+		logger.Fatal("验证码预处理流水线配置无效", zap.Error(err))
+	}
+	ocrManager.SetPipelines(pipelines)
 	// 错误码回调：标记额度并热更新
-	ocrManager.SetOnKeyExhausted(func(keyID int, code int, msg string) {
-		// 将 has_quota 置为 false，并刷新内存
-		if err := ocrKeySvc.MarkQuota(keyID, false); err != nil {
+	ocrManager.SetOnKeyExhausted(func(keyID int, code int, msg, reason string) {
+		// 将 has_quota 置为 false 并记录原因（额度重置只恢复因额度停用的 Key），刷新内存
+		if err := ocrKeySvc.MarkQuota(keyID, false, reason); err != nil {
 			logger.Warn("自动标记has_quota失败", zap.Int("key_id", keyID), zap.Int("code", code), zap.String("msg", msg), zap.Error(err))
 			return
 		}
@@ -131,14 +183,24 @@ func main() {
 			return
 		}
 		ocrManager.Reload(usable)
-		logger.Info("已自动禁用额度用尽的OCR Key", zap.Int("key_id", keyID), zap.Int("code", code))
+		logger.Info("已自动禁用OCR Key", zap.Int("key_id", keyID), zap.Int("code", code), zap.String("reason", reason))
+		webhookService.Notify(model.WebhookEventOCRKeyExhausted, map[string]interface{}{
+			"key_id":      keyID,
+			"error_code":  code,
+			"message":     msg,
+			"reason":      reason,
+			"usable_keys": len(usable),
+		})
 	})
-	// 统计上报：成功/失败计数
-	ocrManager.SetOnUsage(func(keyID int, success bool, errMsg *string) {
-		if err := ocrKeySvc.TouchUsage(keyID, success, errMsg); err != nil {
-			logger.Debug("更新OCR Key使用统计失败", zap.Int("key_id", keyID), zap.Error(err))
+	// 统计上报：成功/失败计数与额度扣减（内存累积，定时批量写入）
+	ocrManager.SetOnUsage(ocrKeySvc.RecordUsage)
+	// 游戏判定（验证码是否被接受）：持久化累计次数，重启后用于初始化准确率
+	ocrManager.SetOnVerdict(func(keyID int, correct bool) {
+		if err := ocrKeySvc.RecordVerdict(keyID, correct); err != nil {
+			logger.Debug("更新OCR Key判定统计失败", zap.Int("key_id", keyID), zap.Error(err))
 		}
 	})
+	ocrKeySvc.SetManager(ocrManager)
 	// 启动时仅加载数据库中的可用 Key（取消 ENV 兜底）
 	if usable, err := ocrKeySvc.ListUsable(); err == nil {
 		ocrManager.Reload(usable)
@@ -146,6 +208,24 @@ func main() {
 		logger.Warn("加载OCR Keys失败", zap.Error(err))
 	}
 	automationSvc := client.NewAutomationService(gameClient, ocrManager, logger)
+	// 兑换进度事件总线（SSE 推送给管理端）
+	eventBus := events.NewBus(cfg.Events.MaxSubscribers)
+	automationSvc.SetEventBus(eventBus)
+	// 继续投递上次退出前未完成的Webhook
+	webhookService.ResumePendingDeliveries()
+	// 验证码样本采集（按游戏判定自动标注，供重新训练模型）
+	if cfg.OCR.CaptureEnabled {
+		captureStore, err := dataset.NewStore(dataset.Options{
+			Dir:       cfg.OCR.CaptureDir,
+			MaxBytes:  int64(cfg.OCR.CaptureMaxMB) << 20,
+			Retention: cfg.OCR.CaptureRetention,
+		}, logger)
+		if err != nil {
+			logger.Warn("验证码样本采集初始化失败，已跳过", zap.Error(err))
+		} else {
+			automationSvc.SetCaptchaStore(captureStore)
+		}
+	}
 
 	// 初始化Worker Manager
 	workerConfig := worker.ManagerConfig{
@@ -160,17 +240,20 @@ func main() {
 		accountRepo,
 		redeemRepo,
 		logRepo,
+		eventBus,
 		logger,
 	)
 
+	workerManager.SetNotifier(webhookService)
+
 	// 启动Worker Manager
 	if err := workerManager.Start(); err != nil {
 		logger.Fatal("启动Worker管理器失败", zap.Error(err))
 	}
-	defer workerManager.Stop()
 
 	// 初始化Service（先账号与兑换服务）
 	accountService := service.NewAccountService(accountRepo, gameClient, logger)
+	accountService.SetWebhookService(webhookService)
 	redeemService := service.NewRedeemService(
 		redeemRepo,
 		accountRepo,
@@ -190,6 +273,10 @@ func main() {
 		return nil
 	}
 
+	// 额度预警与用尽后的热更新
+	ocrKeySvc.SetQuotaAlerts(webhookService, cfg.OCR.QuotaWarnThresholds, reloadFunc)
+	ocrKeySvc.StartUsageFlusher(cfg.OCR.UsageFlushInterval)
+
 	// 初始化定时任务服务（新增：账户服务、OCR服务、热更新函数）
 	cronService := service.NewCronService(
 		redeemRepo,
@@ -206,47 +293,97 @@ func main() {
 		cfg.RSS.FeedURL,
 		cfg.RSS.UpdateURL,
 	)
+	cronService.SetWebhookService(webhookService)
+	// 玩家自助服务（昵称验证码证明账号归属后签发token）
+	if cfg.Security.PlayerChallengeSecret == "" {
+		logger.Warn("⚠️ 未配置 PLAYER_CHALLENGE_SECRET，使用随机密钥；多实例部署需配置相同的值")
+	}
+	playerService := service.NewPlayerService(accountRepo, logRepo, playerTokenRepo, accountService, gameClient, cfg.Security.PlayerChallengeSecret, logger)
+	playerService.StartTokenSweeper(time.Hour)
+	// 管理操作审计
+	auditService := service.NewAuditService(auditRepo, logger)
+	// 自动化客户端 API Key
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, logger)
 	// 初始化Admin服务（依赖cronService）
-	adminService := service.NewAdminService(adminRepo, accountService, cronService, logger)
+	adminService := service.NewAdminService(adminRepo, accountService, cronService, service.LegacyLoginOptions{
+		Enabled: cfg.Security.AdminLegacyLogin,
+		Role:    cfg.Security.AdminLegacyRole,
+	}, auditService, logger)
 
 	// 启动定时任务
 	if err := cronService.Start(); err != nil {
 		logger.Fatal("启动定时任务失败", zap.Error(err))
 	}
-	defer cronService.Stop()
 
 	// 初始化Handler
 	accountHandler := handler.NewAccountHandler(accountService, logger)
+	playerHandler := handler.NewPlayerHandler(playerService, logger)
 	adminHandler := handler.NewAdminHandler(adminService, logger)
 	// OCR Key 管理路由，所有变更后自动热更新
 	ocrKeyHandler := handler.NewOCRKeyHandler(ocrKeySvc, logger, reloadFunc)
 	redeemHandler := handler.NewRedeemHandler(redeemService, logger)
+	eventsHandler := handler.NewEventsHandler(eventBus, redeemService, cfg.Events.Heartbeat, logger)
+	auditHandler := handler.NewAuditHandler(auditService, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, logger)
+	webhookHandler := handler.NewWebhookHandler(webhookService, logger)
+	healthHandler := handler.NewHealthHandler(
+		service.NewHealthService(db, ocrManager, gameClient, workerManager, cronService, cfg.Health, logger),
+		logger,
+	)
 
-	// 设置中间件
-	router.Use(handler.CORSMiddleware())
+	// 存活与就绪检查
+	router.GET("/health", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
 
-	// 创建认证中间件
-	authMiddleware := handler.AuthMiddleware(adminService)
+	// 创建认证中间件；/api/admin 之外需要管理员角色的路由经 adminRouter 注册，同时套用管理接口CORS策略
+	authMiddleware := handler.AuthMiddleware(adminService, apiKeyService, logger)
+	adminRouter := handler.NewAdminRouter(authMiddleware, cfg.CORS.Admin)
+
+	// 设置中间件
+	router.Use(handler.CORSMiddleware(cfg.CORS.Default, map[string]config.CORSPolicy{
+		"/api/me":    cfg.CORS.Player,
+		"/api/admin": cfg.CORS.Admin,
+	}, adminRouter))
+
+	// 创建登录防爆破中间件
+	loginGuardMiddleware := handler.LoginGuardMiddleware(handler.NewLoginGuard(handler.LoginGuardOptions{
+		MaxAttempts:     cfg.Security.LoginMaxAttempts,
+		BaseLockout:     cfg.Security.LoginLockoutBase,
+		MaxLockout:      cfg.Security.LoginLockoutMax,
+		GlobalMaxPerMin: cfg.Security.LoginGlobalMaxPerMin,
+	}), logger)
 
 	// 创建签名验证中间件
-	signMiddleware := handler.SignVerificationMiddleware(cfg.Security.AccountAddSalt, logger)
+	var nonceStore handler.NonceStore = handler.NewMemoryNonceStore()
+	if cfg.Security.SignNonceDB {
+		nonceStore = handler.NewLayeredNonceStore(repository.NewSignNonceRepository(db.GetDB(), logger), logger)
+	}
+	signMiddleware := handler.SignVerificationMiddleware(handler.SignVerifyOptions{
+		Salt:        cfg.Security.AccountAddSalt,
+		MaxSkew:     cfg.Security.SignMaxSkew,
+		NonceStore:  nonceStore,
+		RateLimiter: handler.NewIPRateLimiter(cfg.Security.SignRateLimitPerMin, cfg.Security.SignRateLimitBurst),
+	}, logger)
+
+	// 创建玩家token验证中间件
+	playerAuthMiddleware := handler.PlayerAuthMiddleware(playerService, logger)
 
 	// 注册API路由
 	api := router.Group("/api")
+	// 审计管理员写操作（身份由各路由的认证中间件解析）
+	api.Use(handler.AuditMiddleware(auditService))
 	{
-		// 健康检查（供反向代理下 /api/health 使用）
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"status":    "ok",
-				"timestamp": time.Now().Format(time.RFC3339),
-				"service":   "wjdr-backend-go",
-				"version":   "1.0.0",
-			})
-		})
-		accountHandler.RegisterRoutes(api, authMiddleware, signMiddleware)
-		adminHandler.RegisterRoutes(api, authMiddleware)
+		// 健康检查（/api/health 供反向代理下使用）
+		healthHandler.RegisterRoutes(api, authMiddleware)
+		accountHandler.RegisterRoutes(api, adminRouter, signMiddleware)
+		playerHandler.RegisterRoutes(api, playerAuthMiddleware, signMiddleware)
+		adminHandler.RegisterRoutes(api, authMiddleware, loginGuardMiddleware)
 		ocrKeyHandler.RegisterRoutes(api, authMiddleware)
-		redeemHandler.RegisterRoutes(api, authMiddleware)
+		redeemHandler.RegisterRoutes(api, adminRouter)
+		eventsHandler.RegisterRoutes(api, adminRouter)
+		auditHandler.RegisterRoutes(api, authMiddleware)
+		apiKeyHandler.RegisterRoutes(api, authMiddleware)
+		webhookHandler.RegisterRoutes(api, authMiddleware)
 	}
 
 	// 测试API端点
@@ -287,6 +424,24 @@ func main() {
 		})
 	})
 
+	// Prometheus 指标（METRICS_ENABLED 开启）：未配置 METRICS_ADDR 时挂载在主服务且无鉴权，建议单独监听并只对内网开放
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Addr == "" {
+			router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+		} else {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			metricsSrv = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux}
+			go func() {
+				logger.Info("📈 指标服务启动", zap.String("addr", cfg.Metrics.Addr))
+				if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Error("指标服务启动失败", zap.Error(err))
+				}
+			}()
+		}
+	}
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -294,6 +449,8 @@ func main() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	// 关闭时结束 SSE 长连接，Shutdown 无需等到超时
+	srv.RegisterOnShutdown(eventsHandler.Shutdown)
 
 	// 在goroutine中启动服务器
 	go func() {
@@ -317,8 +474,24 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if metricsSrv != nil {
+		_ = metricsSrv.Shutdown(ctx)
+	}
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("服务器强制关闭", zap.Error(err))
+		// 继续执行后续清理（停止任务、写入未落库的使用统计、关闭识别进程、刷新链路追踪与延迟关闭的数据库）
+		logger.Error("服务器强制关闭", zap.Error(err))
+	}
+	// 先停止定时任务与Worker（等待进行中的任务结束），其后不再有识别调用，再写入最后一批使用统计
+	cronService.Stop()
+	workerManager.Stop()
+	playerService.StopTokenSweeper()
+	ocrKeySvc.StopUsageFlusher()
+	// 识别进程池须在 Worker 全部退出后关闭；等待任务可能已用完上面的关闭时间，单独计时
+	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cleanupCancel()
+	client.ShutdownPaddleOCR(cleanupCtx)
+	if err := shutdownTracing(cleanupCtx); err != nil {
+		logger.Warn("链路追踪数据刷新失败", zap.Error(err))
 	}
 
 	logger.Info("服务器已关闭")
@@ -0,0 +1,21 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- ocr_keys 新增每日额度：daily_quota 为每日额度（NULL 表示不限），remaining_daily_quota 每天0点重置
+-- monthly_quota 改为可空：NULL 表示不限月额度，0 仍表示无额度（已有数据保持原含义）
+-- disabled_reason 记录 has_quota=false 的原因（quota/auth/manual），额度重置只恢复因额度停用的 Key
+
+USE wjdr;
+
+ALTER TABLE ocr_keys
+    MODIFY COLUMN monthly_quota INT NULL DEFAULT NULL COMMENT '每月额度，NULL 表示不限',
+    ADD COLUMN daily_quota INT NULL DEFAULT NULL COMMENT '每日额度，NULL 表示不限' AFTER remaining_quota,
+    ADD COLUMN remaining_daily_quota INT NOT NULL DEFAULT 0 COMMENT '当日剩余额度' AFTER daily_quota,
+    ADD COLUMN disabled_reason VARCHAR(16) NOT NULL DEFAULT '' COMMENT 'has_quota=false 的原因：quota/auth/manual' AFTER has_quota;
+
+-- 已停用的 Key：月额度已用尽的视为额度停用，其余无法区分（可能是鉴权错误或手动停用），按手动停用处理，不会被自动恢复
+UPDATE ocr_keys SET disabled_reason = 'quota' WHERE has_quota = FALSE AND monthly_quota IS NOT NULL AND remaining_quota <= 0;
+UPDATE ocr_keys SET disabled_reason = 'manual' WHERE has_quota = FALSE AND disabled_reason = '';
+
+-- 验证列是否创建成功
+SELECT 'OCR key daily quota columns added successfully' as message;
+SHOW COLUMNS FROM ocr_keys LIKE '%_quota';
+SHOW COLUMNS FROM ocr_keys LIKE 'disabled_reason';
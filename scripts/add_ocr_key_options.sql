@@ -0,0 +1,11 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- ocr_keys 新增 options 列：provider 自定义配置（如 http provider 的请求模板）
+
+USE wjdr;
+
+ALTER TABLE ocr_keys
+    ADD COLUMN options TEXT NULL COMMENT 'provider 自定义配置（JSON），http：{"format":"json|form|multipart","image_field":"image","result_path":"data.text",...}' AFTER secret_key;
+
+-- 验证列是否创建成功
+SELECT 'OCR key options column added successfully' as message;
+SHOW COLUMNS FROM ocr_keys LIKE 'options';
@@ -0,0 +1,11 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- ocr_keys 新增 qps_limit 列：每个 Key 的每秒请求上限（0 表示使用 provider 默认值，baidu 为 2）
+
+USE wjdr;
+
+ALTER TABLE ocr_keys
+    ADD COLUMN qps_limit DOUBLE NOT NULL DEFAULT 0 COMMENT '每秒请求上限，0 表示使用 provider 默认值' AFTER weight;
+
+-- 验证列是否创建成功
+SELECT 'OCR key qps_limit column added successfully' as message;
+SHOW COLUMNS FROM ocr_keys LIKE 'qps_limit';
@@ -0,0 +1,12 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- ocr_keys 新增 verified_correct / verified_wrong 列：游戏对已提交验证码的累计判定（40103 计为错误）
+
+USE wjdr;
+
+ALTER TABLE ocr_keys
+    ADD COLUMN verified_correct INT NOT NULL DEFAULT 0 COMMENT '游戏判定正确次数' AFTER fail_count,
+    ADD COLUMN verified_wrong INT NOT NULL DEFAULT 0 COMMENT '游戏判定错误次数（40103）' AFTER verified_correct;
+
+-- 验证列是否创建成功
+SELECT 'OCR key verdict columns added successfully' as message;
+SHOW COLUMNS FROM ocr_keys LIKE 'verified_%';
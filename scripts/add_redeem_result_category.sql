@@ -0,0 +1,20 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 兑换结果分类：redeem_logs 记录分类，redeem_codes 缓存按分类的计数
+
+USE wjdr;
+
+-- 兑换日志新增结果分类列
+ALTER TABLE redeem_logs
+    ADD COLUMN result_category VARCHAR(32) NULL COMMENT '结果分类：success, already_redeemed, limit_reached, expired, ineligible, technical' AFTER result,
+    ADD INDEX idx_code_category (redeem_code_id, result_category);
+
+-- 兑换码新增分类统计列
+ALTER TABLE redeem_codes
+    ADD COLUMN category_stats JSON NULL COMMENT '按结果分类的计数，如 {"success": 10, "already_redeemed": 3}';
+
+-- 执行后调用 POST /api/accounts/fix-stats：按 client.ClassifyRedeemResult 回填历史日志的分类，并重算各兑换码的 category_stats
+
+-- 验证列是否创建成功
+SELECT 'Redeem result category columns added successfully' as message;
+SHOW COLUMNS FROM redeem_logs LIKE 'result_category';
+SHOW COLUMNS FROM redeem_codes LIKE 'category_stats';
@@ -0,0 +1,32 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增admin_users表（具名管理员 + 角色），admin_tokens 绑定管理员
+
+USE wjdr;
+
+-- 创建管理员账号表（password_hash 为 bcrypt 摘要）
+CREATE TABLE IF NOT EXISTS admin_users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(64) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role ENUM('viewer', 'operator', 'owner') NOT NULL DEFAULT 'viewer' COMMENT 'viewer只读，operator执行兑换与账号维护，owner管理管理员与凭据',
+    is_active BOOLEAN DEFAULT TRUE,
+    last_login_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_username (username)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员账号表';
+
+-- token 绑定管理员与角色（旧版共享密码登录的 token user_id 为空）
+ALTER TABLE admin_tokens
+    ADD COLUMN user_id INT NULL COMMENT '所属管理员，共享密码登录为空' AFTER token,
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'owner' COMMENT '签发时的角色' AFTER user_id,
+    ADD INDEX idx_user_id (user_id);
+
+-- 创建第一个 owner：go run ./cmd/wjdr-cli bootstrap-owner -username <用户名> -password <密码>
+
+-- 验证表是否创建成功
+SELECT 'Admin users table created successfully' as message;
+SHOW TABLES LIKE 'admin_users';
+DESCRIBE admin_users;
+DESCRIBE admin_tokens;
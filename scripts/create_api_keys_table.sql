@@ -0,0 +1,28 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增api_keys表：供机器人等自动化客户端使用的长期API Key（按权限范围授权）
+
+USE wjdr;
+
+-- 创建API Key表（仅保存SHA256摘要）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL COMMENT '用途说明，如 群聊机器人',
+    key_prefix VARCHAR(16) NOT NULL COMMENT '明文前缀，便于识别',
+    key_hash CHAR(64) NOT NULL COMMENT 'API Key的SHA256摘要',
+    scopes JSON NOT NULL COMMENT '权限范围，如 ["redeem:submit"]',
+    allowed_ips JSON NULL COMMENT 'IP/CIDR白名单，空表示不限制',
+    is_active BOOLEAN DEFAULT TRUE,
+    expires_at TIMESTAMP NULL COMMENT '过期时间，空表示永不过期',
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(64) NULL,
+    created_by INT NULL COMMENT '创建者 admin_users.id',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_key_hash (key_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='自动化客户端API Key表';
+
+-- 验证表是否创建成功
+SELECT 'API keys table created successfully' as message;
+SHOW TABLES LIKE 'api_keys';
+DESCRIBE api_keys;
@@ -0,0 +1,33 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增audit_events表，记录管理员的写操作（谁、何时、做了什么、结果）
+
+USE wjdr;
+
+-- 创建审计记录表
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_user_id INT NULL COMMENT '操作管理员ID，共享密码登录为空',
+    actor_name VARCHAR(64) NOT NULL COMMENT '操作人用户名，共享密码登录为 legacy',
+    actor_role VARCHAR(16) NOT NULL COMMENT '操作时的角色',
+    actor_token_id INT NULL COMMENT '使用的admin_tokens记录ID',
+    action VARCHAR(64) NOT NULL COMMENT '动作，如 account.delete、ocr_key.update',
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    target_ids VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '目标ID，逗号分隔',
+    request_summary TEXT NULL COMMENT '请求体摘要（敏感字段已脱敏）',
+    result ENUM('success', 'failed') NOT NULL,
+    status_code INT NOT NULL,
+    error_message TEXT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_created_at (created_at),
+    INDEX idx_actor_name (actor_name, created_at),
+    INDEX idx_action (action, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理操作审计表';
+
+-- 验证表是否创建成功
+SELECT 'Audit events table created successfully' as message;
+SHOW TABLES LIKE 'audit_events';
+DESCRIBE audit_events;
@@ -0,0 +1,25 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增player_tokens表用于玩家自助访问（/api/me）
+
+USE wjdr;
+
+-- 创建玩家访问令牌表（仅保存token的SHA256摘要）
+CREATE TABLE IF NOT EXISTS player_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    game_account_id INT NOT NULL COMMENT '绑定的游戏账号ID',
+    fid VARCHAR(50) NOT NULL COMMENT '绑定的FID',
+    token_hash CHAR(64) NOT NULL COMMENT 'token的SHA256摘要',
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_token_hash (token_hash),
+    INDEX idx_account (game_account_id),
+    INDEX idx_expires_at (expires_at),
+    CONSTRAINT fk_player_tokens_account FOREIGN KEY (game_account_id) REFERENCES game_accounts(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='玩家访问令牌表';
+
+-- 验证表是否创建成功
+SELECT 'Player tokens table created successfully' as message;
+SHOW TABLES LIKE 'player_tokens';
+DESCRIBE player_tokens;
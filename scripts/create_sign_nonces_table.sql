@@ -0,0 +1,18 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增sign_nonces表用于签名防重放（SIGN_NONCE_DB=true 时启用）
+
+USE wjdr;
+
+-- 创建已用签名表
+CREATE TABLE IF NOT EXISTS sign_nonces (
+    nonce VARCHAR(128) PRIMARY KEY COMMENT '已使用的签名',
+    expires_at TIMESTAMP NOT NULL COMMENT '超过时间窗口后可清理',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='签名防重放表';
+
+-- 验证表是否创建成功
+SELECT 'Sign nonces table created successfully' as message;
+SHOW TABLES LIKE 'sign_nonces';
+DESCRIBE sign_nonces;
@@ -0,0 +1,40 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- 新增webhooks/webhook_deliveries表：出站Webhook订阅（HMAC签名）与投递记录
+
+USE wjdr;
+
+-- 创建Webhook订阅表
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL COMMENT '用途说明，如 企业微信群通知',
+    url VARCHAR(512) NOT NULL COMMENT '接收地址（http/https）',
+    secret VARCHAR(128) NOT NULL COMMENT 'HMAC-SHA256签名密钥',
+    events JSON NULL COMMENT '订阅事件，如 ["batch.completed"]，空表示全部',
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INT NULL COMMENT '创建者 admin_users.id',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='出站Webhook订阅表';
+
+-- 创建Webhook投递记录表
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id INT NOT NULL COMMENT 'webhooks.id',
+    event VARCHAR(64) NOT NULL COMMENT '事件名，如 batch.completed',
+    payload TEXT NOT NULL COMMENT '投递的请求体',
+    status ENUM('pending', 'success', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+    response_status INT NULL COMMENT '最近一次响应状态码',
+    error_message VARCHAR(1000) NULL COMMENT '最近一次失败原因',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP NULL COMMENT '投递成功时间',
+
+    INDEX idx_webhook_id (webhook_id, id),
+    INDEX idx_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Webhook投递记录表';
+
+-- 验证表是否创建成功
+SELECT 'Webhook tables created successfully' as message;
+SHOW TABLES LIKE 'webhook%';
+DESCRIBE webhooks;
+DESCRIBE webhook_deliveries;
@@ -0,0 +1,14 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- ocr_keys 凭据加密存储：api_key/secret_key 改为保存 AES-GCM 密文（enc:v1:<主密钥ID>:<base64>），新增 api_key_hint 保存明文末尾 4 位用于脱敏展示
+-- 执行后配置 CREDENTIAL_KEYS 并运行 `wjdr-cli reencrypt-ocr-keys` 加密已有的明文凭据
+
+USE wjdr;
+
+ALTER TABLE ocr_keys
+    MODIFY COLUMN api_key VARCHAR(1024) NOT NULL COMMENT 'API Key（加密存储）',
+    MODIFY COLUMN secret_key VARCHAR(1024) NOT NULL DEFAULT '' COMMENT 'Secret Key（加密存储）',
+    ADD COLUMN api_key_hint VARCHAR(8) NOT NULL DEFAULT '' COMMENT 'API Key 末尾 4 位（脱敏展示）' AFTER secret_key;
+
+-- 验证列是否修改成功
+SELECT 'OCR key credential columns updated successfully' as message;
+SHOW COLUMNS FROM ocr_keys WHERE Field IN ('api_key', 'secret_key', 'api_key_hint');
@@ -0,0 +1,24 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- admin_tokens 改为仅保存token的SHA256摘要，并记录签发IP/User-Agent与最近使用时间
+
+USE wjdr;
+
+-- 新增摘要与会话信息列
+ALTER TABLE admin_tokens
+    ADD COLUMN token_hash CHAR(64) NULL COMMENT 'token的SHA256摘要（十六进制）' AFTER id,
+    ADD COLUMN created_ip VARCHAR(64) NOT NULL DEFAULT '' COMMENT '签发时的客户端IP' AFTER role,
+    ADD COLUMN user_agent VARCHAR(512) NOT NULL DEFAULT '' COMMENT '签发时的User-Agent' AFTER created_ip,
+    ADD COLUMN last_used_at TIMESTAMP NULL COMMENT '最近使用时间' AFTER user_agent;
+
+-- 回填已签发token的摘要（与 utils.HashToken 一致），现有会话无需重新登录
+UPDATE admin_tokens SET token_hash = SHA2(token, 256) WHERE token_hash IS NULL;
+
+-- 摘要唯一索引，删除明文token列
+ALTER TABLE admin_tokens
+    MODIFY COLUMN token_hash CHAR(64) NOT NULL COMMENT 'token的SHA256摘要（十六进制）',
+    ADD UNIQUE KEY uk_token_hash (token_hash),
+    DROP COLUMN token;
+
+-- 验证表结构
+SELECT 'Admin tokens hashed successfully' as message;
+DESCRIBE admin_tokens;
@@ -0,0 +1,75 @@
+#!/usr/bin/env python3
+# -*- coding: utf-8 -*-
+"""
+PaddleOCR 常驻识别进程（由 Go 服务按 PADDLE_OCR_POOL_SIZE 拉起并监督，一般无需手动运行）
+
+模型只在启动时加载一次，之后通过 stdin/stdout 逐行交换 JSON：
+  启动完成   -> {"ready": true}
+  识别请求   <- {"id": 1, "image": "<base64 PNG/JPEG>"}
+  识别结果   -> {"id": 1, "text": "AB12", "score": 0.99}  或 {"id": 1, "error": "..."}
+  健康检查   <- {"id": 2, "cmd": "ping"}                -> {"id": 2, "pong": true}
+stdin 关闭时退出。除 --paddleocr_dir 外的参数原样交给 PaddleOCR 的 utility.parse_args，
+与 predict_rec.py 命令行模式一致（--rec_model_dir、--rec_char_dict_path、--rec_image_shape 等）。
+"""
+import argparse
+import base64
+import json
+import os
+import sys
+
+
+def main():
+    parser = argparse.ArgumentParser(add_help=False)
+    parser.add_argument("--paddleocr_dir", required=True, help="PaddleOCR 仓库根目录")
+    own, rest = parser.parse_known_args()
+
+    # 协议只使用原始 stdout，Paddle 的日志/打印全部转到 stderr
+    out = sys.stdout
+    sys.stdout = sys.stderr
+
+    paddle_dir = os.path.abspath(own.paddleocr_dir)
+    sys.path.insert(0, paddle_dir)
+    sys.argv = [sys.argv[0]] + rest
+
+    import cv2
+    import numpy as np
+    import tools.infer.utility as utility
+    from tools.infer.predict_rec import TextRecognizer
+
+    recognizer = TextRecognizer(utility.parse_args())
+
+    def reply(obj):
+        out.write(json.dumps(obj, ensure_ascii=False) + "\n")
+        out.flush()
+
+    reply({"ready": True})
+
+    for line in sys.stdin:
+        line = line.strip()
+        if not line:
+            continue
+        try:
+            req = json.loads(line)
+        except ValueError as e:
+            reply({"error": "bad request: %s" % e})
+            continue
+
+        rid = req.get("id")
+        if req.get("cmd") == "ping":
+            reply({"id": rid, "pong": True})
+            continue
+
+        try:
+            data = base64.b64decode(req.get("image", ""))
+            img = cv2.imdecode(np.frombuffer(data, np.uint8), cv2.IMREAD_COLOR)
+            if img is None:
+                raise ValueError("invalid image")
+            rec_res, _ = recognizer([img])
+            text, score = rec_res[0]
+            reply({"id": rid, "text": text, "score": float(score)})
+        except Exception as e:  # noqa: BLE001 单张失败不影响进程
+            reply({"id": rid, "error": str(e)})
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,12 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- api_keys 权限范围 accounts:read 更名为 redeem:read（该权限仅用于兑换进度事件流）
+
+USE wjdr;
+
+UPDATE api_keys
+SET scopes = CAST(REPLACE(CAST(scopes AS CHAR), '"accounts:read"', '"redeem:read"') AS JSON)
+WHERE JSON_CONTAINS(scopes, '"accounts:read"');
+
+-- 验证是否已无旧权限范围
+SELECT 'API key scope accounts:read renamed to redeem:read' as message;
+SELECT COUNT(*) AS remaining FROM api_keys WHERE JSON_CONTAINS(scopes, '"accounts:read"');
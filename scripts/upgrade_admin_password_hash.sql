@@ -0,0 +1,14 @@
+-- 无尽冬日Go版本数据库迁移脚本
+-- admin_passwords 摘要由无盐SHA256升级为 bcrypt：放宽列宽，旧摘要在下次登录成功时自动升级
+
+USE wjdr;
+
+ALTER TABLE admin_passwords
+    MODIFY COLUMN password_hash VARCHAR(255) NOT NULL COMMENT 'bcrypt摘要（旧版为64位SHA256，登录后自动升级）';
+
+-- 查看尚未升级的密码（升级完成后应为空）
+SELECT id, description FROM admin_passwords WHERE password_hash NOT LIKE '$2%';
+
+-- 验证列是否修改成功
+SELECT 'Admin password hash column upgraded successfully' as message;
+SHOW COLUMNS FROM admin_passwords LIKE 'password_hash';